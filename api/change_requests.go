@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	util "github.com/datatogether/api/apiutil"
+	"github.com/qri-io/dsdiff"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+)
+
+// ChangeRequestHandlers wraps a ChangeRequestRequests with http.HandlerFuncs
+type ChangeRequestHandlers struct {
+	core.ChangeRequestRequests
+}
+
+// NewChangeRequestHandlers allocates a ChangeRequestHandlers pointer
+func NewChangeRequestHandlers(r repo.Repo, node *p2p.QriNode) *ChangeRequestHandlers {
+	req := core.NewChangeRequestRequests(r, nil)
+	req.Node = node
+	return &ChangeRequestHandlers{*req}
+}
+
+// ChangeRequestsHandler lists change requests, and proposes new ones
+func (h *ChangeRequestHandlers) ChangeRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.listChangeRequestsHandler(w, r)
+	case "POST":
+		h.createChangeRequestHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// DiffHandler compares the version a change request proposes with the
+// version it was proposed against
+func (h *ChangeRequestHandlers) DiffHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.diffHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// AcceptHandler commits the version a change request proposes
+func (h *ChangeRequestHandlers) AcceptHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.reviewHandler(w, r, h.Accept)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// RejectHandler closes a change request without committing it
+func (h *ChangeRequestHandlers) RejectHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.reviewHandler(w, r, h.Reject)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *ChangeRequestHandlers) listChangeRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	lp := core.ListParamsFromRequest(r)
+	p := &core.ChangeRequestListParams{
+		Incoming: r.FormValue("incoming") == "true",
+		Outgoing: r.FormValue("outgoing") == "true",
+		Status:   repo.ChangeRequestStatus(r.FormValue("status")),
+		Limit:    lp.Limit,
+		Offset:   lp.Offset,
+	}
+
+	res := []*repo.ChangeRequest{}
+	if err := h.List(p, &res); err != nil {
+		log.Infof("error listing change requests: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WritePageResponse(w, res, r, lp.Page())
+}
+
+func (h *ChangeRequestHandlers) createChangeRequestHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.CreateChangeRequestParams{}
+	switch r.Header.Get("Content-Type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("error decoding body into params: %s", err.Error()))
+			return
+		}
+	default:
+		p.Target = r.FormValue("target")
+		p.Version = r.FormValue("version")
+		p.Title = r.FormValue("title")
+	}
+
+	res := &repo.ChangeRequest{}
+	if err := h.Create(p, res); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *ChangeRequestHandlers) diffHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue("id")
+	if id == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("id is required"))
+		return
+	}
+
+	res := map[string]*dsdiff.SubDiff{}
+	if err := h.Diff(&id, &res); err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *ChangeRequestHandlers) reviewHandler(w http.ResponseWriter, r *http.Request, review func(*core.ReviewParams, *repo.ChangeRequest) error) {
+	p := &core.ReviewParams{}
+	switch r.Header.Get("Content-Type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("error decoding body into params: %s", err.Error()))
+			return
+		}
+	default:
+		p.ID = r.FormValue("id")
+		p.Comment = r.FormValue("comment")
+	}
+	if p.ID == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("id is required"))
+		return
+	}
+
+	res := &repo.ChangeRequest{}
+	if err := review(p, res); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
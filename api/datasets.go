@@ -17,7 +17,9 @@ import (
 	"github.com/qri-io/dataset/dsutil"
 	"github.com/qri-io/dsdiff"
 	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/render"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/actions"
 )
 
 // DatasetHandlers wraps a requests struct to interface with http.HandlerFunc
@@ -154,6 +156,66 @@ func (h *DatasetHandlers) RenameHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// RevertHandler is the endpoint for reverting a dataset to an earlier version
+func (h *DatasetHandlers) RevertHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST", "PUT":
+		h.revertHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// ResetHandler is the endpoint for moving a dataset head to an earlier version
+func (h *DatasetHandlers) ResetHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST", "PUT":
+		h.resetHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// GraphHandler is the endpoint for the lineage graph of datasets
+func (h *DatasetHandlers) GraphHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.graphHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// ReadmeHandler is the endpoint for a dataset's readme
+func (h *DatasetHandlers) ReadmeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.readmeHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// RenderHandler is the endpoint for charts of a dataset's data
+func (h *DatasetHandlers) RenderHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.renderHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 // DataHandler gets a dataset's data
 func (h *DatasetHandlers) DataHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -200,6 +262,8 @@ func (h *DatasetHandlers) zipDatasetHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	actions.RecordUsage(h.repo, repo.UsageExported, *res)
+
 	w.Header().Set("Content-Type", "application/zip")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("filename=\"%s.zip\"", "dataset"))
 	dsutil.WriteZipArchive(h.repo.Store(), res.Dataset, w)
@@ -433,6 +497,7 @@ type saveParamsJSON struct {
 	Data      json.RawMessage `json:"data,omitempty"`
 	Meta      json.RawMessage `json:"meta,omitempty"`
 	Structure json.RawMessage `json:"structure,omitempty"`
+	Readme    string          `json:"readme,omitempty"`
 }
 
 func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
@@ -480,6 +545,10 @@ func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
 			save.Structure = cafs.NewMemfileReader("structure.json", bytes.NewReader(saveParams.Structure))
 			save.StructureFilename = "structure.json"
 		}
+		if saveParams.Readme != "" {
+			save.Readme = strings.NewReader(saveParams.Readme)
+			save.ReadmeFilename = "readme.md"
+		}
 	} else {
 		save = &core.SaveParams{
 			Peername: r.FormValue("peername"),
@@ -518,6 +587,16 @@ func (h *DatasetHandlers) saveHandler(w http.ResponseWriter, r *http.Request) {
 			save.Structure = cafs.NewMemfileReader(structureHeader.Filename, structurefile)
 			save.StructureFilename = structureHeader.Filename
 		}
+
+		readmefile, readmeHeader, err := r.FormFile("readme")
+		if err != nil && err != http.ErrMissingFile {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("error opening readme file: %s", err))
+			return
+		}
+		if readmefile != nil {
+			save.Readme = readmefile
+			save.ReadmeFilename = readmeHeader.Filename
+		}
 	}
 
 	res := &repo.DatasetRef{}
@@ -550,6 +629,50 @@ func (h *DatasetHandlers) removeHandler(w http.ResponseWriter, r *http.Request)
 	util.WriteResponse(w, ref)
 }
 
+func (h *DatasetHandlers) revertHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := DatasetRefFromPath(r.URL.Path[len("/revert"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if ref.Name == "" || ref.Path == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("need dataset name and version path: '/revert/[peername]/[datasetname]/at/[path]'"))
+		return
+	}
+
+	res := &repo.DatasetRef{}
+	if err := h.Revert(&ref, res); err != nil {
+		log.Infof("error reverting dataset: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) resetHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := DatasetRefFromPath(r.URL.Path[len("/reset"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if ref.Name == "" || ref.Path == "" {
+		util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("need dataset name and version path: '/reset/[peername]/[datasetname]/at/[path]'"))
+		return
+	}
+
+	res := &repo.DatasetRef{}
+	if err := h.Reset(&ref, res); err != nil {
+		log.Infof("error resetting dataset: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
 // RenameReqParams is an encoding struct
 // its intent is to be a more user-friendly structure for the api endpoint
 // that will map to and from the core.RenameParams struct
@@ -653,6 +776,7 @@ func (h DatasetHandlers) dataHandler(w http.ResponseWriter, r *http.Request) {
 		util.WriteErrResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	actions.RecordUsage(h.repo, repo.UsageRead, d)
 
 	page := util.PageFromRequest(r)
 	dataResponse := DataResponse{
@@ -663,3 +787,108 @@ func (h DatasetHandlers) dataHandler(w http.ResponseWriter, r *http.Request) {
 		log.Infof("error writing repsonse: %s", err.Error())
 	}
 }
+
+func (h *DatasetHandlers) graphHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.GraphParams{
+		Ancestry: r.FormValue("ancestry") == "true",
+		Derived:  r.FormValue("derived") == "true",
+	}
+	if len(r.URL.Path) > len("/graph/") {
+		ref, err := DatasetRefFromPath(r.URL.Path[len("/graph"):])
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		p.Ref = ref
+	}
+
+	res := &repo.GraphData{}
+	if err := h.Graph(p, res); err != nil {
+		log.Infof("error getting graph: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if r.FormValue("format") == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		if err := res.WriteDOT(w); err != nil {
+			log.Info(err.Error())
+		}
+		return
+	}
+
+	util.WriteResponse(w, res)
+}
+
+func (h *DatasetHandlers) readmeHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := DatasetRefFromPath(r.URL.Path[len("/readme"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	p := &core.ReadmeParams{
+		Ref:  ref,
+		HTML: r.FormValue("format") != "md",
+	}
+	res := []byte{}
+	if err := h.Readme(p, &res); err != nil {
+		log.Infof("error getting readme: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(res) == 0 {
+		util.WriteErrResponse(w, http.StatusNotFound, fmt.Errorf("%s has no readme", ref.AliasString()))
+		return
+	}
+
+	if p.HTML {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	}
+	w.Write(res)
+}
+
+func (h *DatasetHandlers) renderHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := DatasetRefFromPath(r.URL.Path[len("/render"):])
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	format, err := render.ParseFormat(r.FormValue("format"))
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	p := &core.RenderParams{
+		Ref:    ref,
+		Format: string(format),
+	}
+	if p.Width, err = util.ReqParamInt("width", r); err != nil {
+		p.Width = 0
+	}
+	if p.Height, err = util.ReqParamInt("height", r); err != nil {
+		p.Height = 0
+	}
+	if err := render.CheckSize(p.Width, p.Height); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res := []byte{}
+	if err := h.Render(p, &res); err != nil {
+		log.Infof("error rendering dataset: %s", err.Error())
+		if err == render.ErrChartTooLarge {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Write(res)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	util "github.com/datatogether/api/apiutil"
+	"github.com/qri-io/qri/repo"
+)
+
+// eventsHeartbeat is how often an idle event stream sends a comment to keep
+// the connection open
+var eventsHeartbeat = 30 * time.Second
+
+// EventHandlers streams repo events over http
+type EventHandlers struct {
+	repo repo.Repo
+}
+
+// NewEventHandlers allocates an EventHandlers pointer
+func NewEventHandlers(r repo.Repo) *EventHandlers {
+	return &EventHandlers{repo: r}
+}
+
+// EventsHandler streams events as they're logged using Server-Sent Events.
+// Streams can be filtered with a comma-separated list of event types, and
+// a dataset reference:
+//
+//	GET /events?type=ds_created,ds_renamed&dataset=peer/movies
+func (h *EventHandlers) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.eventsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *EventHandlers) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := EventFilterFromRequest(h.repo, r)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		util.WriteErrResponse(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	sub := h.repo.Subscribe(filter)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.Info(err.Error())
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// EventFilterFromRequest reads event type & dataset filters from request
+// query params. dataset references are canonicalized against the repo, so
+// aliases like "me" match the events the repo publishes
+func EventFilterFromRequest(r repo.Repo, req *http.Request) (repo.EventFilter, error) {
+	f := repo.EventFilter{}
+	for _, param := range req.URL.Query()["type"] {
+		for _, t := range strings.Split(param, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				f.Types = append(f.Types, repo.EventType(t))
+			}
+		}
+	}
+
+	if ds := req.FormValue("dataset"); ds != "" {
+		ref, err := repo.ParseDatasetRef(ds)
+		if err != nil {
+			return f, fmt.Errorf("invalid dataset reference: %s", err.Error())
+		}
+		// canonicalizing fills in the dataset's current path, which would
+		// limit the filter to one version
+		path := ref.Path
+		if err := repo.CanonicalizeDatasetRef(r, &ref); err != nil {
+			return f, err
+		}
+		ref.Path = path
+		f.Ref = &ref
+	}
+	return f, nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/test"
+)
+
+func TestEventsHandler(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	h := NewEventHandlers(r)
+	server := httptest.NewServer(http.HandlerFunc(h.EventsHandler))
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/events?type=ds_renamed&dataset=peer/movies")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type mismatch. expected text/event-stream, got: %s", ct)
+	}
+
+	// headers are flushed after subscribing, so these events are delivered
+	r.LogEvent(repo.ETDsCreated, repo.DatasetRef{Peername: "peer", ProfileID: pro.ID, Name: "movies"})
+	r.LogEvent(repo.ETDsRenamed, repo.DatasetRef{Peername: "peer", ProfileID: pro.ID, Name: "movies"})
+
+	rdr := bufio.NewReader(res.Body)
+	line, err := rdr.ReadString('\n')
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if line != "event: ds_renamed\n" {
+		t.Errorf("expected filtered stream to start with a rename event, got: %q", line)
+	}
+	if line, _ = rdr.ReadString('\n'); !strings.HasPrefix(line, "data: {") || !strings.Contains(line, `"name":"movies"`) {
+		t.Errorf("expected event json data, got: %q", line)
+	}
+}
+
+func TestEventFilterFromRequest(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+
+	req := httptest.NewRequest("GET", "/events?type=ds_created,ds_pinned&type=ds_added&dataset=peer/cities", nil)
+	f, err := EventFilterFromRequest(r, req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(f.Types) != 3 || f.Types[2] != repo.ETDsAdded {
+		t.Errorf("expected 3 event types, got: %v", f.Types)
+	}
+	if f.Ref == nil || f.Ref.Peername != "peer" || f.Ref.Name != "cities" {
+		t.Errorf("dataset filter mismatch, got: %v", f.Ref)
+	}
+}
+
+func TestEventFilterFromRequestMe(t *testing.T) {
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := httptest.NewRequest("GET", "/events?dataset=me/movies", nil)
+	f, err := EventFilterFromRequest(r, req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if f.Ref == nil || f.Ref.Peername != pro.Peername || f.Ref.ProfileID != pro.ID || f.Ref.Path != "" {
+		t.Errorf("expected me to be canonicalized to the repo's profile, got: %v", f.Ref)
+	}
+
+	e := &repo.Event{Type: repo.ETDsRenamed, Ref: repo.DatasetRef{Peername: pro.Peername, ProfileID: pro.ID, Name: "movies"}}
+	if !f.Match(e) {
+		t.Errorf("expected filter to match an event for %s/movies", pro.Peername)
+	}
+}
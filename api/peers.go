@@ -1,6 +1,8 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	util "github.com/datatogether/api/apiutil"
@@ -58,6 +60,54 @@ func (h *PeerHandlers) PeerHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// FollowHandler adds a profile to this peer's follow list
+func (h *PeerHandlers) FollowHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		if h.ReadOnly {
+			readOnlyResponse(w, "/peers/follow")
+			return
+		}
+		h.relationshipHandler(w, r, h.Follow)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// UnfollowHandler removes a profile from this peer's follow list
+func (h *PeerHandlers) UnfollowHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		if h.ReadOnly {
+			readOnlyResponse(w, "/peers/unfollow")
+			return
+		}
+		h.relationshipHandler(w, r, h.Unfollow)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// TrustHandler sets how far this peer trusts a profile
+func (h *PeerHandlers) TrustHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		if h.ReadOnly {
+			readOnlyResponse(w, "/peers/trust")
+			return
+		}
+		h.relationshipHandler(w, r, h.SetTrust)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
 // ConnectToPeerHandler is the endpoint for explicitly connecting to a peer
 func (h *PeerHandlers) ConnectToPeerHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -87,7 +137,11 @@ func (h *PeerHandlers) ConnectionsHandler(w http.ResponseWriter, r *http.Request
 }
 
 func (h *PeerHandlers) listPeersHandler(w http.ResponseWriter, r *http.Request) {
-	args := core.ListParamsFromRequest(r)
+	args := core.PeerListParams{
+		ListParams: core.ListParamsFromRequest(r),
+		Following:  r.FormValue("following") == "true",
+		Trust:      profile.Trust(r.FormValue("trust")),
+	}
 	args.OrderBy = "created"
 	res := []*profile.Profile{}
 	if err := h.List(&args, &res); err != nil {
@@ -134,6 +188,35 @@ func (h *PeerHandlers) peerHandler(w http.ResponseWriter, r *http.Request) {
 	util.WriteResponse(w, res)
 }
 
+func (h *PeerHandlers) relationshipHandler(w http.ResponseWriter, r *http.Request, relate func(*core.RelationshipParams, *profile.Relationship) error) {
+	p := &core.RelationshipParams{}
+	switch r.Header.Get("Content-Type") {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, fmt.Errorf("error decoding body into params: %s", err.Error()))
+			return
+		}
+	default:
+		p.Peername = r.FormValue("peername")
+		p.Trust = profile.Trust(r.FormValue("trust"))
+		if idstr := r.FormValue("profile_id"); idstr != "" {
+			id, err := profile.IDB58Decode(idstr)
+			if err != nil {
+				util.WriteErrResponse(w, http.StatusBadRequest, err)
+				return
+			}
+			p.ProfileID = id
+		}
+	}
+
+	res := &profile.Relationship{}
+	if err := relate(p, res); err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
 func (h *PeerHandlers) namespaceHandler(w http.ResponseWriter, r *http.Request) {
 
 }
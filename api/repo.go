@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+
+	util "github.com/datatogether/api/apiutil"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/actions"
+)
+
+// RepoHandlers wraps a RepoRequests with http.HandlerFuncs
+type RepoHandlers struct {
+	core.RepoRequests
+}
+
+// NewRepoHandlers allocates a RepoHandlers pointer
+func NewRepoHandlers(r repo.Repo) *RepoHandlers {
+	req := core.NewRepoRequests(r, nil)
+	return &RepoHandlers{*req}
+}
+
+// DiskUsageHandler reports the storage each dataset uses
+func (h *RepoHandlers) DiskUsageHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.diskUsageHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+// StatsHandler reports how often each dataset was used
+func (h *RepoHandlers) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.statsHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *RepoHandlers) diskUsageHandler(w http.ResponseWriter, r *http.Request) {
+	in := true
+	res := &actions.UsageReport{}
+	if err := h.DiskUsage(&in, res); err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
+func (h *RepoHandlers) statsHandler(w http.ResponseWriter, r *http.Request) {
+	p := &core.StatsParams{}
+	if days, err := util.ReqParamInt("days", r); err == nil {
+		p.Days = days
+	}
+
+	res := []*actions.DatasetStats{}
+	if err := h.Stats(p, &res); err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	util.WriteResponse(w, res)
+}
@@ -210,6 +210,9 @@ func NewServerRoutes(s *Server) *http.ServeMux {
 	ph := NewPeerHandlers(s.qriNode.Repo, s.qriNode, s.cfg.API.ReadOnly)
 	m.Handle("/peers", s.middleware(ph.PeersHandler))
 	m.Handle("/peers/", s.middleware(ph.PeerHandler))
+	m.Handle("/peers/follow", s.middleware(ph.FollowHandler))
+	m.Handle("/peers/unfollow", s.middleware(ph.UnfollowHandler))
+	m.Handle("/peers/trust", s.middleware(ph.TrustHandler))
 
 	m.Handle("/connect/", s.middleware(ph.ConnectToPeerHandler))
 	m.Handle("/connections", s.middleware(ph.ConnectionsHandler))
@@ -230,12 +233,31 @@ func NewServerRoutes(s *Server) *http.ServeMux {
 	m.Handle("/export/", s.middleware(dsh.ZipDatasetHandler))
 	m.Handle("/diff", s.middleware(dsh.DiffHandler))
 	m.Handle("/data/", s.middleware(dsh.DataHandler))
+	m.Handle("/revert/", s.middleware(dsh.RevertHandler))
+	m.Handle("/reset/", s.middleware(dsh.ResetHandler))
+	m.Handle("/graph", s.middleware(dsh.GraphHandler))
+	m.Handle("/graph/", s.middleware(dsh.GraphHandler))
+	m.Handle("/readme/", s.middleware(dsh.ReadmeHandler))
+	m.Handle("/render/", s.middleware(dsh.RenderHandler))
 
 	hh := NewHistoryHandlers(s.qriNode.Repo)
 	// TODO - stupid hack for now.
 	hh.HistoryRequests.Node = s.qriNode
 	m.Handle("/history/", s.middleware(hh.LogHandler))
 
+	eh := NewEventHandlers(s.qriNode.Repo)
+	m.Handle("/events", s.middleware(eh.EventsHandler))
+
+	rph := NewRepoHandlers(s.qriNode.Repo)
+	m.Handle("/repo/du", s.middleware(rph.DiskUsageHandler))
+	m.Handle("/repo/stats", s.middleware(rph.StatsHandler))
+
+	crh := NewChangeRequestHandlers(s.qriNode.Repo, s.qriNode)
+	m.Handle("/requests", s.middleware(crh.ChangeRequestsHandler))
+	m.Handle("/requests/diff", s.middleware(crh.DiffHandler))
+	m.Handle("/requests/accept", s.middleware(crh.AcceptHandler))
+	m.Handle("/requests/reject", s.middleware(crh.RejectHandler))
+
 	rh := NewRootHandler(dsh, ph)
 	m.Handle("/", s.datasetRefMiddleware(s.middleware(rh.Handler)))
 
@@ -129,6 +129,18 @@ func TestServerRoutes(t *testing.T) {
 
 		{"GET", "/connect/", "", "", 400},
 
+		{"GET", "/repo/du", "", "", 200},
+		{"GET", "/repo/stats", "", "", 200},
+
+		{"POST", "/peers/follow", "", "", 400},
+		{"POST", "/peers/unfollow", "", "", 400},
+		{"POST", "/peers/trust", "", "", 400},
+
+		{"GET", "/requests", "", "", 200},
+		{"GET", "/requests/diff", "", "", 400},
+		{"POST", "/requests/accept", "", "", 400},
+		{"POST", "/requests/reject", "", "", 400},
+
 		// blatently checking all options for easy test coverage bump
 		{"OPTIONS", "/add", "", "", 200},
 		{"OPTIONS", "/add/", "", "", 200},
@@ -142,6 +154,22 @@ func TestServerRoutes(t *testing.T) {
 		{"OPTIONS", "/me/", "", "", 200},
 		{"OPTIONS", "/list/", "", "", 200},
 		{"OPTIONS", "/history/", "", "", 200},
+		{"OPTIONS", "/revert/", "", "", 200},
+		{"OPTIONS", "/reset/", "", "", 200},
+		{"OPTIONS", "/graph", "", "", 200},
+		{"OPTIONS", "/graph/", "", "", 200},
+		{"OPTIONS", "/readme/", "", "", 200},
+		{"OPTIONS", "/render/", "", "", 200},
+		{"OPTIONS", "/events", "", "", 200},
+		{"OPTIONS", "/repo/du", "", "", 200},
+		{"OPTIONS", "/repo/stats", "", "", 200},
+		{"OPTIONS", "/peers/follow", "", "", 200},
+		{"OPTIONS", "/peers/unfollow", "", "", 200},
+		{"OPTIONS", "/peers/trust", "", "", 200},
+		{"OPTIONS", "/requests", "", "", 200},
+		{"OPTIONS", "/requests/diff", "", "", 200},
+		{"OPTIONS", "/requests/accept", "", "", 200},
+		{"OPTIONS", "/requests/reject", "", "", 200},
 	}
 
 	for i, c := range cases {
@@ -273,6 +301,9 @@ func TestServerReadOnlyRoutes(t *testing.T) {
 		{"PUT", "/profile/poster", 403},
 		{"GET", "/peers", 403},
 		{"GET", "/peers/", 403},
+		{"POST", "/peers/follow", 403},
+		{"POST", "/peers/unfollow", 403},
+		{"POST", "/peers/trust", 403},
 		{"GET", "/connections", 403},
 		{"GET", "/list", 403},
 		{"POST", "/save", 403},
@@ -292,6 +323,8 @@ func TestServerReadOnlyRoutes(t *testing.T) {
 		{"POST", "/diff", 403},
 		{"GET", "/diff", 403},
 		{"GET", "/data/", 403},
+		{"POST", "/revert/", 403},
+		{"POST", "/reset/", 403},
 
 		// active endpoints:
 		{"GET", "/status", 200},
@@ -302,6 +335,11 @@ func TestServerReadOnlyRoutes(t *testing.T) {
 		// {"GET", "/peer", 200},
 		{"GET", "/peer/movies", 200},
 		{"GET", "/history/peer/movies", 200},
+		{"GET", "/graph", 200},
+		{"GET", "/graph/peer/movies?ancestry=true", 200},
+		{"GET", "/readme/peer/movies", 404},
+		{"GET", "/render/peer/movies?format=gif", 400},
+		{"GET", "/render/peer/movies?format=png&width=100000", 400},
 
 		// blatently checking all options for easy test coverage bump
 		{"OPTIONS", "/add", 200},
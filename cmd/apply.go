@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/core"
+	"github.com/spf13/cobra"
+)
+
+var applyJobs int
+
+var applyCmd = &cobra.Command{
+	Use:   "apply MANIFEST",
+	Short: "add & save many datasets from a manifest file",
+	Long: `
+apply adds or saves every dataset listed in a manifest file. The manifest is
+a yaml file that lists datasets by name, along with the data file or url,
+metadata file, structure file and commit message for each:
+
+  datasets:
+    - name: me/cities
+      data: cities.csv
+      meta: cities_meta.json
+      title: update city populations
+    - name: me/wells
+      url: https://example.com/wells.csv
+      structure: wells_structure.json
+
+File paths are relative to the manifest. Datasets that don't exist yet are
+added, datasets that already match their entry are left alone. An entry that
+fails doesn't stop the others from being applied, a summary is printed at
+the end.`,
+	Example: `  apply a manifest, working on up to 8 datasets at once:
+  $ qri apply manifest.yaml --jobs 8`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please provide the path to a manifest file"))
+		}
+
+		m, err := core.ReadManifest(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		res := []core.ApplyResult{}
+		err = req.Apply(&core.ApplyParams{Manifest: m, Jobs: applyJobs}, &res)
+		ExitIfErr(err)
+
+		counts := map[core.ApplyStatus]int{}
+		for _, r := range res {
+			counts[r.Status]++
+			switch r.Status {
+			case core.ApplyAdded:
+				printSuccess("added %s", r.Ref.String())
+			case core.ApplySaved:
+				printSuccess("saved %s", r.Ref.String())
+			case core.ApplyUnchanged:
+				printInfo("unchanged %s", r.Name)
+			case core.ApplyFailed:
+				printErr(fmt.Errorf("failed %s: %s", r.Name, r.Error))
+			}
+		}
+
+		printInfo("\n%d datasets: %d added, %d saved, %d unchanged, %d failed", len(res), counts[core.ApplyAdded], counts[core.ApplySaved], counts[core.ApplyUnchanged], counts[core.ApplyFailed])
+		if counts[core.ApplyFailed] > 0 {
+			ErrExit(fmt.Errorf("%d of %d datasets failed to apply", counts[core.ApplyFailed], len(res)))
+		}
+	},
+}
+
+func init() {
+	applyCmd.Flags().IntVarP(&applyJobs, "jobs", "j", 4, "number of datasets to work on at once")
+	RootCmd.AddCommand(applyCmd)
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	ipfs "github.com/qri-io/cafs/ipfs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo/actions"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	backupExcludeKeys bool
+	backupPassphrase  string
+)
+
+// backupKeys are the private keys removed from a config file before it's
+// written to a backup
+type backupKeys struct {
+	Profile string `json:"profile"`
+	P2P     string `json:"p2p"`
+}
+
+var repoBackupCmd = &cobra.Command{
+	Use:   "backup FILE",
+	Short: "write your entire qri repo to a single archive file",
+	Long: `
+backup writes every dataset reference, your event log, known peer profiles,
+your config file & every block your datasets need to a single zip archive.
+
+Private keys are encrypted with a passphrase before they're written. Use
+--exclude-keys to leave them out of the backup entirely. The passphrase can
+also be set with the QRI_BACKUP_PASSPHRASE environment variable.`,
+	Example: `  back up your repo:
+  $ qri repo backup qri_backup.zip
+
+  back up without private keys:
+  $ qri repo backup --exclude-keys qri_backup.zip`,
+	Args: cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := &config.Config{}
+		*cfg = *core.Config
+		keys := &backupKeys{}
+		if cfg.Profile != nil {
+			pro := *cfg.Profile
+			keys.Profile = pro.PrivKey
+			pro.PrivKey = ""
+			cfg.Profile = &pro
+		}
+		if cfg.P2P != nil {
+			p2p := *cfg.P2P
+			keys.P2P = p2p.PrivKey
+			p2p.PrivKey = ""
+			cfg.P2P = &p2p
+		}
+
+		params := actions.BackupParams{}
+		var err error
+		params.Config, err = yaml.Marshal(cfg)
+		ExitIfErr(err)
+
+		if !backupExcludeKeys {
+			params.Keys, err = json.Marshal(keys)
+			ExitIfErr(err)
+			params.Passphrase = backupPassphraseInput("passphrase to encrypt private keys:")
+			if params.Passphrase == "" {
+				ErrExit(fmt.Errorf("a passphrase is required to back up private keys. use --exclude-keys to leave them out"))
+			}
+		}
+
+		r := getRepo(false)
+
+		f, err := os.Create(args[0])
+		ExitIfErr(err)
+		mf, err := actions.Backup(r, f, params)
+		if err != nil {
+			f.Close()
+			os.Remove(args[0])
+			ErrExit(err)
+		}
+		ExitIfErr(f.Close())
+
+		printSuccess("backed up %d blocks to: %s", len(mf.Blocks), args[0])
+	},
+}
+
+var repoRestoreCmd = &cobra.Command{
+	Use:   "restore FILE",
+	Short: "rebuild a qri repo from a backup archive",
+	Long: `
+restore adds the contents of a backup created with 'qri repo backup' to your
+repo. Every block is checked against the checksum recorded when the backup was
+made, and restoring stops at the first block that doesn't match.
+
+If no repo exists yet, restore creates one using the config file & private
+keys stored in the backup. Backups made with --exclude-keys can only be
+restored into an existing repo. Datasets that already exist in your repo are
+kept as-is.`,
+	Example: `  restore a backup:
+  $ qri repo restore qri_backup.zip`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		f, err := os.Open(args[0])
+		ExitIfErr(err)
+		defer f.Close()
+		fi, err := f.Stat()
+		ExitIfErr(err)
+
+		archive, err := actions.OpenBackup(f, fi.Size())
+		ExitIfErr(err)
+
+		if !QRIRepoInitialized() {
+			restoreConfig(archive)
+		}
+
+		loadConfig()
+		r := getRepo(false)
+
+		res, err := archive.Restore(r)
+		ExitIfErr(err)
+
+		printSuccess("restored %d blocks, %d datasets, %d profiles & %d events from: %s", res.Blocks, res.Refs, res.Profiles, res.Events, args[0])
+	},
+}
+
+// restoreConfig creates a new repo from the config & keys in a backup
+func restoreConfig(archive *actions.BackupArchive) {
+	if !archive.Manifest.HasConfig || !archive.Manifest.HasKeys {
+		ErrExit(fmt.Errorf("this backup doesn't include a config file & private keys. run 'qri setup' before restoring it"))
+	}
+
+	data, err := archive.Keys(backupPassphraseInput("passphrase to decrypt private keys:"))
+	ExitIfErr(err)
+	keys := &backupKeys{}
+	err = json.Unmarshal(data, keys)
+	ExitIfErr(err)
+
+	cfg := &config.Config{}
+	err = yaml.Unmarshal(archive.Config, cfg)
+	ExitIfErr(err)
+	if cfg.Profile != nil {
+		cfg.Profile.PrivKey = keys.Profile
+	}
+	if cfg.P2P != nil {
+		cfg.P2P.PrivKey = keys.P2P
+	}
+	err = cfg.Validate()
+	ExitIfErr(err)
+
+	if err := os.MkdirAll(QriRepoPath, os.ModePerm); err != nil {
+		ErrExit(fmt.Errorf("error creating home dir: %s", err.Error()))
+	}
+
+	if cfg.Store == nil || cfg.Store.Type == "ipfs" {
+		err = ipfs.InitRepo(IpfsFsPath, "")
+		if err != nil && strings.Contains(err.Error(), "already") {
+			err = nil
+		}
+		ExitIfErr(err)
+	}
+
+	err = cfg.WriteToFile(configFilepath())
+	ExitIfErr(err)
+	printInfo("created repo for peer %s", archive.Manifest.Peername)
+}
+
+// backupPassphraseInput reads a passphrase from flags, the environment, or
+// by asking the user
+func backupPassphraseInput(message string) string {
+	if backupPassphrase != "" {
+		return backupPassphrase
+	}
+	if pass := os.Getenv("QRI_BACKUP_PASSPHRASE"); pass != "" {
+		return pass
+	}
+	return inputText(message, "")
+}
+
+func init() {
+	repoBackupCmd.Flags().BoolVarP(&backupExcludeKeys, "exclude-keys", "", false, "don't include private keys in the backup")
+	repoBackupCmd.Flags().StringVarP(&backupPassphrase, "passphrase", "", "", "passphrase used to encrypt private keys")
+	repoRestoreCmd.Flags().StringVarP(&backupPassphrase, "passphrase", "", "", "passphrase used to decrypt private keys")
+
+	repoCmd.AddCommand(repoBackupCmd, repoRestoreCmd)
+}
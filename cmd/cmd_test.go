@@ -137,6 +137,22 @@ func TestCommandsIntegration(t *testing.T) {
 		return
 	}
 
+	manifestFilepath := filepath.Join(path, "/manifest.yaml")
+	manifest := "datasets:\n  - name: me/movies2\n    data: movies2.csv\n  - name: me/links_two\n    data: links.json\n"
+	if err := ioutil.WriteFile(manifestFilepath, []byte(manifest), os.ModePerm); err != nil {
+		t.Errorf("error writing manifest file: %s", err.Error())
+		return
+	}
+
+	backupFilepath := filepath.Join(path, "/backup.zip")
+
+	visFilepath := filepath.Join(path, "/vis.json")
+	vis := `{"format":"chart","visualizations":{"type":"bar","x":"movie_title","y":"duration"}}`
+	if err := ioutil.WriteFile(visFilepath, []byte(vis), os.ModePerm); err != nil {
+		t.Errorf("error writing visconfig file: %s", err.Error())
+		return
+	}
+
 	commands := [][]string{
 		{"help"},
 		{"version"},
@@ -151,14 +167,29 @@ func TestCommandsIntegration(t *testing.T) {
 		{"add", "--data=" + linksFilepath, "me/links"},
 		{"info", "me/movies"},
 		{"list"},
+		{"apply", manifestFilepath, "--jobs=2"},
 		{"save", "--data=" + movies2FilePath, "-t" + "commit_1", "me/movies"},
+		{"save", "--vis=" + visFilepath, "-t" + "add chart", "me/movies"},
+		{"render", "me/movies", "-o" + filepath.Join(path, "movies.svg")},
 		{"log", "me/movies"},
 		{"diff", "me/movies", "me/movies2", "-d", "detail"},
 		{"export", "--dataset", "-o" + path, "me/movies"},
 		{"rename", "me/movies", "me/movie"},
 		{"data", "--limit=1", "--data-format=cbor", "me/movie"},
 		{"validate", "me/movie"},
+		{"fsck"},
+		{"status"},
+		{"graph", "me/movie", "--ancestry"},
 		{"remove", "me/movie"},
+		{"webhook", "add", "http://localhost:2599/hook", "--events=ds_created,ds_renamed"},
+		{"webhook", "list"},
+		{"webhook", "log"},
+		{"request", "list"},
+		{"repo", "migrate", "--dry-run"},
+		{"repo", "backup", "--passphrase=hunter2", backupFilepath},
+		{"repo", "restore", backupFilepath},
+		{"repo", "du"},
+		{"stats"},
 	}
 
 	for i, args := range commands {
@@ -6,6 +6,7 @@ import (
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/webhook"
 	"github.com/spf13/cobra"
 )
 
@@ -59,6 +60,12 @@ peers & swapping data.`,
 
 		r = getRepo(true)
 
+		// webhooks are re-read from config on each catch up, so they can be
+		// changed while connect is running. events logged by other commands
+		// while connect wasn't running are sent when it starts
+		d := webhook.NewDispatcher(webhooks, webhookDeliveryLog(), webhookCursors())
+		go d.Run(r, r.Subscribe(repo.EventFilter{}))
+
 		s, err := api.New(r, func(c *config.Config) {
 			*c = *core.Config
 
@@ -100,6 +107,7 @@ peers & swapping data.`,
 		})
 		ExitIfErr(err)
 
+		defer releaseRepoLock()
 		err = s.Serve()
 		if err != nil && err.Error() == "http: Server closed" {
 			return
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/cafs"
+	ipfs "github.com/qri-io/cafs/ipfs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo/actions"
+	"github.com/qri-io/qri/store/localfs"
+	"github.com/spf13/cobra"
+)
+
+var convertStoreTo string
+
+var repoConvertStoreCmd = &cobra.Command{
+	Use:   "convert-store",
+	Short: "move your datasets to a different content store",
+	Long: `
+convert-store copies every dataset in your repo, including history, from the
+content store you're using now to another one & switches your config to use
+it. Supported stores are "ipfs", an IPFS node at $IPFS_PATH, "local", a plain
+directory at $QRI_PATH/store that doesn't need IPFS, and "s3", the bucket set
+in your store config.
+
+Stores hash content differently, so converted datasets get new paths. Your old
+store is left as-is; remove it yourself once you're happy with the result.`,
+	Example: `  stop using IPFS:
+  $ qri repo convert-store --to local`,
+	Run: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+		if core.Config.Store == nil {
+			core.Config.Store = config.DefaultStore()
+		}
+		switch convertStoreTo {
+		case "ipfs", "local":
+		case "s3":
+			if core.Config.Store.Bucket == "" {
+				ErrExit(fmt.Errorf("set store.bucket before converting to an s3 store"))
+			}
+		default:
+			ErrExit(fmt.Errorf("--to must be one of 'ipfs', 'local' or 's3'"))
+		}
+		if core.Config.Store.Type == convertStoreTo {
+			printSuccess("repo already uses the %s store", convertStoreTo)
+			return
+		}
+
+		r := getRepo(false)
+		dst, err := newFilestore(convertStoreTo)
+		ExitIfErr(err)
+
+		// switch config over as the last step of conversion, so references are
+		// rolled back if the config can't be written
+		res, err := actions.ConvertStore(r, dst, func() error {
+			prev := core.Config.Store.Type
+			core.Config.Store.Type = convertStoreTo
+			if err := core.Config.WriteToFile(configFilepath()); err != nil {
+				core.Config.Store.Type = prev
+				return err
+			}
+			return nil
+		})
+		ExitIfErr(err)
+
+		printSuccess("copied %d versions of %d datasets to the %s store", res.Versions, res.Datasets, convertStoreTo)
+	},
+}
+
+// newFilestore creates an offline store of type storeType if one doesn't
+// exist, and opens it. s3 stores use the bucket in the store config
+func newFilestore(storeType string) (cafs.Filestore, error) {
+	switch storeType {
+	case "local":
+		return localfs.NewFilestore(LocalStorePath())
+	case "s3":
+		return s3Filestore(core.Config.Store)
+	}
+
+	if err := ipfs.InitRepo(IpfsFsPath, ""); err != nil && !strings.Contains(err.Error(), "already") {
+		return nil, err
+	}
+	return ipfs.NewFilestore(func(cfg *ipfs.StoreCfg) {
+		cfg.FsRepoPath = IpfsFsPath
+		cfg.Online = false
+	})
+}
+
+func init() {
+	repoConvertStoreCmd.Flags().StringVarP(&convertStoreTo, "to", "", "", "store to convert to, one of 'ipfs', 'local' or 's3'")
+	repoCmd.AddCommand(repoConvertStoreCmd)
+}
@@ -36,8 +36,8 @@ Data reads records from a dataset`,
 			return
 		}
 
-		r := getRepo(false)
-		req := core.NewDatasetRequests(r, nil)
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
 
 		dsr, err := repo.ParseDatasetRef(args[0])
 		ExitIfErr(err)
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/qri-io/qri/repo/actions"
+	"github.com/spf13/cobra"
+)
+
+var repoDuCmd = &cobra.Command{
+	Use:   "du",
+	Short: "show how much storage each dataset uses",
+	Long: `
+du (disk usage) lists every dataset in your repo with the storage it uses, 
+largest first. Sizes are in bytes:
+- head: the latest version of the dataset
+- history: every version of the dataset, counting data shared between 
+  versions once
+- shared: the part of history also used by other datasets. removing a dataset 
+  won’t free it’s shared bytes
+
+The total counts data shared between datasets once, and can be compared with 
+the unreferenced data “qri gc” would remove.`,
+	Example: `  find the datasets taking up the most space:
+  $ qri repo du`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := repoRequests(false)
+		ExitIfErr(err)
+
+		in := true
+		res := &actions.UsageReport{}
+		err = req.DiskUsage(&in, res)
+		ExitIfErr(err)
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.SetHeader([]string{"dataset", "versions", "head", "history", "shared"})
+		for _, ds := range res.Datasets {
+			table.Append([]string{
+				ds.Ref,
+				strconv.Itoa(ds.Versions),
+				strconv.FormatInt(ds.HeadBytes, 10),
+				strconv.FormatInt(ds.HistoryBytes, 10),
+				strconv.FormatInt(ds.SharedBytes, 10),
+			})
+		}
+		table.Render()
+
+		printSuccess("%d datasets use %d bytes", len(res.Datasets), res.TotalBytes)
+		if res.Collectable {
+			printInfo("gc would free %d unreferenced bytes", res.UnreferencedBytes)
+		} else {
+			printInfo("your store can't be garbage collected")
+		}
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(repoDuCmd)
+}
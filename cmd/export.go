@@ -13,6 +13,7 @@ import (
 	"github.com/qri-io/dataset/dsutil"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/actions"
 	"github.com/spf13/cobra"
 )
 
@@ -56,6 +57,7 @@ To export everything about a dataset, use the --dataset flag.`,
 		res := &repo.DatasetRef{}
 		err = req.Get(&dsr, res)
 		ExitIfErr(err)
+		actions.RecordUsage(r, repo.UsageExported, *res)
 
 		fmt.Println(res)
 		ds := res.Dataset
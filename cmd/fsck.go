@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo/actions"
+	"github.com/spf13/cobra"
+)
+
+var fsckRepair bool
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "check the integrity of your repository",
+	Long: `
+fsck checks every dataset in your repo. For each reference it confirms the 
+dataset and it’s components load, that every version in it’s history resolves, 
+and that commit signatures verify for every author who’s key you know. It also 
+checks your search index and event log agree with the list of datasets in your 
+repo.
+
+With --repair, references to datasets that no longer load are dropped, and the 
+search index is rebuilt if it’s missing entries, has stale ones, or can’t be 
+read.`,
+	Example: `  check your repo & fix what can be fixed:
+  $ qri fsck --repair`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := repoRequests(false)
+		ExitIfErr(err)
+
+		res := &actions.FsckResult{}
+		err = req.Fsck(&core.FsckParams{Repair: fsckRepair}, res)
+		ExitIfErr(err)
+
+		for _, p := range res.Problems {
+			if p.Repaired {
+				printInfo("%s\t%s: %s (repaired)", p.Check, p.Ref, p.Message)
+			} else {
+				printWarning("%s\t%s: %s", p.Check, p.Ref, p.Message)
+			}
+		}
+
+		if len(res.Problems) == 0 {
+			printSuccess("checked %d datasets, no problems found", res.RefsChecked)
+			return
+		}
+		printSuccess("checked %d datasets, found %d problems", res.RefsChecked, len(res.Problems))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(fsckCmd)
+	fsckCmd.Flags().BoolVarP(&fsckRepair, "repair", "", false, "drop broken references & rebuild the search index")
+}
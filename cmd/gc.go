@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo/actions"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcDryRun bool
+	gcIPFSGC bool
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "remove unreferenced data from your repository",
+	Long: `
+gc (garbage collection) frees up disk space by removing data your repo no 
+longer needs. Removing a dataset or saving a new version doesn’t delete 
+anything from the underlying store right away. gc works out everything that’s 
+still reachable from your datasets, their history, and profile photos, and 
+removes the rest.
+
+On an ipfs store gc only unpins data qri pinned that your repo no longer 
+references. Your ipfs repo is shared with anything else you do with ipfs, so 
+the unpinned data stays on disk until ipfs’ own garbage collection runs. Pass 
+--ipfs-gc to run it as well, keeping in mind it removes everything in your ipfs 
+repo that isn’t pinned, whether qri added it or not.
+
+Use --dry-run to see how much space would be freed without removing anything. 
+gc locks the repo while it removes data. If “qri connect” is running gc will 
+run within the connected process.`,
+	Example: `  see how much space gc would free up:
+  $ qri gc --dry-run
+
+  unpin unused data & run ipfs garbage collection:
+  $ qri gc --ipfs-gc`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := repoRequests(false)
+		ExitIfErr(err)
+
+		res := &actions.GCResult{}
+		err = req.GC(&core.GCParams{DryRun: gcDryRun, IPFSGC: gcIPFSGC}, res)
+		ExitIfErr(err)
+
+		if res.DryRun {
+			for _, key := range res.Removed {
+				printInfo("would remove %s", key)
+			}
+			printSuccess("gc would free %d bytes in %d blocks", res.BytesFreed, len(res.Removed))
+			return
+		}
+		if res.Pending {
+			printSuccess("gc unpinned %d bytes in %d blocks, run “qri gc --ipfs-gc” or “ipfs repo gc” to free them", res.BytesFreed, len(res.Removed))
+			return
+		}
+		printSuccess("gc freed %d bytes in %d blocks", res.BytesFreed, len(res.Removed))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().BoolVarP(&gcDryRun, "dry-run", "n", false, "report what would be removed without removing anything")
+	gcCmd.Flags().BoolVarP(&gcIPFSGC, "ipfs-gc", "", false, "run ipfs garbage collection after unpinning, removing all unpinned ipfs data")
+}
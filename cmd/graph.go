@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphFormat                 string
+	graphAncestry, graphDerived bool
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [DATASET]",
+	Short: "show the lineage graph of datasets in your repo",
+	Long: `
+graph shows how the datasets in your repo connect to each other. Each dataset
+links to its previous version, its components, and any datasets its transform
+draws on.
+
+Given a dataset reference, graph shows only the part of the graph connected to
+that dataset. Use --ancestry to limit the graph to what the dataset was derived
+from, and --derived to limit it to datasets derived from it.
+
+graph can print as an ascii tree (the default), json, or graphviz dot.`,
+	Example: `  show everything b5/comics was derived from:
+  $ qri graph b5/comics --ancestry
+
+  render your repo's graph as an svg with graphviz:
+  $ qri graph --format dot | dot -Tsvg > graph.svg`,
+	Args: cobra.MaximumNArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		p := &core.GraphParams{
+			Ancestry: graphAncestry,
+			Derived:  graphDerived,
+		}
+		if len(args) == 1 {
+			ref, err := repo.ParseDatasetRef(args[0])
+			ExitIfErr(err)
+			p.Ref = ref
+		} else if graphAncestry || graphDerived {
+			ErrExit(fmt.Errorf("--ancestry and --derived require a dataset reference"))
+		}
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		res := &repo.GraphData{}
+		err = req.Graph(p, res)
+		ExitIfErr(err)
+
+		switch graphFormat {
+		case "", "ascii":
+			printGraphTree(os.Stdout, res)
+		case "json":
+			data, err := json.MarshalIndent(res, "", "  ")
+			ExitIfErr(err)
+			fmt.Printf("%s\n", string(data))
+		case "dot":
+			err = res.WriteDOT(os.Stdout)
+			ExitIfErr(err)
+		default:
+			ErrExit(fmt.Errorf("unrecognized format: %s", graphFormat))
+		}
+	},
+}
+
+// printGraphTree writes a graph as an indented ascii tree, starting from the
+// graph's root if one is set. nodes reachable from more than one parent are
+// expanded the first time they're printed & marked thereafter
+func printGraphTree(w io.Writer, g *repo.GraphData) {
+	types := map[string]string{}
+	for _, n := range g.Nodes {
+		types[n.Path] = string(n.Type)
+	}
+
+	printed := map[string]bool{}
+	var printNode func(path, prefix, branch, indent string)
+	printNode = func(path, prefix, branch, indent string) {
+		if printed[path] {
+			fmt.Fprintf(w, "%s%s%s %s (see above)\n", prefix, branch, types[path], path)
+			return
+		}
+		printed[path] = true
+		fmt.Fprintf(w, "%s%s%s %s\n", prefix, branch, types[path], path)
+
+		children := g.Children(path)
+		for i, child := range children {
+			if i == len(children)-1 {
+				printNode(child, prefix+indent, "└── ", "    ")
+			} else {
+				printNode(child, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+
+	roots := g.Roots()
+	if g.Root != "" {
+		roots = []string{g.Root}
+	}
+	for _, root := range roots {
+		printNode(root, "", "", "")
+	}
+	// derived datasets aren't reachable from the root, print any stragglers
+	for _, n := range g.Nodes {
+		if !printed[n.Path] && len(g.Children(n.Path)) > 0 {
+			printNode(n.Path, "", "", "")
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(graphCmd)
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "f", "", "set output format [ascii, json, dot]")
+	graphCmd.Flags().BoolVarP(&graphAncestry, "ancestry", "a", false, "only show what the dataset was derived from")
+	graphCmd.Flags().BoolVarP(&graphDerived, "derived", "d", false, "only show datasets derived from the dataset")
+}
@@ -41,7 +41,8 @@ var infoCmd = &cobra.Command{
 
 		online := false
 		// check to see if we're all local
-		r := getRepo(false)
+		r, _, err := repoOrClient(false)
+		ExitIfErr(err)
 		for _, arg := range args {
 			ref, err := repo.ParseDatasetRef(arg)
 			ExitIfErr(err)
@@ -63,8 +64,10 @@ var infoCmd = &cobra.Command{
 			ExitIfErr(err)
 
 			if ref.IsPeerRef() {
-				err = repo.CanonicalizeProfile(r, &ref)
-				ExitIfErr(err)
+				if r != nil {
+					err = repo.CanonicalizeProfile(r, &ref)
+					ExitIfErr(err)
+				}
 				p := &core.PeerInfoParams{
 					Peername: ref.Peername,
 				}
@@ -89,6 +92,12 @@ var infoCmd = &cobra.Command{
 
 				if outformat == "" {
 					printDatasetRefInfo(i, res)
+					if res.Dataset != nil && res.Dataset.Meta != nil && res.Dataset.Meta.ReadmePath != "" {
+						md := []byte{}
+						err = req.Readme(&core.ReadmeParams{Ref: res}, &md)
+						ExitIfErr(err)
+						printReadme(md)
+					}
 				} else {
 					data, err := json.MarshalIndent(res.Dataset, "", "  ")
 					ExitIfErr(err)
@@ -45,6 +45,10 @@ qri repository.`,
 			switch outformat {
 			case "":
 				for _, ref := range refs {
+					if ref.Stale {
+						printWarning("%s (stale)", ref.String())
+						continue
+					}
 					printInfo(ref.String())
 				}
 			case dataset.JSONDataFormat.String():
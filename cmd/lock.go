@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/rpc"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo/fs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// repoLock is the lock this process holds on the qri repo, if any
+	repoLock *fsrepo.Lock
+	// repoLockMode is the kind of lock the running command needs
+	repoLockMode = fsrepo.LockExclusive
+)
+
+// sharedLockCmds only read from the repo, and can run alongside each other
+var sharedLockCmds = map[string]bool{
+	"data":     true,
+	"diff":     true,
+	"export":   true,
+	"graph":    true,
+	"info":     true,
+	"list":     true,
+	"log":      true,
+	"render":   true,
+	"search":   true,
+	"status":   true,
+	"validate": true,
+}
+
+// setRepoLockMode picks the kind of repo lock a command takes
+func setRepoLockMode(cmd *cobra.Command, args []string) {
+	repoLockMode = fsrepo.LockExclusive
+	if sharedLockCmds[cmd.Name()] {
+		repoLockMode = fsrepo.LockShared
+	}
+}
+
+// lockRepo takes a lock on the qri repo, held until the process exits. The
+// lock is only taken once, unless a command needs a stronger lock than the
+// one already held
+func lockRepo() error {
+	if repoLock != nil {
+		if repoLock.Base() == QriRepoPath && repoLock.Mode() >= repoLockMode {
+			return nil
+		}
+		repoLock.Release()
+		repoLock = nil
+	}
+
+	l, err := fsrepo.AcquireLock(QriRepoPath, repoLockMode)
+	if err != nil {
+		return err
+	}
+	repoLock = l
+	return nil
+}
+
+// releaseRepoLock gives up the repo lock, if held
+func releaseRepoLock() {
+	if repoLock != nil {
+		repoLock.Release()
+		repoLock = nil
+	}
+}
+
+// dialRPC connects to the RPC listener of a running qri process
+func dialRPC() (*rpc.Client, error) {
+	port := config.DefaultRPCPort
+	if core.Config != nil && core.Config.RPC != nil && core.Config.RPC.Port != 0 {
+		port = core.Config.RPC.Port
+	}
+	conn, err := net.Dial("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+	return rpc.NewClient(conn), nil
+}
@@ -42,7 +42,8 @@ working backwards in time.`,
 
 		online := false
 
-		r := getRepo(false)
+		r, _, err := repoOrClient(false)
+		ExitIfErr(err)
 		ref, err := repo.ParseDatasetRef(args[0])
 		ExitIfErr(err)
 
@@ -71,7 +72,11 @@ working backwards in time.`,
 		ExitIfErr(err)
 
 		for _, ref := range refs {
-			printSuccess("%s - %s\n\t%s\n", ref.Dataset.Commit.Timestamp.Format("Jan _2 15:04:05"), ref.Path, ref.Dataset.Commit.Title)
+			printSuccess("%s - %s\n\t%s", ref.Dataset.Commit.Timestamp.Format("Jan _2 15:04:05"), ref.Path, ref.Dataset.Commit.Title)
+			if ref.Verification != repo.SigUnchecked {
+				fmt.Printf("\tsignature: %s\n", signatureString(ref.Verification))
+			}
+			fmt.Println()
 		}
 
 		// outformat := cmd.Flag("format").Value.String()
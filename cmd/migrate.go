@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"github.com/qri-io/qri/repo/fs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateTo       int
+	migrateDryRun   bool
+	migrateNoBackup bool
+)
+
+// repoCmd groups commands that maintain the qri repo itself
+var repoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "maintain your qri repo",
+}
+
+var repoMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "upgrade your qri repo to the format this version of qri uses",
+	Long: `
+migrate updates the files qri keeps in your repo when a new version of qri
+changes how they're stored. qri won't open a repo that needs migrating.
+
+The repo is copied to a backup directory alongside it before any changes are
+made. Use --to to migrate to an earlier format version, which is useful when
+going back to an older version of qri.`,
+	Example: `  see which migrations would run:
+  $ qri repo migrate --dry-run
+
+  upgrade your repo:
+  $ qri repo migrate`,
+	Run: func(cmd *cobra.Command, args []string) {
+		target := fsrepo.RepoVersion
+		if cmd.Flag("to").Changed {
+			target = migrateTo
+		}
+
+		err := lockRepo()
+		ExitIfErr(err)
+
+		steps, err := fsrepo.MigrationPlan(QriRepoPath, target)
+		ExitIfErr(err)
+		if len(steps) == 0 {
+			printSuccess("repo is already at version %d", target)
+			return
+		}
+
+		if migrateDryRun {
+			for _, step := range steps {
+				printInfo("%s", step.String())
+			}
+			return
+		}
+
+		if !migrateNoBackup {
+			backup, err := fsrepo.BackupRepo(QriRepoPath)
+			ExitIfErr(err)
+			printInfo("backed up repo to: %s", backup)
+		}
+
+		applied, err := fsrepo.Migrate(QriRepoPath, target)
+		for _, step := range applied {
+			printSuccess("%s", step.String())
+		}
+		ExitIfErr(err)
+		printSuccess("repo migrated to version %d", target)
+	},
+}
+
+func init() {
+	repoMigrateCmd.Flags().IntVarP(&migrateTo, "to", "", 0, "format version to migrate to, default is the latest")
+	repoMigrateCmd.Flags().BoolVarP(&migrateDryRun, "dry-run", "n", false, "list migrations without applying them")
+	repoMigrateCmd.Flags().BoolVarP(&migrateNoBackup, "no-backup", "", false, "skip backing up the repo before migrating")
+
+	repoCmd.AddCommand(repoMigrateCmd)
+	RootCmd.AddCommand(repoCmd)
+}
@@ -10,13 +10,29 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	peersFollowing bool
+	peersTrust     string
+)
+
 // peersCmd represents the info command
 var peersCmd = &cobra.Command{
 	Use:   "peers",
 	Short: "list known qri peers",
-	Long:  `peers lists the peers your qri node has seen before`,
+	Long: `
+peers lists the peers your qri node has seen before. Follow peers to keep 
+track of them, and set how far you trust them: trusted peers are asked for 
+data first, blocked peers are hidden from peer lists & search results, 
+aren't fetched from, and their messages are ignored.`,
 	Example: `  list qri peers:
-  $ qri peers`,
+  $ qri peers
+
+  follow a peer & list the peers you follow:
+  $ qri peers follow b5
+  $ qri peers --following
+
+  block a peer:
+  $ qri peers trust spammer blocked`,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		loadConfig()
 	},
@@ -35,12 +51,24 @@ var peersCmd = &cobra.Command{
 		pr, err := peerRequests(false)
 		ExitIfErr(err)
 
+		trust, err := parseTrustFlag(peersTrust)
+		ExitIfErr(err)
+
+		p := &core.PeerListParams{
+			ListParams: core.ListParams{Limit: 200},
+			Following:  peersFollowing,
+			Trust:      trust,
+		}
 		res := []*profile.Profile{}
-		err = pr.List(&core.ListParams{Limit: 200}, &res)
+		err = pr.List(p, &res)
 		ExitIfErr(err)
 
 		if len(res) == 0 {
-			printWarning("no peers connected")
+			if peersFollowing || peersTrust != "" {
+				printWarning("no peers match")
+			} else {
+				printWarning("no peers connected")
+			}
 			return
 		}
 
@@ -56,7 +84,87 @@ var peersCmd = &cobra.Command{
 	},
 }
 
+var peersFollowCmd = &cobra.Command{
+	Use:   "follow PEER",
+	Short: "add a peer to the peers you follow",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		pr, err := peerRequests(false)
+		ExitIfErr(err)
+
+		res := &profile.Relationship{}
+		err = pr.Follow(relationshipParams(args[0]), res)
+		ExitIfErr(err)
+		printSuccess("following %s", args[0])
+	},
+}
+
+var peersUnfollowCmd = &cobra.Command{
+	Use:   "unfollow PEER",
+	Short: "remove a peer from the peers you follow",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		pr, err := peerRequests(false)
+		ExitIfErr(err)
+
+		res := &profile.Relationship{}
+		err = pr.Unfollow(relationshipParams(args[0]), res)
+		ExitIfErr(err)
+		printSuccess("unfollowed %s", args[0])
+	},
+}
+
+var peersTrustCmd = &cobra.Command{
+	Use:   "trust PEER LEVEL",
+	Short: "set how far you trust a peer: trusted, neutral or blocked",
+	Args:  cobra.ExactArgs(2),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		trust, err := parseTrustFlag(args[1])
+		ExitIfErr(err)
+
+		pr, err := peerRequests(false)
+		ExitIfErr(err)
+
+		p := relationshipParams(args[0])
+		p.Trust = trust
+		res := &profile.Relationship{}
+		err = pr.SetTrust(p, res)
+		ExitIfErr(err)
+		printSuccess("%s is %s", args[0], res.Trust)
+	},
+}
+
+// relationshipParams identifies a peer by profile ID if peer is one, by
+// peername otherwise
+func relationshipParams(peer string) *core.RelationshipParams {
+	if id, err := profile.IDB58Decode(peer); err == nil {
+		return &core.RelationshipParams{ProfileID: id}
+	}
+	return &core.RelationshipParams{Peername: peer}
+}
+
+// parseTrustFlag parses a trust level, leaving empty strings empty
+func parseTrustFlag(s string) (profile.Trust, error) {
+	if s == "" {
+		return "", nil
+	}
+	return profile.ParseTrust(s)
+}
+
 func init() {
-	RootCmd.AddCommand(peersCmd)
 	peersCmd.Flags().StringP("format", "f", "", "set output format [json]")
+	peersCmd.Flags().BoolVar(&peersFollowing, "following", false, "only list peers you follow")
+	peersCmd.Flags().StringVar(&peersTrust, "trust", "", "only list peers with a trust level [trusted, neutral, blocked]")
+
+	peersCmd.AddCommand(peersFollowCmd, peersUnfollowCmd, peersTrustCmd)
+	RootCmd.AddCommand(peersCmd)
 }
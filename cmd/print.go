@@ -63,6 +63,9 @@ func printDatasetRefInfo(i int, ref repo.DatasetRef) {
 
 	fmt.Printf("%s  %s\n", cyan(i), white(ref.Name))
 	fmt.Printf("    %s\n", blue(ref.Path))
+	if ref.Verification != repo.SigUnchecked {
+		fmt.Printf("    signature: %s\n", signatureString(ref.Verification))
+	}
 	if ds != nil && ds.Meta != nil {
 		if ds.Meta.Title != "" {
 			fmt.Printf("    %s\n", white(ds.Meta.Title))
@@ -99,6 +102,17 @@ func printDatasetRefInfo(i int, ref repo.DatasetRef) {
 	// fmt.Println()
 }
 
+// printReadme writes markdown readme text, indented to line up with
+// dataset info
+func printReadme(md []byte) {
+	white := color.New(color.FgWhite).SprintFunc()
+	fmt.Println("    readme:")
+	for _, line := range strings.Split(strings.TrimRight(string(md), "\n"), "\n") {
+		fmt.Printf("      %s\n", white(line))
+	}
+	fmt.Println()
+}
+
 func printPeerInfo(i int, p *profile.Profile) {
 	white := color.New(color.FgWhite).SprintFunc()
 	// cyan := color.New(color.FgCyan).SprintFunc()
@@ -258,3 +272,15 @@ func printDiffs(diffText string) {
 	// }
 	// fmt.Printf("%s", output)
 }
+
+// signatureString colorizes a signature verification status
+func signatureString(status repo.SignatureStatus) string {
+	switch status {
+	case repo.SigValid:
+		return color.GreenString(string(status))
+	case repo.SigInvalid:
+		return color.RedString(string(status))
+	default:
+		return color.YellowString(string(status))
+	}
+}
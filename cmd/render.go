@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderFormat string
+	renderOutput string
+	renderWidth  int
+	renderHeight int
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render DATASET",
+	Short: "draw a chart of a dataset",
+	Long: `
+render draws a chart of a dataset's data, using the chart described by the
+dataset's visconfig. Charts can be written as svg, png, or a standalone html
+page. A visconfig describes the chart type (bar, line, or scatter), the
+columns to use for x & y values, and optionally how to aggregate y values that
+share an x value (sum, count, mean, min, or max):
+
+  {
+    "format": "chart",
+    "visualizations": {
+      "type": "bar",
+      "x": "city",
+      "y": "pop"
+    }
+  }
+
+add a visconfig to a dataset with "qri save --vis visconfig.json".`,
+	Example: `  render a dataset's chart as a png:
+  $ qri render me/cities --format png -o cities.png`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please provide the name of a dataset to render"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		p := &core.RenderParams{
+			Ref:    ref,
+			Format: renderFormat,
+			Width:  renderWidth,
+			Height: renderHeight,
+		}
+		res := []byte{}
+		err = req.Render(p, &res)
+		ExitIfErr(err)
+
+		if renderOutput == "" {
+			format := renderFormat
+			if format == "" {
+				format = "svg"
+			}
+			renderOutput = fmt.Sprintf("%s.%s", ref.Name, format)
+		}
+		err = ioutil.WriteFile(renderOutput, res, os.ModePerm)
+		ExitIfErr(err)
+		printSuccess("rendered %s to: %s", ref.AliasString(), renderOutput)
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderFormat, "format", "f", "svg", "output format [svg|png|html]")
+	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "", "path to write to, default is [dataset name].[format]")
+	renderCmd.Flags().IntVarP(&renderWidth, "width", "", 0, "chart width in pixels, overriding the visconfig")
+	renderCmd.Flags().IntVarP(&renderHeight, "height", "", 0, "chart height in pixels, overriding the visconfig")
+	RootCmd.AddCommand(renderCmd)
+}
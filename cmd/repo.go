@@ -2,16 +2,21 @@ package cmd
 
 import (
 	"fmt"
-	"net"
 	"net/rpc"
+	"path/filepath"
 	"strings"
 
+	"github.com/qri-io/cafs"
 	ipfs "github.com/qri-io/cafs/ipfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/core"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/fs"
+	"github.com/qri-io/qri/repo/middleware"
+	"github.com/qri-io/qri/repo/sqlite"
+	"github.com/qri-io/qri/store/localfs"
+	"github.com/qri-io/qri/store/s3"
 )
 
 var (
@@ -28,26 +33,74 @@ func getRepo(online bool) repo.Repo {
 		ErrExit(fmt.Errorf("no qri repo found, please run `qri setup`"))
 	}
 
-	fs := getIpfsFilestore(online)
-	r, err := fsrepo.NewRepo(fs, core.Config.Profile, QriRepoPath)
+	err := lockRepo()
+	ExitIfErr(err)
+
+	fs, err := getFilestore(online)
+	ExitIfErr(err)
+	r, err := newRepo(fs)
 	ExitIfErr(err)
 
 	return r
 }
 
-func getIpfsFilestore(online bool) *ipfs.Filestore {
-	fs, err := ipfs.NewFilestore(func(cfg *ipfs.StoreCfg) {
+// newRepo opens the repo at QriRepoPath, applying configured middleware
+func newRepo(store cafs.Filestore) (repo.Repo, error) {
+	if core.Config.Repo == nil {
+		return fsrepo.NewRepo(store, core.Config.Profile, QriRepoPath)
+	}
+
+	var (
+		r   repo.Repo
+		err error
+	)
+	switch core.Config.Repo.Type {
+	case "sqlite":
+		r, err = sqliterepo.NewRepo(store, core.Config.Profile, filepath.Join(QriRepoPath, "repo.sqlite"))
+	default:
+		r, err = fsrepo.NewRepo(store, core.Config.Profile, QriRepoPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return middleware.Wrap(r, core.Config.Repo.Middleware)
+}
+
+// getFilestore opens the content store named by the store.type config
+// setting. online only affects ipfs stores
+func getFilestore(online bool) (cafs.Filestore, error) {
+	if core.Config.Store != nil {
+		switch core.Config.Store.Type {
+		case "local":
+			return localfs.NewFilestore(LocalStorePath())
+		case "s3":
+			return s3Filestore(core.Config.Store)
+		}
+	}
+	return ipfs.NewFilestore(func(cfg *ipfs.StoreCfg) {
 		cfg.FsRepoPath = IpfsFsPath
 		cfg.Online = online
 	})
-	ExitIfErr(err)
-	return fs
+}
+
+// s3Filestore connects to an s3 store, caching reads in $QRI_PATH/store_cache
+// unless the config says otherwise
+func s3Filestore(cfg *config.Store) (cafs.Filestore, error) {
+	c := *cfg
+	if c.CacheDir == "" {
+		c.CacheDir = filepath.Join(QriRepoPath, "store_cache")
+	}
+	return s3store.NewFilestore(&c)
+}
+
+// LocalStorePath is the location of the local content store
+func LocalStorePath() string {
+	return filepath.Join(QriRepoPath, "store")
 }
 
 func datasetRequests(online bool) (*core.DatasetRequests, error) {
-	// TODO - bad bad hardcode
-	if conn, err := net.Dial("tcp", ":2504"); err == nil {
-		return core.NewDatasetRequests(nil, rpc.NewClient(conn)), nil
+	if cli, err := dialRPC(); err == nil {
+		return core.NewDatasetRequests(nil, cli), nil
 	}
 
 	if !online {
@@ -86,9 +139,8 @@ func searchRequests(online bool) (*core.SearchRequests, error) {
 }
 
 func historyRequests(online bool) (*core.HistoryRequests, error) {
-	// TODO - bad bad hardcode
-	if conn, err := net.Dial("tcp", ":2504"); err == nil {
-		return core.NewHistoryRequests(nil, rpc.NewClient(conn)), nil
+	if cli, err := dialRPC(); err == nil {
+		return core.NewHistoryRequests(nil, cli), nil
 	}
 
 	if !online {
@@ -110,12 +162,42 @@ func historyRequests(online bool) (*core.HistoryRequests, error) {
 	return req, nil
 }
 
+func changeRequestRequests(online bool) (*core.ChangeRequestRequests, error) {
+	if cli, err := dialRPC(); err == nil {
+		return core.NewChangeRequestRequests(nil, cli), nil
+	}
+
+	if !online {
+		r, cli, err := repoOrClient(online)
+		if err != nil {
+			return nil, err
+		}
+		return core.NewChangeRequestRequests(r, cli), nil
+	}
+
+	n, err := qriNode(online)
+	if err != nil {
+		return nil, err
+	}
+
+	req := core.NewChangeRequestRequests(n.Repo, nil)
+	req.Node = n
+	return req, nil
+}
+
+func repoRequests(online bool) (*core.RepoRequests, error) {
+	r, cli, err := repoOrClient(online)
+	if err != nil {
+		return nil, err
+	}
+	return core.NewRepoRequests(r, cli), nil
+}
+
 func peerRequests(online bool) (*core.PeerRequests, error) {
 	// return nil, nil
 
-	// TODO - bad bad hardcode
-	if conn, err := net.Dial("tcp", ":2504"); err == nil {
-		return core.NewPeerRequests(nil, rpc.NewClient(conn)), nil
+	if cli, err := dialRPC(); err == nil {
+		return core.NewPeerRequests(nil, cli), nil
 	}
 
 	node, err := qriNode(online)
@@ -132,21 +214,29 @@ func repoOrClient(online bool) (repo.Repo, *rpc.Client, error) {
 		return nil, rpcClient, nil
 	}
 
-	if fs, err := ipfs.NewFilestore(func(cfg *ipfs.StoreCfg) {
-		cfg.FsRepoPath = IpfsFsPath
-		cfg.Online = online
-	}); err == nil {
-		r, err := fsrepo.NewRepo(fs, core.Config.Profile, QriRepoPath)
+	// another qri process holding the repo lock is most likely a daemon,
+	// route requests to it over RPC
+	if err := lockRepo(); err != nil {
+		if _, ok := err.(*fsrepo.LockedError); ok {
+			if cli, e := dialRPC(); e == nil {
+				return nil, cli, nil
+			}
+		}
+		return nil, nil, err
+	}
+
+	if fs, err := getFilestore(online); err == nil {
+		r, err := newRepo(fs)
 		ExitIfErr(err)
 
 		return r, nil, err
 
 	} else if strings.Contains(err.Error(), "lock") {
-		conn, err := net.Dial("tcp", fmt.Sprintf(":%d", core.Config.RPC.Port))
+		cli, err := dialRPC()
 		if err != nil {
 			return nil, nil, err
 		}
-		return nil, rpc.NewClient(conn), nil
+		return nil, cli, nil
 	} else {
 		return nil, nil, err
 	}
@@ -157,19 +247,19 @@ func repoOrClient(online bool) (repo.Repo, *rpc.Client, error) {
 func qriNode(online bool) (node *p2p.QriNode, err error) {
 	var (
 		r  repo.Repo
-		fs *ipfs.Filestore
+		fs cafs.Filestore
 	)
 
-	fs, err = ipfs.NewFilestore(func(cfg *ipfs.StoreCfg) {
-		cfg.FsRepoPath = IpfsFsPath
-		cfg.Online = online
-	})
+	if err = lockRepo(); err != nil {
+		return
+	}
 
+	fs, err = getFilestore(online)
 	if err != nil {
 		return
 	}
 
-	r, err = fsrepo.NewRepo(fs, core.Config.Profile, QriRepoPath)
+	r, err = newRepo(fs)
 	if err != nil {
 		return
 	}
@@ -177,6 +267,7 @@ func qriNode(online bool) (node *p2p.QriNode, err error) {
 	node, err = p2p.NewQriNode(r, func(c *config.P2P) {
 		c.Enabled = online
 		c.QriBootstrapAddrs = core.Config.P2P.QriBootstrapAddrs
+		c.RequireSignatures = core.Config.P2P.RequireSignatures
 	})
 	if err != nil {
 		return
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"github.com/qri-io/dsdiff"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	requestListIncoming bool
+	requestListOutgoing bool
+	requestListStatus   string
+	requestListLimit    int
+	requestListOffset   int
+	requestCreateTitle  string
+	requestComment      string
+)
+
+var requestCmd = &cobra.Command{
+	Use:   "request",
+	Short: "propose & review changes to datasets",
+	Long: `
+Change requests propose a new version of a dataset to the peer that owns it, 
+much like a pull request. Save your changes as a dataset in your own repo, 
+with the latest version of the dataset you’re changing as it’s previous 
+version, then propose it with “qri request create”. The owner must be online.
+
+Owners review incoming change requests with “qri request diff”, then accept 
+them, adding the proposed version to their dataset as a new commit, or reject 
+them with a comment. The peer that proposed the change hears back if it’s 
+connected. Run “qri connect” to send & receive change requests.`,
+	Example: `  propose your version of b5/cities:
+  $ qri request create b5/cities me/cities --title "fix population counts"
+
+  review change requests proposed to you:
+  $ qri request list --incoming
+  $ qri request diff /ipfs/QmZfwmhbcgSDGqGaoMMYx8jxBGauZw75zPjnZAyfwPso7M/dataset.json
+  $ qri request accept /ipfs/QmZfwmhbcgSDGqGaoMMYx8jxBGauZw75zPjnZAyfwPso7M/dataset.json`,
+}
+
+var requestListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "list change requests",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := changeRequestRequests(false)
+		ExitIfErr(err)
+
+		p := &core.ChangeRequestListParams{
+			Incoming: requestListIncoming,
+			Outgoing: requestListOutgoing,
+			Status:   repo.ChangeRequestStatus(requestListStatus),
+			Limit:    requestListLimit,
+			Offset:   requestListOffset,
+		}
+		res := []*repo.ChangeRequest{}
+		err = req.List(p, &res)
+		ExitIfErr(err)
+
+		if len(res) == 0 {
+			printInfo("no change requests")
+			return
+		}
+		for _, cr := range res {
+			printChangeRequest(cr)
+		}
+	},
+}
+
+var requestCreateCmd = &cobra.Command{
+	Use:   "create TARGET VERSION",
+	Short: "propose a version of a dataset to it's owner",
+	Args:  cobra.ExactArgs(2),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := changeRequestRequests(true)
+		ExitIfErr(err)
+
+		p := &core.CreateChangeRequestParams{
+			Target:  args[0],
+			Version: args[1],
+			Title:   requestCreateTitle,
+		}
+		res := &repo.ChangeRequest{}
+		err = req.Create(p, res)
+		ExitIfErr(err)
+
+		printSuccess("proposed change to %s", res.Target.AliasString())
+		printInfo("%s", res.ID)
+	},
+}
+
+var requestDiffCmd = &cobra.Command{
+	Use:   "diff ID",
+	Short: "show the changes a change request proposes",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := changeRequestRequests(false)
+		ExitIfErr(err)
+
+		id := args[0]
+		diffs := map[string]*dsdiff.SubDiff{}
+		err = req.Diff(&id, &diffs)
+		ExitIfErr(err)
+
+		result, err := dsdiff.MapDiffsToString(diffs, "listKeys")
+		ExitIfErr(err)
+		printDiffs(result)
+	},
+}
+
+var requestAcceptCmd = &cobra.Command{
+	Use:   "accept ID",
+	Short: "commit the changes a change request proposes",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := changeRequestRequests(true)
+		ExitIfErr(err)
+
+		res := &repo.ChangeRequest{}
+		err = req.Accept(&core.ReviewParams{ID: args[0], Comment: requestComment}, res)
+		ExitIfErr(err)
+
+		printSuccess("accepted change to %s", res.Target.AliasString())
+		printInfo("new version: %s", res.Commit)
+	},
+}
+
+var requestRejectCmd = &cobra.Command{
+	Use:   "reject ID",
+	Short: "close a change request without committing it",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := changeRequestRequests(true)
+		ExitIfErr(err)
+
+		res := &repo.ChangeRequest{}
+		err = req.Reject(&core.ReviewParams{ID: args[0], Comment: requestComment}, res)
+		ExitIfErr(err)
+
+		printSuccess("rejected change to %s", res.Target.AliasString())
+	},
+}
+
+func printChangeRequest(cr *repo.ChangeRequest) {
+	direction := "to"
+	peer := cr.Target.AliasString()
+	if cr.Incoming {
+		direction = "from"
+		peer = cr.FromPeername
+	}
+	printSuccess("%s  %s %s", cr.Status, direction, peer)
+	printInfo("    %s", cr.Title)
+	printInfo("    %s", cr.ID)
+	if cr.Comment != "" {
+		printInfo("    comment: %s", cr.Comment)
+	}
+}
+
+func init() {
+	requestListCmd.Flags().BoolVarP(&requestListIncoming, "incoming", "", false, "only list change requests proposed to you")
+	requestListCmd.Flags().BoolVarP(&requestListOutgoing, "outgoing", "", false, "only list change requests you proposed")
+	requestListCmd.Flags().StringVarP(&requestListStatus, "status", "s", "", "only list change requests with a status: open, accepted or rejected")
+	requestListCmd.Flags().IntVarP(&requestListLimit, "limit", "l", 25, "limit results, default 25")
+	requestListCmd.Flags().IntVarP(&requestListOffset, "offset", "o", 0, "offset results, default 0")
+	requestCreateCmd.Flags().StringVarP(&requestCreateTitle, "title", "t", "", "title of the change request, defaults to the version's commit title")
+	requestAcceptCmd.Flags().StringVarP(&requestComment, "comment", "c", "", "note to the peer that proposed the change")
+	requestRejectCmd.Flags().StringVarP(&requestComment, "comment", "c", "", "reason for rejecting the change")
+
+	requestCmd.AddCommand(requestListCmd, requestCreateCmd, requestDiffCmd, requestAcceptCmd, requestRejectCmd)
+	RootCmd.AddCommand(requestCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var resetHard bool
+
+var datasetResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "move a dataset back to an earlier version",
+	Long: `
+Reset points a dataset at an earlier version from it’s log. Any versions that 
+came after that point are dropped from the dataset’s history. Unlike revert, 
+reset doesn’t create a new version, it rewrites history, so be careful with 
+datasets other peers have already added.
+
+qri doesn’t keep a working copy of your datasets, so the only kind of reset 
+is a hard one. The --hard flag is required to make that explicit.`,
+	Example: `  reset b5/precip to an earlier version:
+  $ qri reset --hard b5/precip@/ipfs/QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please provide a reference to the version to reset to: [peername]/[datasetname]@[path]"))
+		}
+		if !resetHard {
+			ErrExit(fmt.Errorf("reset rewrites dataset history, use --hard to confirm"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		res := repo.DatasetRef{}
+		err = req.Reset(&ref, &res)
+		ExitIfErr(err)
+
+		printSuccess("reset %s to %s", res.AliasString(), res.Path)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(datasetResetCmd)
+	datasetResetCmd.Flags().BoolVarP(&resetHard, "hard", "", false, "move the dataset head, discarding later versions")
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+var datasetRevertCmd = &cobra.Command{
+	Use:   "revert",
+	Short: "create a new version of a dataset that matches an earlier version",
+	Long: `
+Revert undoes changes to a dataset by saving a new version whose data, 
+metadata, and structure match an earlier version from the dataset’s log. 
+Nothing is removed from history: the revert is itself a new version, with a 
+commit message that names the version it restores.
+
+Use “qri log [ref]” to find the path of the version you’d like to go back to.`,
+	Example: `  revert b5/precip to an earlier version:
+  $ qri revert b5/precip@/ipfs/QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			ErrExit(fmt.Errorf("please provide a reference to the version to revert to: [peername]/[datasetname]@[path]"))
+		}
+
+		ref, err := repo.ParseDatasetRef(args[0])
+		ExitIfErr(err)
+
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		res := repo.DatasetRef{}
+		err = req.Revert(&ref, &res)
+		ExitIfErr(err)
+
+		printSuccess("reverted %s to %s, new version: %s", res.AliasString(), ref.Path, res.Path)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(datasetRevertCmd)
+}
@@ -34,6 +34,7 @@ https://qri.io
 
 Feedback, questions, bug reports, and contributions are welcome!
 https://github.com/qri-io/qri/issues`,
+	PersistentPreRun: setRepoLockMode,
 }
 
 func init() {
@@ -16,6 +16,8 @@ var (
 	saveURL            string
 	saveMetaFile       string
 	saveStructureFile  string
+	saveReadmeFile     string
+	saveVisConfigFile  string
 	saveTitle          string
 	saveMessage        string
 	savePassive        bool
@@ -42,15 +44,15 @@ collaboration are in the works. Sit tight sportsfans.`,
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		var (
-			dataFile, metaFile, structureFile *os.File
-			err                               error
+			dataFile, metaFile, structureFile, readmeFile, visConfigFile *os.File
+			err                                                          error
 		)
 
 		if len(args) < 1 {
 			ErrExit(fmt.Errorf("please provide the name of an existing dataset so save updates to"))
 		}
-		if saveMetaFile == "" && saveDataFile == "" && saveStructureFile == "" {
-			ErrExit(fmt.Errorf("one of --structure, --meta or --data or --url is required"))
+		if saveMetaFile == "" && saveDataFile == "" && saveStructureFile == "" && saveReadmeFile == "" && saveVisConfigFile == "" && saveURL == "" {
+			ErrExit(fmt.Errorf("one of --structure, --meta, --readme, --vis, --data or --url is required"))
 		}
 
 		ref, err := repo.ParseDatasetRef(args[0])
@@ -62,6 +64,10 @@ collaboration are in the works. Sit tight sportsfans.`,
 		ExitIfErr(err)
 		structureFile, err = loadFileIfPath(saveStructureFile)
 		ExitIfErr(err)
+		readmeFile, err = loadFileIfPath(saveReadmeFile)
+		ExitIfErr(err)
+		visConfigFile, err = loadFileIfPath(saveVisConfigFile)
+		ExitIfErr(err)
 
 		save := &core.SaveParams{
 			Name:              ref.Name,
@@ -72,6 +78,8 @@ collaboration are in the works. Sit tight sportsfans.`,
 			DataFilename:      filepath.Base(saveDataFile),
 			MetadataFilename:  filepath.Base(saveMetaFile),
 			StructureFilename: filepath.Base(saveStructureFile),
+			ReadmeFilename:    filepath.Base(saveReadmeFile),
+			VisConfigFilename: filepath.Base(saveVisConfigFile),
 		}
 
 		if dataFile != nil {
@@ -83,6 +91,12 @@ collaboration are in the works. Sit tight sportsfans.`,
 		if structureFile != nil {
 			save.Structure = structureFile
 		}
+		if readmeFile != nil {
+			save.Readme = readmeFile
+		}
+		if visConfigFile != nil {
+			save.VisConfig = visConfigFile
+		}
 
 		req, err := datasetRequests(false)
 		ExitIfErr(err)
@@ -113,6 +127,8 @@ func init() {
 	saveCmd.Flags().StringVarP(&saveURL, "url", "", "", "url that data file can be updated from")
 	saveCmd.Flags().StringVarP(&saveMetaFile, "meta", "", "", "metadata.json file")
 	saveCmd.Flags().StringVarP(&saveStructureFile, "structure", "", "", "structure.json file")
+	saveCmd.Flags().StringVarP(&saveReadmeFile, "readme", "", "", "markdown-formatted readme file")
+	saveCmd.Flags().StringVarP(&saveVisConfigFile, "vis", "", "", "visconfig.json file describing how to chart the dataset")
 	saveCmd.Flags().StringVarP(&saveTitle, "title", "t", "", "title of commit message for save")
 	saveCmd.Flags().StringVarP(&saveMessage, "message", "m", "", "commit message for save")
 	saveCmd.Flags().BoolVarP(&saveShowValidation, "show-validation", "s", false, "display a list of validation errors upon adding")
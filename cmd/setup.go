@@ -12,6 +12,7 @@ import (
 	"github.com/qri-io/doggos"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/store/localfs"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +20,7 @@ var (
 	setupAnonymous      bool
 	setupOverwrite      bool
 	setupIPFS           bool
+	setupStore          string
 	setupPeername       string
 	setupIPFSConfigData string
 	setupConfigData     string
@@ -35,7 +37,8 @@ never run qri before, you’ll need to run setup before you can do anything.
 Setup does a few things:
 - create a qri repository to keep all of your data
 - provisions a new qri ID
-- create an IPFS repository if one doesn’t exist
+- create an IPFS repository if one doesn’t exist, or a local content store
+  when run with --store=local
 
 This command is automatically run if you invoke any qri command without first 
 running setup. If setup has already been run, by default qri won’t let you 
@@ -87,6 +90,13 @@ overwrite this info.`,
 			cfg.Profile = config.DefaultProfile()
 		}
 
+		if setupStore != "" {
+			if cfg.Store == nil {
+				cfg.Store = config.DefaultStore()
+			}
+			cfg.Store.Type = setupStore
+		}
+
 		if setupPeername != "" {
 			cfg.Profile.Peername = setupPeername
 		} else if cfg.Profile.Peername == doggos.DoggoNick(cfg.Profile.ID) && !setupAnonymous {
@@ -101,7 +111,13 @@ overwrite this info.`,
 			ErrExit(fmt.Errorf("error creating home dir: %s", err.Error()))
 		}
 
-		if setupIPFS {
+		if cfg.Store != nil && cfg.Store.Type == "local" {
+			_, err = localfs.NewFilestore(LocalStorePath())
+			ExitIfErr(err)
+		} else if cfg.Store != nil && cfg.Store.Type == "s3" {
+			_, err = s3Filestore(cfg.Store)
+			ExitIfErr(err)
+		} else if setupIPFS {
 			tmpIPFSConfigPath := ""
 			if setupIPFSConfigData != "" {
 				err = readAtFile(&setupIPFSConfigData)
@@ -139,6 +155,7 @@ func init() {
 	setupCmd.Flags().BoolVarP(&setupAnonymous, "anonymous", "a", false, "use an auto-generated peername")
 	setupCmd.Flags().BoolVarP(&setupOverwrite, "overwrite", "", false, "overwrite repo if one exists")
 	setupCmd.Flags().BoolVarP(&setupIPFS, "init-ipfs", "", true, "initialize an IPFS repo if one isn't present")
+	setupCmd.Flags().StringVarP(&setupStore, "store", "", "", "content store to use, one of 'ipfs' (default), 'local' or 's3'. s3 stores need a bucket set in --conifg-data")
 	setupCmd.Flags().StringVarP(&setupPeername, "peername", "", "", "choose your desired peername")
 	setupCmd.Flags().StringVarP(&setupIPFSConfigData, "ipfs-config", "", "", "json-encoded configuration data, specify a filepath with '@' prefix")
 	setupCmd.Flags().StringVarP(&setupConfigData, "conifg-data", "", "", "json-encoded configuration data, specify a filepath with '@' prefix")
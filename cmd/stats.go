@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo/actions"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsDays  int
+	statsDaily bool
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "show how often your datasets are used",
+	Long: `
+Stats lists each of your datasets with how many times it was:
+- served: sent to a peer that asked for it
+- listed: included in a list of datasets sent to a peer
+- read: read through the api’s /data/ endpoint
+- exported: exported with “qri export” or the api
+
+Counts are bucketed by day & kept in your repo. Nothing about who used a 
+dataset is recorded, and counts are never sent anywhere. Datasets are served 
+& listed while “qri connect” is running.`,
+	Example: `  see which datasets were used this week:
+  $ qri stats --days 7
+
+  break usage down by day:
+  $ qri stats --daily`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := repoRequests(false)
+		ExitIfErr(err)
+
+		res := []*actions.DatasetStats{}
+		err = req.Stats(&core.StatsParams{Days: statsDays}, &res)
+		ExitIfErr(err)
+
+		if len(res) == 0 {
+			printInfo("no datasets")
+			return
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		if statsDaily {
+			table.SetHeader([]string{"day", "dataset", "served", "listed", "read", "exported"})
+			for _, s := range res {
+				for _, d := range s.Days {
+					table.Append([]string{
+						d.Day,
+						d.Ref,
+						strconv.Itoa(d.Served),
+						strconv.Itoa(d.Listed),
+						strconv.Itoa(d.Read),
+						strconv.Itoa(d.Exported),
+					})
+				}
+			}
+		} else {
+			table.SetHeader([]string{"dataset", "served", "listed", "read", "exported"})
+			for _, s := range res {
+				table.Append([]string{
+					s.Ref,
+					strconv.Itoa(s.Total.Served),
+					strconv.Itoa(s.Total.Listed),
+					strconv.Itoa(s.Total.Read),
+					strconv.Itoa(s.Total.Exported),
+				})
+			}
+		}
+		table.Render()
+	},
+}
+
+func init() {
+	statsCmd.Flags().IntVarP(&statsDays, "days", "d", 30, "number of days of usage to show, counting today")
+	statsCmd.Flags().BoolVarP(&statsDaily, "daily", "", false, "show usage for each day")
+	RootCmd.AddCommand(statsCmd)
+}
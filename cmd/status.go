@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/qri-io/qri/core"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show which derived datasets are out of date",
+	Long: `
+status lists datasets in your repo that were derived from other datasets using
+a transform, and which of them are stale. A derived dataset goes stale when a
+dataset it was derived from gets a new version. Saving a new version of the
+derived dataset brings it up to date.
+
+Setting repo.derivedupdates to "rerun" in your config re-runs the transforms
+of derived datasets automatically instead of marking them stale. Each re-run
+is logged as a ds_rerun event. Datasets who's transform syntax qri can't run
+are still marked stale.`,
+	Example: `  show derived datasets:
+  $ qri status`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := datasetRequests(false)
+		ExitIfErr(err)
+
+		in := true
+		res := []core.DatasetStatus{}
+		err = req.Status(&in, &res)
+		ExitIfErr(err)
+
+		if len(res) == 0 {
+			printInfo("no derived datasets")
+			return
+		}
+
+		for _, s := range res {
+			ups := make([]string, len(s.Upstreams))
+			for i, up := range s.Upstreams {
+				ups[i] = up.AliasString()
+			}
+
+			if s.Stale {
+				printWarning("%s (stale)", s.Ref.AliasString())
+			} else {
+				printSuccess("%s (up to date)", s.Ref.AliasString())
+			}
+			printInfo("    derived from: %s", strings.Join(ups, ", "))
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+}
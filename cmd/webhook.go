@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/core"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/webhook"
+	"github.com/spf13/cobra"
+)
+
+var (
+	webhookAddEvents []string
+	webhookAddSecret string
+	webhookLogLimit  int
+	webhookLogOffset int
+)
+
+// webhookCursors gives the store of webhook cursors kept in the repo
+func webhookCursors() webhook.FileCursors {
+	return webhook.FileCursors(filepath.Join(QriRepoPath, "webhook_cursors.json"))
+}
+
+// webhooks reads the configured webhooks from the config file, falling back
+// to the loaded config if the file can't be read
+func webhooks() config.Webhooks {
+	cfg, err := config.ReadFromFile(configFilepath())
+	if err != nil {
+		return core.Config.Webhooks
+	}
+	return cfg.Webhooks
+}
+
+// webhookDeliveryLog gives the log of webhook deliveries kept in the repo
+func webhookDeliveryLog() webhook.FileDeliveryLog {
+	return webhook.FileDeliveryLog(filepath.Join(QriRepoPath, "webhook_deliveries.json"))
+}
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "manage urls notified when datasets change",
+	Long: `
+webhooks send a POST request to a url each time an event happens in your repo,
+like creating, renaming or pinning a dataset. The request body is the event as
+json. Each request has these headers:
+
+  X-Qri-Event:      the type of event
+  X-Qri-Delivery:   a unique id for the delivery
+  X-Qri-Signature:  sha256= followed by the hex HMAC-SHA256 of the body, keyed
+                    with the webhook's secret
+
+Failed deliveries are retried with backoff. Webhooks are sent by "qri connect".
+Events from commands run while connect isn't running are sent the next time it
+starts, and webhooks only get events logged after they're added.`,
+	Example: `  notify a url whenever a dataset is created or renamed:
+  $ qri webhook add https://example.com/hook --events ds_created,ds_renamed
+
+  check for failed deliveries:
+  $ qri webhook log`,
+}
+
+var webhookAddCmd = &cobra.Command{
+	Use:   "add URL",
+	Short: "add a webhook",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		events := []string{}
+		for _, e := range webhookAddEvents {
+			t, err := repo.ParseEventType(strings.TrimSpace(e))
+			ExitIfErr(err)
+			events = append(events, string(t))
+		}
+
+		hook := &config.Webhook{
+			ID:     webhook.NewID(4),
+			URL:    args[0],
+			Events: events,
+			Secret: webhookAddSecret,
+		}
+		generated := hook.Secret == ""
+		if generated {
+			hook.Secret = webhook.NewID(16)
+		}
+		ExitIfErr(hook.Validate())
+
+		// the hook's cursor starts now, so it gets events logged from here on,
+		// even if connect isn't running yet
+		ExitIfErr(webhook.StartCursor(webhookCursors(), hook.ID, time.Now()))
+		core.Config.Webhooks = append(core.Config.Webhooks, hook)
+		ExitIfErr(core.SaveConfig())
+
+		printSuccess("added webhook %s", hook.ID)
+		if generated {
+			printInfo("secret: %s", hook.Secret)
+		}
+	},
+}
+
+var webhookListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "list webhooks",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(core.Config.Webhooks) == 0 {
+			printInfo("no webhooks")
+			return
+		}
+		for _, hook := range core.Config.Webhooks {
+			events := "all events"
+			if len(hook.Events) > 0 {
+				events = strings.Join(hook.Events, ", ")
+			}
+			printSuccess("%s  %s", hook.ID, hook.URL)
+			printInfo("    %s", events)
+		}
+	},
+}
+
+var webhookRemoveCmd = &cobra.Command{
+	Use:     "remove ID",
+	Aliases: []string{"rm"},
+	Short:   "remove a webhook",
+	Args:    cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		hooks := config.Webhooks{}
+		for _, hook := range core.Config.Webhooks {
+			if hook.ID != args[0] {
+				hooks = append(hooks, hook)
+			}
+		}
+		if len(hooks) == len(core.Config.Webhooks) {
+			ErrExit(fmt.Errorf("no webhook with id: %s", args[0]))
+		}
+
+		core.Config.Webhooks = hooks
+		ExitIfErr(core.SaveConfig())
+		printSuccess("removed webhook %s", args[0])
+	},
+}
+
+var webhookLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "show recent webhook deliveries",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		dels, err := webhookDeliveryLog().Deliveries(webhookLogLimit, webhookLogOffset)
+		ExitIfErr(err)
+
+		if len(dels) == 0 {
+			printInfo("no deliveries")
+			return
+		}
+		for _, d := range dels {
+			line := fmt.Sprintf("%s  %s  %s %s -> %s (%d attempts)", d.Time.Format("2006-01-02 15:04:05"), d.WebhookID, d.EventType, d.Ref, d.URL, d.Attempts)
+			if d.Success {
+				printSuccess("%s", line)
+			} else {
+				printWarning("%s\n    %s", line, d.Error)
+			}
+		}
+	},
+}
+
+func init() {
+	webhookAddCmd.Flags().StringSliceVarP(&webhookAddEvents, "events", "e", nil, "comma-separated event types to deliver, default is all events")
+	webhookAddCmd.Flags().StringVarP(&webhookAddSecret, "secret", "s", "", "key to sign deliveries with, default is a random secret")
+	webhookLogCmd.Flags().IntVarP(&webhookLogLimit, "limit", "l", 25, "limit results, default 25")
+	webhookLogCmd.Flags().IntVarP(&webhookLogOffset, "offset", "o", 0, "offset results, default 0")
+
+	webhookCmd.AddCommand(webhookAddCmd, webhookListCmd, webhookRemoveCmd, webhookLogCmd)
+	RootCmd.AddCommand(webhookCmd)
+}
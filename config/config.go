@@ -26,6 +26,8 @@ type Config struct {
 	Webapp  *Webapp
 	RPC     *RPC
 	Logging *Logging
+
+	Webhooks Webhooks
 }
 
 // DefaultConfig gives a new default qri configuration
@@ -41,6 +43,8 @@ func DefaultConfig() *Config {
 		Webapp:  DefaultWebapp(),
 		RPC:     DefaultRPC(),
 		Logging: DefaultLogging(),
+
+		Webhooks: DefaultWebhooks(),
 	}
 }
 
@@ -222,5 +226,8 @@ func (cfg Config) Validate() error {
 	if err := cfg.RPC.Validate(); err != nil {
 		return err
 	}
-	return cfg.Logging.Validate()
+	if err := cfg.Logging.Validate(); err != nil {
+		return err
+	}
+	return cfg.Webhooks.Validate()
 }
@@ -40,6 +40,10 @@ type P2P struct {
 	// any data that is verifyably posted by the same peer
 	ProfileReplication string `json:"profilereplication"`
 
+	// RequireSignatures makes this peer refuse datasets fetched from the network
+	// unless their commit is validly signed by the author's public key
+	RequireSignatures bool `json:"requiresignatures"`
+
 	// list of addresses to bootsrap qri peers on
 	BoostrapAddrs []string `json:"bootstrapaddrs"`
 }
@@ -151,6 +155,10 @@ func (cfg P2P) Validate() error {
           "full"
         ]
       },
+      "requiresignatures": {
+        "description": "When true, datasets fetched from peers are refused unless their commit is validly signed by the author",
+        "type": "boolean"
+      },
       "bootstrapaddrs": {
         "description": "List of addresses to bootstrap qri peers on",
         "anyOf": [
@@ -111,6 +111,18 @@ func (cfg *Profile) DecodeProfile() (*profile.Profile, error) {
 		p.Profile = datastore.NewKey(cfg.Profile)
 	}
 
+	if cfg.PrivKey != "" {
+		pk, err := cfg.DecodePrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		data, err := pk.GetPublic().Bytes()
+		if err != nil {
+			return nil, err
+		}
+		p.PubKey = base64.StdEncoding.EncodeToString(data)
+	}
+
 	return p, nil
 }
 
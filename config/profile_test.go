@@ -80,3 +80,18 @@ func TestProfileValidate(t *testing.T) {
 		t.Errorf("error validating default profile: %s", err)
 	}
 }
+
+func TestProfileDecodeProfilePubKey(t *testing.T) {
+	p := DefaultProfile()
+	pro, err := p.DecodeProfile()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if pro.PubKey == "" {
+		t.Fatal("expected decoded profile to carry a public key")
+	}
+
+	if _, err := pro.PublicKey(); err != nil {
+		t.Errorf("unexpected error decoding public key: %s", err.Error())
+	}
+}
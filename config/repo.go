@@ -1,18 +1,45 @@
 package config
 
-import "github.com/qri-io/jsonschema"
+import (
+	"fmt"
+	"time"
+
+	"github.com/qri-io/jsonschema"
+)
 
 // Repo configures a qri repo
 type Repo struct {
+	// Middleware lists named repo middleware to wrap the repo in, outermost
+	// first. see the repo/middleware package for available middleware
 	Middleware []string `json:"middleware"`
-	Type       string   `json:"type"`
+	// Type is the kind of repo to use, one of "fs" or "sqlite". sqlite repos
+	// keep everything but the block store in a single database file
+	Type string `json:"type"`
+	// DerivedUpdates sets what happens to derived datasets when a dataset
+	// they were derived from changes, one of DerivedStale or DerivedRerun
+	DerivedUpdates string `json:"derivedupdates"`
+	// EventsMaxAge archives event log history older than this duration,
+	// eg: "720h". empty means keep events regardless of age
+	EventsMaxAge string `json:"eventsmaxage,omitempty"`
+	// EventsMaxCount archives the oldest event log history once the log holds
+	// more than this many events. zero means no limit
+	EventsMaxCount int `json:"eventsmaxcount,omitempty"`
 }
 
+const (
+	// DerivedStale marks derived datasets as stale when an upstream changes
+	DerivedStale = "stale"
+	// DerivedRerun re-runs the transforms of derived datasets when an upstream
+	// changes. Datasets who's transforms can't be run are marked stale
+	DerivedRerun = "rerun"
+)
+
 // DefaultRepo creates & returns a new default repo configuration
 func DefaultRepo() *Repo {
 	return &Repo{
-		Type:       "fs",
-		Middleware: []string{},
+		Type:           "fs",
+		Middleware:     []string{},
+		DerivedUpdates: DerivedStale,
 	}
 }
 
@@ -26,7 +53,7 @@ func (cfg Repo) Validate() error {
     "required": ["middleware", "type"],
     "properties": {
       "middleware": {
-        "description": "Middleware packages that need to be applied to the repo",
+        "description": "Named middleware to wrap the repo in, outermost first. built-in middleware: audit, cache, metrics, readonly. cache only sees changes other processes make to fs repos, use it with a single process for other repo types",
         "type": "array",
         "items": {
           "type": "string"
@@ -36,10 +63,47 @@ func (cfg Repo) Validate() error {
         "description": "Type of repository",
         "type": "string",
         "enum": [
-          "fs"
+          "fs",
+          "sqlite"
         ]
+      },
+      "derivedupdates": {
+        "description": "What to do with derived datasets when a dataset they were derived from changes",
+        "type": "string",
+        "enum": [
+          "",
+          "stale",
+          "rerun"
+        ]
+      },
+      "eventsmaxage": {
+        "description": "Duration of event log history to keep, eg: 720h",
+        "type": "string"
+      },
+      "eventsmaxcount": {
+        "description": "Number of events to keep in the event log, 0 for no limit",
+        "type": "integer",
+        "minimum": 0
       }
     }
   }`)
-	return validate(schema, &cfg)
+	if err := validate(schema, &cfg); err != nil {
+		return err
+	}
+	if _, err := cfg.EventsRetention(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// EventsRetention parses EventsMaxAge, returning zero if it isn't set
+func (cfg Repo) EventsRetention() (time.Duration, error) {
+	if cfg.EventsMaxAge == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(cfg.EventsMaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("invalid eventsmaxage '%s': %s", cfg.EventsMaxAge, err.Error())
+	}
+	return d, nil
 }
@@ -10,3 +10,45 @@ func TestRepoValidate(t *testing.T) {
 		t.Errorf("error validating default repo: %s", err)
 	}
 }
+
+func TestRepoValidateDerivedUpdates(t *testing.T) {
+	cases := []struct {
+		derived string
+		valid   bool
+	}{
+		{"", true},
+		{DerivedStale, true},
+		{DerivedRerun, true},
+		{"sometimes", false},
+	}
+
+	for i, c := range cases {
+		r := DefaultRepo()
+		r.DerivedUpdates = c.derived
+		if err := r.Validate(); (err == nil) != c.valid {
+			t.Errorf("case %d validity mismatch. expected valid: %t, got error: %v", i, c.valid, err)
+		}
+	}
+}
+
+func TestRepoValidateEventsRetention(t *testing.T) {
+	cases := []struct {
+		maxAge   string
+		maxCount int
+		valid    bool
+	}{
+		{"", 0, true},
+		{"720h", 1000, true},
+		{"a month", 0, false},
+		{"", -1, false},
+	}
+
+	for i, c := range cases {
+		r := DefaultRepo()
+		r.EventsMaxAge = c.maxAge
+		r.EventsMaxCount = c.maxCount
+		if err := r.Validate(); (err == nil) != c.valid {
+			t.Errorf("case %d validity mismatch. expected valid: %t, got error: %v", i, c.valid, err)
+		}
+	}
+}
@@ -1,10 +1,31 @@
 package config
 
-import "github.com/qri-io/jsonschema"
+import (
+	"fmt"
+
+	"github.com/qri-io/jsonschema"
+)
 
 // Store configures a qri content addessed file store (cafs)
 type Store struct {
+	// Type is the kind of store to use, one of:
+	// "ipfs" - an IPFS node, addressed at $IPFS_PATH
+	// "local" - a plain directory of blocks at $QRI_PATH/store, no IPFS required
+	// "s3" - an S3-compatible object store, configured by the fields below
 	Type string `json:"type"`
+	// Bucket is the s3 bucket blocks are kept in. required for s3 stores
+	Bucket string `json:"bucket,omitempty"`
+	// Prefix is prepended to the key of every object qri writes, allowing
+	// many stores to share a bucket
+	Prefix string `json:"prefix,omitempty"`
+	// Endpoint overrides the s3 API address, for S3-compatible services like
+	// MinIO. empty uses AWS
+	Endpoint string `json:"endpoint,omitempty"`
+	// Region is the s3 region of the bucket
+	Region string `json:"region,omitempty"`
+	// CacheDir is a local directory to cache blocks read from s3 in. empty
+	// uses $QRI_PATH/store_cache
+	CacheDir string `json:"cachedir,omitempty"`
 }
 
 // DefaultStore returns a new default Store configuration
@@ -27,10 +48,38 @@ func (cfg Store) Validate() error {
         "description": "Type of store",
         "type": "string",
         "enum": [
-          "ipfs"
+          "ipfs",
+          "local",
+          "s3"
         ]
+      },
+      "bucket": {
+        "description": "Bucket to keep blocks in, for s3 stores",
+        "type": "string"
+      },
+      "prefix": {
+        "description": "Prefix for the keys of objects written to s3",
+        "type": "string"
+      },
+      "endpoint": {
+        "description": "Address of an S3-compatible API, empty for AWS",
+        "type": "string"
+      },
+      "region": {
+        "description": "Region of the s3 bucket",
+        "type": "string"
+      },
+      "cachedir": {
+        "description": "Local directory to cache blocks read from s3 in",
+        "type": "string"
       }
     }
   }`)
-	return validate(schema, &cfg)
+	if err := validate(schema, &cfg); err != nil {
+		return err
+	}
+	if cfg.Type == "s3" && cfg.Bucket == "" {
+		return fmt.Errorf("store.bucket is required for s3 stores")
+	}
+	return nil
 }
@@ -10,3 +10,20 @@ func TestStoreValidate(t *testing.T) {
 		t.Errorf("error validating default store: %s", err)
 	}
 }
+
+func TestStoreValidateS3(t *testing.T) {
+	cases := []struct {
+		cfg   Store
+		valid bool
+	}{
+		{Store{Type: "s3", Bucket: "qri", Prefix: "blocks", Endpoint: "http://localhost:9000"}, true},
+		{Store{Type: "s3"}, false},
+		{Store{Type: "local"}, true},
+	}
+
+	for i, c := range cases {
+		if err := c.cfg.Validate(); (err == nil) != c.valid {
+			t.Errorf("case %d validity mismatch. expected valid: %t, got error: %v", i, c.valid, err)
+		}
+	}
+}
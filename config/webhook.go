@@ -0,0 +1,73 @@
+package config
+
+import "github.com/qri-io/jsonschema"
+
+// Webhook configures a url that's sent a POST request when repo events
+// happen. Requests are signed with Secret
+type Webhook struct {
+	// ID identifies the webhook for removal & in delivery logs
+	ID string `json:"id"`
+	// URL is the address deliveries are sent to
+	URL string `json:"url"`
+	// Events lists the event types to deliver, empty means all events
+	Events []string `json:"events"`
+	// Secret is the key used to sign deliveries
+	Secret string `json:"secret,omitempty"`
+}
+
+// Webhooks is a list of webhook configurations
+type Webhooks []*Webhook
+
+// DefaultWebhooks creates an empty webhooks configuration
+func DefaultWebhooks() Webhooks {
+	return Webhooks{}
+}
+
+// Validate validates all fields of each webhook returning the first error
+// found
+func (hooks Webhooks) Validate() error {
+	for _, hook := range hooks {
+		if err := hook.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates all fields of webhook returning all errors found.
+func (cfg Webhook) Validate() error {
+	if cfg.Events == nil {
+		cfg.Events = []string{}
+	}
+	schema := jsonschema.Must(`{
+    "$schema": "http://json-schema.org/draft-06/schema#",
+    "title": "Webhook",
+    "description": "Config for a webhook",
+    "type": "object",
+    "required": ["id", "url", "events"],
+    "properties": {
+      "id": {
+        "description": "Identifier for this webhook",
+        "type": "string",
+        "minLength": 1
+      },
+      "url": {
+        "description": "URL deliveries are POSTed to",
+        "type": "string",
+        "pattern": "^https?://"
+      },
+      "events": {
+        "description": "Event types to deliver, empty for all events",
+        "type": "array",
+        "items": {
+          "type": "string"
+        }
+      },
+      "secret": {
+        "description": "Key used to sign deliveries",
+        "type": "string"
+      }
+    }
+  }`)
+	return validate(schema, &cfg)
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestWebhooksValidate(t *testing.T) {
+	if err := DefaultWebhooks().Validate(); err != nil {
+		t.Errorf("error validating default webhooks: %s", err)
+	}
+
+	cases := []struct {
+		hook  *Webhook
+		valid bool
+	}{
+		{&Webhook{ID: "a1", URL: "https://example.com/hook", Events: []string{}}, true},
+		{&Webhook{ID: "a1", URL: "http://localhost:8080", Events: []string{"ds_created"}, Secret: "shh"}, true},
+		{&Webhook{ID: "a1", URL: "ftp://example.com", Events: []string{}}, false},
+		{&Webhook{ID: "", URL: "https://example.com/hook", Events: []string{}}, false},
+	}
+
+	for i, c := range cases {
+		if err := (Webhooks{c.hook}).Validate(); (err == nil) != c.valid {
+			t.Errorf("case %d validity mismatch. expected valid: %t, got error: %v", i, c.valid, err)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dsdiff"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/store/storeutil"
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest lists datasets to add or save in a single batch
+type Manifest struct {
+	Datasets []*ManifestEntry `json:"datasets" yaml:"datasets"`
+}
+
+// ManifestEntry describes the desired state of a single dataset in a manifest
+type ManifestEntry struct {
+	// Name is a dataset reference, eg: me/dataset_name. required.
+	Name string `json:"name" yaml:"name"`
+	// Data is a path to a data file. either Data or URL is required to add a dataset
+	Data string `json:"data,omitempty" yaml:"data,omitempty"`
+	// URL to download data from
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Meta is a path to a json-formatted metadata file. optional.
+	Meta string `json:"meta,omitempty" yaml:"meta,omitempty"`
+	// Structure is a path to a json-formatted structure file. optional.
+	Structure string `json:"structure,omitempty" yaml:"structure,omitempty"`
+	// Title & Message are used as the commit message when saving
+	Title   string `json:"title,omitempty" yaml:"title,omitempty"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// ReadManifest loads a yaml (or json) manifest from path. File paths in
+// manifest entries are relative to the directory the manifest lives in
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %s", err.Error())
+	}
+
+	m := &Manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %s", err.Error())
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	abs := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	for i, e := range m.Datasets {
+		if e == nil {
+			return nil, fmt.Errorf("manifest entry %d is empty", i)
+		}
+		e.Data = abs(e.Data)
+		e.Meta = abs(e.Meta)
+		e.Structure = abs(e.Structure)
+	}
+	return m, nil
+}
+
+// ApplyStatus describes the outcome of applying a single manifest entry
+type ApplyStatus string
+
+const (
+	// ApplyAdded indicates a new dataset was created
+	ApplyAdded = ApplyStatus("added")
+	// ApplySaved indicates a new version of an existing dataset was saved
+	ApplySaved = ApplyStatus("saved")
+	// ApplyUnchanged indicates the dataset already matched it's manifest entry
+	ApplyUnchanged = ApplyStatus("unchanged")
+	// ApplyFailed indicates an error occurred applying the entry
+	ApplyFailed = ApplyStatus("failed")
+)
+
+// ApplyParams defines parameters for the Apply method
+type ApplyParams struct {
+	Manifest *Manifest
+	// Jobs is the maximum number of entries to work on at once. defaults to 1
+	Jobs int
+}
+
+// ApplyResult is the outcome of applying a single manifest entry
+type ApplyResult struct {
+	Name   string
+	Ref    repo.DatasetRef
+	Status ApplyStatus
+	// Error is a description of what went wrong when Status is ApplyFailed
+	Error string
+}
+
+// Apply adds or saves each dataset in a manifest, skipping datasets that
+// haven't changed. Up to p.Jobs entries are applied at once, entries that
+// name the same dataset are applied one at a time. A failed entry doesn't
+// stop the rest from being applied, results are returned in manifest order
+func (r *DatasetRequests) Apply(p *ApplyParams, res *[]ApplyResult) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Apply", p, res)
+	}
+
+	if p.Manifest == nil {
+		return fmt.Errorf("manifest is required")
+	}
+
+	jobs := p.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	var (
+		entries = p.Manifest.Datasets
+		results = make([]ApplyResult, len(entries))
+		idxs    = make(chan int)
+		locks   = &applyLocks{names: map[string]*sync.Mutex{}}
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range idxs {
+				results[idx] = r.applyEntry(entries[idx], locks)
+			}
+		}()
+	}
+	for i := range entries {
+		idxs <- i
+	}
+	close(idxs)
+	wg.Wait()
+
+	*res = results
+	return nil
+}
+
+// applySources holds the contents of files named by a manifest entry
+type applySources struct {
+	dataFilename string
+	data         []byte
+	meta         []byte
+	structure    []byte
+}
+
+// applyLocks holds a lock for each dataset named in a manifest
+type applyLocks struct {
+	sync.Mutex
+	names map[string]*sync.Mutex
+}
+
+// acquire locks the named dataset, returning a func that unlocks it
+func (l *applyLocks) acquire(name string) func() {
+	l.Lock()
+	lock, ok := l.names[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.names[name] = lock
+	}
+	l.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// applyEntry reads the sources of a manifest entry, then adds or saves it
+// while holding the lock for the dataset it names
+func (r *DatasetRequests) applyEntry(e *ManifestEntry, locks *applyLocks) ApplyResult {
+	res := ApplyResult{Status: ApplyFailed}
+	if e == nil {
+		res.Error = "empty manifest entry"
+		return res
+	}
+	res.Name = e.Name
+
+	fail := func(err error) ApplyResult {
+		log.Debugf("error applying %s: %s", e.Name, err.Error())
+		res.Error = err.Error()
+		return res
+	}
+
+	ref, err := repo.ParseDatasetRef(e.Name)
+	if err != nil {
+		return fail(fmt.Errorf("invalid dataset name: %s", err.Error()))
+	}
+
+	src, err := readApplySources(e)
+	if err != nil {
+		return fail(err)
+	}
+
+	if err := repo.CanonicalizeDatasetRef(r.repo, &ref); err != nil {
+		return fail(err)
+	}
+
+	// checking whether the dataset exists & writing it must happen together,
+	// but only entries for the same dataset need to wait on one another
+	defer locks.acquire(ref.AliasString())()
+	// another entry for the dataset may have saved a version since ref was
+	// canonicalized, drop the path so the current head is used
+	ref.Path = ""
+
+	if _, err := r.repo.GetRef(ref); err == repo.ErrNotFound {
+		p := &InitParams{
+			Peername:     ref.Peername,
+			Name:         ref.Name,
+			DataFilename: src.dataFilename,
+		}
+		if src.data != nil {
+			p.Data = bytes.NewReader(src.data)
+		}
+		if src.meta != nil {
+			p.MetadataFilename = filepath.Base(e.Meta)
+			p.Metadata = bytes.NewReader(src.meta)
+		}
+		if src.structure != nil {
+			p.StructureFilename = filepath.Base(e.Structure)
+			p.Structure = bytes.NewReader(src.structure)
+		}
+		if err := r.Init(p, &res.Ref); err != nil {
+			return fail(err)
+		}
+		res.Status = ApplyAdded
+		return res
+	} else if err != nil {
+		return fail(err)
+	}
+
+	prev := &repo.DatasetRef{}
+	if err := r.Get(&ref, prev); err != nil {
+		return fail(fmt.Errorf("error getting previous dataset: %s", err.Error()))
+	}
+	changed, err := r.applyChanged(prev, src)
+	if err != nil {
+		return fail(err)
+	}
+	if !changed {
+		res.Ref = *prev
+		res.Status = ApplyUnchanged
+		return res
+	}
+
+	p := &SaveParams{
+		Name:         ref.Name,
+		Peername:     ref.Peername,
+		DataFilename: src.dataFilename,
+		Title:        e.Title,
+		Message:      e.Message,
+	}
+	if src.data != nil {
+		p.Data = bytes.NewReader(src.data)
+	}
+	if src.meta != nil {
+		p.MetadataFilename = filepath.Base(e.Meta)
+		p.Metadata = bytes.NewReader(src.meta)
+	}
+	if src.structure != nil {
+		p.StructureFilename = filepath.Base(e.Structure)
+		p.Structure = bytes.NewReader(src.structure)
+	}
+	if err := r.Save(p, &res.Ref); err != nil {
+		return fail(err)
+	}
+	res.Status = ApplySaved
+	return res
+}
+
+// applyClient fetches data from manifest urls. A timeout keeps one slow
+// server from stalling the whole manifest
+var applyClient = &http.Client{Timeout: 5 * time.Minute}
+
+// readApplySources loads the data, metadata & structure a manifest entry
+// points to. Data fetched from a URL records the URL in metadata the same
+// way Init & Save do
+func readApplySources(e *ManifestEntry) (src *applySources, err error) {
+	src = &applySources{}
+	if e.Data != "" && e.URL != "" {
+		return nil, fmt.Errorf("entry needs either a url or a data file, not both")
+	}
+
+	if e.Data != "" {
+		src.dataFilename = filepath.Base(e.Data)
+		if src.data, err = ioutil.ReadFile(e.Data); err != nil {
+			return nil, fmt.Errorf("error reading data file: %s", err.Error())
+		}
+	} else if e.URL != "" {
+		res, err := applyClient.Get(e.URL)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching url: %s", err.Error())
+		}
+		defer res.Body.Close()
+		src.dataFilename = filepath.Base(e.URL)
+		if src.data, err = ioutil.ReadAll(res.Body); err != nil {
+			return nil, fmt.Errorf("error reading url response: %s", err.Error())
+		}
+	}
+
+	if e.Meta != "" {
+		if src.meta, err = ioutil.ReadFile(e.Meta); err != nil {
+			return nil, fmt.Errorf("error reading metadata file: %s", err.Error())
+		}
+	}
+	if e.URL != "" {
+		mt := &dataset.Meta{}
+		if src.meta != nil {
+			if err := json.Unmarshal(src.meta, mt); err != nil {
+				return nil, fmt.Errorf("error parsing metadata json: %s", err.Error())
+			}
+		}
+		mt.DownloadPath = e.URL
+		mt.AccrualPeriodicity = "R/P1W"
+		if src.meta, err = json.Marshal(mt); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.Structure != "" {
+		if src.structure, err = ioutil.ReadFile(e.Structure); err != nil {
+			return nil, fmt.Errorf("error reading structure file: %s", err.Error())
+		}
+	}
+
+	return src, nil
+}
+
+// applyChanged checks if saving src on top of prev would change the dataset.
+// data is compared by hash, metadata & structure are merged onto the previous
+// version the way Save merges them, then diffed
+func (r *DatasetRequests) applyChanged(prev *repo.DatasetRef, src *applySources) (bool, error) {
+	ds := prev.Dataset
+	if ds == nil {
+		return true, nil
+	}
+
+	if src.data != nil {
+		same, err := sameData(r.repo.Store(), ds.DataPath, src.data)
+		if err != nil {
+			return false, err
+		}
+		if !same {
+			return true, nil
+		}
+	}
+
+	if src.meta != nil {
+		if ds.Meta == nil {
+			return true, nil
+		}
+		mt := &dataset.Meta{}
+		if err := json.Unmarshal(src.meta, mt); err != nil {
+			return false, fmt.Errorf("error parsing metadata json: %s", err.Error())
+		}
+		next := &dataset.Meta{}
+		next.Assign(ds.Meta, mt)
+		diff, err := dsdiff.DiffMeta(ds.Meta, next)
+		if err != nil {
+			return false, fmt.Errorf("error diffing metadata: %s", err.Error())
+		}
+		if diff != nil && len(diff.Deltas()) > 0 {
+			return true, nil
+		}
+	}
+
+	if src.structure != nil {
+		if ds.Structure == nil {
+			return true, nil
+		}
+		st := &dataset.Structure{}
+		if err := json.Unmarshal(src.structure, st); err != nil {
+			return false, fmt.Errorf("error parsing structure json: %s", err.Error())
+		}
+		next := &dataset.Structure{}
+		next.Assign(ds.Structure, st)
+		diff, err := dsdiff.DiffStructure(ds.Structure, next)
+		if err != nil {
+			return false, fmt.Errorf("error diffing structure: %s", err.Error())
+		}
+		if diff != nil && len(diff.Deltas()) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// sameData checks data against the file at path by hashing both, without
+// writing data to the store. A missing file is treated as different
+func sameData(store cafs.Filestore, path string, data []byte) (bool, error) {
+	if path == "" {
+		return false, nil
+	}
+	f, err := store.Get(datastore.NewKey(path))
+	if err != nil {
+		log.Debug(err.Error())
+		return false, nil
+	}
+	defer f.Close()
+
+	h := storeutil.NewHasher()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, fmt.Errorf("error reading data file %s: %s", path, err.Error())
+	}
+	prev, err := h.Hash()
+	if err != nil {
+		return false, err
+	}
+	next, err := storeutil.Hash(data)
+	if err != nil {
+		return false, err
+	}
+	return prev == next, nil
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestDatasetRequestsApply(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "qri_test_apply")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"links.json": `["http://datatogether.org","https://github.com/datatogether"]`,
+		"meta.json":  `{"title":"movies!"}`,
+		"manifest.yaml": `datasets:
+  - name: me/links
+    data: links.json
+  - name: me/movies
+    data: ../movies.csv
+  - name: me/movies
+    meta: meta.json
+    title: retitle movies
+  - name: me/missing
+    data: missing.csv
+  - name: me/cities
+`,
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), os.ModePerm); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	m, err := ReadManifest(filepath.Join(dir, "manifest.yaml"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if m.Datasets[0].Data != filepath.Join(dir, "links.json") {
+		t.Errorf("expected data path to be relative to manifest, got: %s", m.Datasets[0].Data)
+	}
+	// point the second entry at the data movies was created with
+	m.Datasets[1].Data, err = filepath.Abs("../repo/test/testdata/movies/data.csv")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := NewDatasetRequests(mr, nil)
+	res := []ApplyResult{}
+	if err := req.Apply(&ApplyParams{Manifest: m, Jobs: 3}, &res); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expect := []ApplyStatus{ApplyAdded, ApplyUnchanged, ApplySaved, ApplyFailed, ApplyUnchanged}
+	if len(res) != len(expect) {
+		t.Fatalf("expected %d results, got: %d", len(expect), len(res))
+	}
+	for i, s := range expect {
+		if res[i].Status != s {
+			t.Errorf("result %d (%s) status mismatch. expected: %s, got: %s. error: %s", i, res[i].Name, s, res[i].Status, res[i].Error)
+		}
+	}
+	if res[3].Error == "" {
+		t.Error("expected failed result to describe it's error")
+	}
+	if res[2].Ref.Dataset == nil || res[2].Ref.Dataset.Meta.Title != "movies!" {
+		t.Error("expected saved result to have new metadata")
+	}
+
+	if err := req.Apply(&ApplyParams{}, &res); err == nil {
+		t.Error("expected applying without a manifest to error")
+	}
+}
@@ -0,0 +1,375 @@
+package core
+
+import (
+	"fmt"
+	"net/rpc"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	ipfs "github.com/qri-io/cafs/ipfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dsdiff"
+	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/actions"
+)
+
+// ChangeRequestRequests encapsulates business logic for proposing changes to
+// other peers' datasets, and reviewing changes proposed to ours
+type ChangeRequestRequests struct {
+	repo repo.Repo
+	cli  *rpc.Client
+	Node *p2p.QriNode
+}
+
+// CoreRequestsName implements the Requets interface
+func (ChangeRequestRequests) CoreRequestsName() string { return "requests" }
+
+// NewChangeRequestRequests creates a ChangeRequestRequests pointer from either
+// a repo or an rpc.Client
+func NewChangeRequestRequests(r repo.Repo, cli *rpc.Client) *ChangeRequestRequests {
+	if r != nil && cli != nil {
+		panic(fmt.Errorf("both repo and client supplied to NewChangeRequestRequests"))
+	}
+
+	return &ChangeRequestRequests{
+		repo: r,
+		cli:  cli,
+	}
+}
+
+// ChangeRequestListParams defines parameters for the List method
+type ChangeRequestListParams struct {
+	// Incoming lists change requests proposed to this repo
+	Incoming bool
+	// Outgoing lists change requests this repo proposed. if neither Incoming
+	// nor Outgoing is set, both are listed
+	Outgoing bool
+	// Status limits results to change requests with a status, eg: open
+	Status repo.ChangeRequestStatus
+	Limit  int
+	Offset int
+}
+
+// List lists change requests, newest first
+func (r *ChangeRequestRequests) List(p *ChangeRequestListParams, res *[]*repo.ChangeRequest) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequestRequests.List", p, res)
+	}
+
+	all, err := r.repo.ChangeRequests().ListChangeRequests(-1, 0)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error listing change requests: %s", err.Error())
+	}
+
+	crs := []*repo.ChangeRequest{}
+	for _, cr := range all {
+		if p.Incoming != p.Outgoing && cr.Incoming != p.Incoming {
+			continue
+		}
+		if p.Status != "" && cr.Status != p.Status {
+			continue
+		}
+		crs = append(crs, cr)
+	}
+
+	if p.Offset >= len(crs) {
+		crs = []*repo.ChangeRequest{}
+	} else {
+		crs = crs[p.Offset:]
+	}
+	if p.Limit > 0 && p.Limit < len(crs) {
+		crs = crs[:p.Limit]
+	}
+
+	*res = crs
+	return nil
+}
+
+// Get fetches a change request by ID
+func (r *ChangeRequestRequests) Get(id *string, res *repo.ChangeRequest) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequestRequests.Get", id, res)
+	}
+
+	cr, err := r.repo.ChangeRequests().GetChangeRequest(*id)
+	if err != nil {
+		return fmt.Errorf("error getting change request %s: %s", *id, err.Error())
+	}
+	*res = *cr
+	return nil
+}
+
+// CreateChangeRequestParams defines parameters for the Create method
+type CreateChangeRequestParams struct {
+	// Target is the dataset to propose changes to, eg: b5/cities
+	Target string
+	// Version is a dataset in this repo with the proposed changes. It's
+	// previous version must be a version of Target
+	Version string
+	// Title briefly describes the change
+	Title string
+}
+
+// Create proposes a version of a dataset in this repo as a change to a
+// dataset another peer owns
+func (r *ChangeRequestRequests) Create(p *CreateChangeRequestParams, res *repo.ChangeRequest) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequestRequests.Create", p, res)
+	}
+	if r.Node == nil {
+		return fmt.Errorf("proposing changes requires a p2p connection")
+	}
+
+	target, err := repo.ParseDatasetRef(p.Target)
+	if err != nil {
+		return fmt.Errorf("error parsing target reference: %s", err.Error())
+	}
+	if err := repo.CanonicalizeDatasetRef(r.repo, &target); err != nil {
+		return err
+	}
+	if target.Name == "" {
+		return repo.ErrNameRequired
+	}
+	pro, err := r.repo.Profile()
+	if err != nil {
+		return err
+	}
+	if target.ProfileID == pro.ID {
+		return fmt.Errorf("can't propose changes to your own dataset")
+	}
+	if target.ProfileID == "" {
+		return fmt.Errorf("unknown peer: %s", target.Peername)
+	}
+
+	version, err := repo.ParseDatasetRef(p.Version)
+	if err != nil {
+		return fmt.Errorf("error parsing version reference: %s", err.Error())
+	}
+	if err := repo.CanonicalizeDatasetRef(r.repo, &version); err != nil {
+		return err
+	}
+	if version.Path == "" {
+		return fmt.Errorf("unknown dataset: %s", p.Version)
+	}
+	ds, err := dsfs.LoadDataset(r.repo.Store(), datastore.NewKey(version.Path))
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error loading dataset: %s", err.Error())
+	}
+	if ds.PreviousPath == "" || ds.PreviousPath == "/" {
+		return fmt.Errorf("%s has no previous version to propose changes to", p.Version)
+	}
+	if target.Path != "" && target.Path != ds.PreviousPath {
+		return fmt.Errorf("%s isn't based on the latest version of %s", p.Version, target.AliasString())
+	}
+	target.Path = ds.PreviousPath
+
+	title := p.Title
+	if title == "" && ds.Commit != nil {
+		title = ds.Commit.Title
+	}
+
+	now := time.Now()
+	cr := &repo.ChangeRequest{
+		ID:           version.Path,
+		Target:       target,
+		From:         pro.ID,
+		FromPeername: pro.Peername,
+		Title:        title,
+		Status:       repo.CRStatusOpen,
+		Created:      now,
+		Updated:      now,
+	}
+	if _, err := r.repo.ChangeRequests().GetChangeRequest(cr.ID); err == nil {
+		return repo.ErrChangeRequestExists
+	}
+
+	if err := r.Node.ProposeChange(cr); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error proposing change: %s", err.Error())
+	}
+	if err := r.repo.ChangeRequests().PutChangeRequest(cr); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error saving change request: %s", err.Error())
+	}
+
+	*res = *cr
+	return nil
+}
+
+// Diff compares the version a change request proposes with the version it
+// was proposed against
+func (r *ChangeRequestRequests) Diff(id *string, res *map[string]*dsdiff.SubDiff) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequestRequests.Diff", id, res)
+	}
+
+	cr, err := r.repo.ChangeRequests().GetChangeRequest(*id)
+	if err != nil {
+		return fmt.Errorf("error getting change request %s: %s", *id, err.Error())
+	}
+
+	base, err := r.loadVersion(cr.Target.Path)
+	if err != nil {
+		return err
+	}
+	proposed, err := r.loadVersion(cr.ID)
+	if err != nil {
+		return err
+	}
+
+	dsr := NewDatasetRequests(r.repo, nil)
+	return dsr.Diff(&DiffParams{DsLeft: base, DsRight: proposed, DiffAll: true}, res)
+}
+
+// ReviewParams defines parameters for the Accept & Reject methods
+type ReviewParams struct {
+	// ID of the change request to review
+	ID string
+	// Comment is an optional note to the proposing peer
+	Comment string
+}
+
+// Accept commits the version a change request proposes to the target dataset,
+// and tells the proposing peer. The dataset can't have changed since the
+// change was proposed
+func (r *ChangeRequestRequests) Accept(p *ReviewParams, res *repo.ChangeRequest) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequestRequests.Accept", p, res)
+	}
+
+	cr, err := r.openIncoming(p.ID)
+	if err != nil {
+		return err
+	}
+
+	pro, err := r.repo.Profile()
+	if err != nil {
+		return err
+	}
+	head, err := r.repo.GetRef(repo.DatasetRef{Peername: pro.Peername, ProfileID: pro.ID, Name: cr.Target.Name})
+	if err != nil {
+		return fmt.Errorf("error getting %s: %s", cr.Target.AliasString(), err.Error())
+	}
+	if head.Path != cr.Target.Path {
+		return fmt.Errorf("%s has changed since this change was proposed", head.AliasString())
+	}
+
+	proposed, err := r.loadVersion(cr.ID)
+	if err != nil {
+		return err
+	}
+	data, err := dsfs.LoadData(r.repo.Store(), proposed)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error loading proposed data: %s", err.Error())
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(proposed)
+	ds.PreviousPath = head.Path
+	ds.Commit = &dataset.Commit{
+		Title:   fmt.Sprintf("accepted change request from %s: %s", cr.FromPeername, cr.Title),
+		Message: p.Comment,
+	}
+	// drop component paths so the commit is written against this repo's
+	// history rather than the proposing peer's
+	if ds.Meta != nil {
+		ds.Meta.SetPath("")
+	}
+	if ds.Structure != nil {
+		ds.Structure.SetPath("")
+	}
+
+	act := actions.Dataset{r.repo}
+	ref, err := act.CreateDataset(cr.Target.Name, ds, data, true)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error committing change: %s", err.Error())
+	}
+
+	cr.Status = repo.CRStatusAccepted
+	cr.Comment = p.Comment
+	cr.Commit = ref.Path
+	return r.review(cr, res)
+}
+
+// Reject closes a change request without committing it, and tells the
+// proposing peer
+func (r *ChangeRequestRequests) Reject(p *ReviewParams, res *repo.ChangeRequest) error {
+	if r.cli != nil {
+		return r.cli.Call("ChangeRequestRequests.Reject", p, res)
+	}
+
+	cr, err := r.openIncoming(p.ID)
+	if err != nil {
+		return err
+	}
+	cr.Status = repo.CRStatusRejected
+	cr.Comment = p.Comment
+	return r.review(cr, res)
+}
+
+// openIncoming gets a change request that's waiting for this repo to
+// review it
+func (r *ChangeRequestRequests) openIncoming(id string) (*repo.ChangeRequest, error) {
+	cr, err := r.repo.ChangeRequests().GetChangeRequest(id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting change request %s: %s", id, err.Error())
+	}
+	if !cr.Incoming {
+		return nil, fmt.Errorf("only %s can review this change request", cr.Target.Peername)
+	}
+	if cr.Status != repo.CRStatusOpen {
+		return nil, fmt.Errorf("change request is already %s", cr.Status)
+	}
+	return cr, nil
+}
+
+// review saves a reviewed change request & tells the proposing peer. The
+// review stands if the peer can't be reached
+func (r *ChangeRequestRequests) review(cr *repo.ChangeRequest, res *repo.ChangeRequest) error {
+	cr.Updated = time.Now()
+	if err := r.repo.ChangeRequests().PutChangeRequest(cr); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error saving change request: %s", err.Error())
+	}
+
+	if r.Node != nil {
+		if err := r.Node.NotifyChange(cr); err != nil {
+			log.Debugf("error notifying %s: %s", cr.FromPeername, err.Error())
+		}
+	}
+
+	*res = *cr
+	return nil
+}
+
+// loadVersion loads a dataset version, fetching it from the network if it
+// isn't in this repo's store
+func (r *ChangeRequestRequests) loadVersion(path string) (*dataset.Dataset, error) {
+	store := r.repo.Store()
+	key := datastore.NewKey(path)
+
+	if fs, ok := repo.BaseStore(store).(*ipfs.Filestore); ok {
+		if has, err := fs.Has(key); err == nil && !has {
+			root := datastore.NewKey(strings.TrimSuffix(path, "/"+dsfs.PackageFileDataset.String()))
+			if _, err := fs.Fetch(cafs.SourceAny, root); err != nil {
+				log.Debug(err.Error())
+				return nil, fmt.Errorf("error fetching %s: %s", path, err.Error())
+			}
+		}
+	}
+
+	ds, err := dsfs.LoadDataset(store, key)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error loading dataset %s: %s", path, err.Error())
+	}
+	return ds, nil
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/dsdiff"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestChangeRequestRequests(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	head, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// propose writes a version of cities with a new title as if a peer had
+	// proposed it
+	propose := func(title string) *repo.ChangeRequest {
+		store := mr.Store()
+		prev, err := dsfs.LoadDataset(store, datastore.NewKey(head.Path))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		data, err := dsfs.LoadData(store, prev)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ds := &dataset.Dataset{}
+		ds.Assign(prev)
+		ds.PreviousPath = head.Path
+		ds.Meta = &dataset.Meta{Title: title}
+		ds.Structure.SetPath("")
+		path, err := dsfs.CreateDataset(store, ds, data, mr.PrivateKey(), true)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		cr := &repo.ChangeRequest{
+			ID:           path.String(),
+			Target:       head,
+			From:         profile.IDB58MustDecode("QmRdexT18WuAKVX3vPusqmJTWLeNSeJgjmMbaF5QLGHna1"),
+			FromPeername: "other_peer",
+			Title:        title,
+			Incoming:     true,
+			Status:       repo.CRStatusOpen,
+			Created:      time.Now(),
+		}
+		if err := mr.ChangeRequests().PutChangeRequest(cr); err != nil {
+			t.Fatal(err.Error())
+		}
+		return cr
+	}
+	rejected := propose("not this one")
+	accepted := propose("a better title")
+
+	req := NewChangeRequestRequests(mr, nil)
+
+	list := []*repo.ChangeRequest{}
+	if err := req.List(&ChangeRequestListParams{Incoming: true}, &list); err != nil {
+		t.Errorf("list error: %s", err.Error())
+		return
+	}
+	if len(list) != 2 {
+		t.Errorf("expected 2 incoming change requests, got: %d", len(list))
+	}
+	if err := req.List(&ChangeRequestListParams{Outgoing: true}, &list); err != nil || len(list) != 0 {
+		t.Errorf("expected no outgoing change requests, got: %d, err: %v", len(list), err)
+	}
+
+	diffs := map[string]*dsdiff.SubDiff{}
+	if err := req.Diff(&accepted.ID, &diffs); err != nil {
+		t.Errorf("diff error: %s", err.Error())
+		return
+	}
+	if diffs["meta"] == nil || len(diffs["meta"].Deltas()) == 0 {
+		t.Errorf("expected diff to include meta changes")
+	}
+
+	res := &repo.ChangeRequest{}
+	if err := req.Reject(&ReviewParams{ID: rejected.ID, Comment: "no thanks"}, res); err != nil {
+		t.Errorf("reject error: %s", err.Error())
+		return
+	}
+	if res.Status != repo.CRStatusRejected || res.Comment != "no thanks" {
+		t.Errorf("expected rejected change request, got: %#v", res)
+	}
+	if err := req.Accept(&ReviewParams{ID: rejected.ID}, res); err == nil {
+		t.Errorf("expected accepting a rejected change request to error")
+	}
+
+	if err := req.Accept(&ReviewParams{ID: accepted.ID}, res); err != nil {
+		t.Errorf("accept error: %s", err.Error())
+		return
+	}
+	if res.Status != repo.CRStatusAccepted || res.Commit == "" {
+		t.Errorf("expected accepted change request with a commit, got: %#v", res)
+	}
+
+	ref, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ref.Path != res.Commit {
+		t.Errorf("expected cities to be at the accepted commit %s, got: %s", res.Commit, ref.Path)
+	}
+	ds, err := dsfs.LoadDataset(mr.Store(), datastore.NewKey(ref.Path))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if ds.PreviousPath != head.Path || ds.Meta == nil || ds.Meta.Title != "a better title" {
+		t.Errorf("accepted commit mismatch. previous path: %s, meta: %v", ds.PreviousPath, ds.Meta)
+	}
+}
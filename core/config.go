@@ -5,6 +5,8 @@ import (
 
 	golog "github.com/ipfs/go-log"
 	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo/actions"
+	"github.com/qri-io/qri/repo/fs"
 )
 
 var (
@@ -51,6 +53,18 @@ we won't be shipping changes that require starting over.
 		}
 	}
 
+	if cfg != nil && cfg.Repo != nil {
+		// configure how derived datasets respond to changes upstream
+		actions.RerunDerived = cfg.Repo.DerivedUpdates == config.DerivedRerun
+
+		// configure how much event log history to keep
+		maxAge, e := cfg.Repo.EventsRetention()
+		if e != nil && err == nil {
+			err = e
+		}
+		fsrepo.EventRetention = fsrepo.Retention{MaxAge: maxAge, MaxCount: cfg.Repo.EventsMaxCount}
+	}
+
 	Config = cfg
 
 	return err
@@ -29,11 +29,16 @@ func Receivers(node *p2p.QriNode) []Requests {
 	dsr := NewDatasetRequests(r, nil)
 	dsr.Node = node
 
+	crr := NewChangeRequestRequests(r, nil)
+	crr.Node = node
+
 	return []Requests{
 		dsr,
 		NewHistoryRequests(r, nil),
 		NewPeerRequests(node, nil),
 		NewProfileRequests(r, nil),
 		NewSearchRequests(r, nil),
+		NewRepoRequests(r, nil),
+		crr,
 	}
 }
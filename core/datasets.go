@@ -22,6 +22,7 @@ import (
 	"github.com/qri-io/dsdiff"
 	"github.com/qri-io/jsonschema"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/render"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/actions"
 	"github.com/qri-io/varName"
@@ -142,6 +143,10 @@ func (r *DatasetRequests) List(p *ListParams, res *[]repo.DatasetRef) error {
 			}
 		}
 		replies[i].Dataset = ds
+
+		if replies[i].Stale, err = r.repo.IsStale(replies[i]); err != nil {
+			log.Debug(err.Error())
+		}
 	}
 
 	*res = replies
@@ -177,9 +182,10 @@ func (r *DatasetRequests) Get(p *repo.DatasetRef, res *repo.DatasetRef) (err err
 				// So for now we're doing this weirdness with re-creating a gob-friendly version
 				// of a dataset
 				*res = repo.DatasetRef{
-					Peername: p.Peername,
-					Name:     p.Name,
-					Path:     ref.Path,
+					Peername:     p.Peername,
+					Name:         p.Name,
+					Path:         ref.Path,
+					Verification: ref.Verification,
 					Dataset: &dataset.Dataset{
 						Commit: ds.Commit,
 						Meta:   ds.Meta,
@@ -215,6 +221,9 @@ func (r *DatasetRequests) Get(p *repo.DatasetRef, res *repo.DatasetRef) (err err
 		Path:      p.Path,
 		Dataset:   ds,
 	}
+	if _, err := actions.VerifyDataset(r.repo, res); err != nil {
+		log.Debug(err.Error())
+	}
 	return nil
 }
 
@@ -353,6 +362,10 @@ type SaveParams struct {
 	Metadata          io.Reader // stream of complete dataset update. optional.
 	StructureFilename string    // filename for new data. optional.
 	Structure         io.Reader // stream of complete dataset update.
+	ReadmeFilename    string    // filename for readme file. optional.
+	Readme            io.Reader // stream of markdown-formatted readme text. optional.
+	VisConfigFilename string    // filename for visconfig file. optional.
+	VisConfig         io.Reader // stream of json-formatted visconfig. optional.
 	Title             string    // save message title. required.
 	Message           string    // save message. optional.
 }
@@ -370,6 +383,9 @@ func (r *DatasetRequests) Save(p *SaveParams, res *repo.DatasetRef) (err error)
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Save", p, res)
 	}
+	// the readme is written before the dataset that references it, hold off
+	// garbage collection until both are in place
+	defer actions.HoldStore()()
 
 	var (
 		data     []byte
@@ -391,8 +407,8 @@ func (r *DatasetRequests) Save(p *SaveParams, res *repo.DatasetRef) (err error)
 		return fmt.Errorf("error getting previous dataset: %s", err.Error())
 	}
 
-	if p.URL == "" && p.Data == nil && p.Metadata == nil && p.Structure == nil {
-		return fmt.Errorf("to save update, need a URL or data file, metadata file, or structure file")
+	if p.URL == "" && p.Data == nil && p.Metadata == nil && p.Structure == nil && p.Readme == nil && p.VisConfig == nil {
+		return fmt.Errorf("to save update, need a URL or data file, metadata file, structure file, readme file, or visconfig file")
 	}
 
 	if p.URL != "" && p.Data != nil {
@@ -462,11 +478,26 @@ func (r *DatasetRequests) Save(p *SaveParams, res *repo.DatasetRef) (err error)
 		// TODO - make this configurable via a param?
 		mt.AccrualPeriodicity = "R/P1W"
 	}
+	if p.Readme != nil {
+		md, err := ioutil.ReadAll(p.Readme)
+		if err != nil {
+			return fmt.Errorf("error reading readme: %s", err.Error())
+		}
+		if mt.ReadmePath, err = r.repo.SaveReadme(md); err != nil {
+			return err
+		}
+	}
 	changes := &dataset.Dataset{
 		Commit:    &dataset.Commit{Title: p.Title, Message: p.Message},
 		Structure: st,
 		Meta:      mt,
 	}
+	if p.VisConfig != nil {
+		changes.VisConfig = &dataset.VisConfig{}
+		if err := json.NewDecoder(p.VisConfig).Decode(changes.VisConfig); err != nil {
+			return fmt.Errorf("error parsing visconfig json: %s", err.Error())
+		}
+	}
 
 	// add all previous fields and any changes
 	ds.Assign(prev.Dataset, changes)
@@ -490,6 +521,9 @@ func (r *DatasetRequests) Save(p *SaveParams, res *repo.DatasetRef) (err error)
 	// so we reset the paths
 	ds.Meta.SetPath("")
 	ds.Structure.SetPath("")
+	if ds.VisConfig != nil {
+		ds.VisConfig.SetPath("")
+	}
 
 	dataf = cafs.NewMemfileBytes("data."+st.Format.String(), data)
 	ref, err := r.repo.CreateDataset(p.Name, ds, dataf, true)
@@ -599,6 +633,9 @@ func (r *DatasetRequests) Remove(p *repo.DatasetRef, ok *bool) (err error) {
 	}
 
 	if pinner, ok := r.repo.Store().(cafs.Pinner); ok {
+		if err := actions.UnpinReadme(r.repo, ref); err != nil {
+			log.Debug(err.Error())
+		}
 		// path := datastore.NewKey(strings.TrimSuffix(p.Path, "/"+dsfs.PackageFileDataset.String()))
 		if err = pinner.Unpin(datastore.NewKey(p.Path), true); err != nil {
 			log.Debug(err.Error())
@@ -615,6 +652,170 @@ func (r *DatasetRequests) Remove(p *repo.DatasetRef, ok *bool) (err error) {
 	return nil
 }
 
+// Revert creates a new version of a dataset whose components equal an
+// earlier version in that dataset's history. p must specify both a dataset
+// name and the path of the version to revert to
+func (r *DatasetRequests) Revert(p *repo.DatasetRef, res *repo.DatasetRef) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Revert", p, res)
+	}
+
+	if err := repo.CanonicalizeProfile(r.repo, p); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error canonicalizing reference: %s", err.Error())
+	}
+
+	if p.Name == "" || p.Path == "" {
+		return fmt.Errorf("both a dataset name and version path are required to revert: [peername]/[datasetname]@[path]")
+	}
+
+	ref, err := r.repo.RevertDataset(*p, true)
+	if err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+
+	if err = r.repo.ReadDataset(&ref); err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+
+	*res = ref
+	return nil
+}
+
+// Reset moves the head of a dataset back to an earlier version in it's
+// history, dropping any later versions from the dataset's log. p must specify
+// both a dataset name and the path of the version to reset to
+func (r *DatasetRequests) Reset(p *repo.DatasetRef, res *repo.DatasetRef) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Reset", p, res)
+	}
+
+	if err := repo.CanonicalizeProfile(r.repo, p); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error canonicalizing reference: %s", err.Error())
+	}
+
+	if p.Name == "" || p.Path == "" {
+		return fmt.Errorf("both a dataset name and version path are required to reset: [peername]/[datasetname]@[path]")
+	}
+
+	ref, err := r.repo.ResetDataset(*p)
+	if err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+
+	if err = r.repo.ReadDataset(&ref); err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+
+	*res = ref
+	return nil
+}
+
+// GraphParams defines parameters for the Graph method
+type GraphParams struct {
+	// Ref optionally limits the graph to nodes connected to one dataset
+	Ref repo.DatasetRef
+	// Ancestry includes what Ref was derived from
+	Ancestry bool
+	// Derived includes datasets derived from Ref
+	Derived bool
+}
+
+// Graph gives the lineage graph of datasets in this repo, linking datasets
+// to their previous versions, components, and transform resources
+func (r *DatasetRequests) Graph(p *GraphParams, res *repo.GraphData) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Graph", p, res)
+	}
+
+	nodes, err := r.repo.Graph()
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error getting repo graph: %s", err.Error())
+	}
+
+	if p.Ref.IsEmpty() {
+		*res = *repo.NewGraphData(nodes)
+		return nil
+	}
+
+	ref := p.Ref
+	if err := repo.CanonicalizeDatasetRef(r.repo, &ref); err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+	if ref.Path == "" {
+		return fmt.Errorf("couldn't find a version of %s to graph", p.Ref)
+	}
+
+	sub, err := repo.FilterGraph(nodes, ref.Path, p.Ancestry, p.Derived)
+	if err == repo.ErrNotFound {
+		return fmt.Errorf("%s isn't in this repo's graph", ref)
+	} else if err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+
+	g := repo.NewGraphData(sub)
+	g.Root = ref.Path
+	*res = *g
+	return nil
+}
+
+// DatasetStatus describes how a derived dataset relates to the datasets it
+// was derived from
+type DatasetStatus struct {
+	Ref       repo.DatasetRef   `json:"ref"`
+	Upstreams []repo.DatasetRef `json:"upstreams"`
+	Stale     bool              `json:"stale"`
+}
+
+// Status lists this repo's derived datasets, reporting which have fallen
+// behind the datasets they were derived from
+func (r *DatasetRequests) Status(in *bool, res *[]DatasetStatus) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Status", in, res)
+	}
+
+	count, err := r.repo.RefCount()
+	if err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+	refs, err := r.repo.References(count, 0)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error getting namespace: %s", err.Error())
+	}
+
+	statuses := []DatasetStatus{}
+	for _, ref := range refs {
+		ups, err := r.repo.Upstreams(ref)
+		if err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("error getting lineage for %s: %s", ref.AliasString(), err.Error())
+		}
+		if len(ups) == 0 {
+			continue
+		}
+		stale, err := r.repo.IsStale(ref)
+		if err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("error getting lineage for %s: %s", ref.AliasString(), err.Error())
+		}
+		ref.Stale = stale
+		statuses = append(statuses, DatasetStatus{Ref: ref, Upstreams: ups, Stale: stale})
+	}
+
+	*res = statuses
+	return nil
+}
+
 // StructuredDataParams defines parameters for retrieving
 // structured data (which is the kind of data datasets contain)
 type StructuredDataParams struct {
@@ -711,6 +912,9 @@ func (r *DatasetRequests) Add(ref *repo.DatasetRef, res *repo.DatasetRef) (err e
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Add", ref, res)
 	}
+	// fetched blocks aren't referenced until they're pinned & the reference
+	// is put, hold off garbage collection until then
+	defer actions.HoldStore()()
 
 	if err := repo.CanonicalizeDatasetRef(r.repo, ref); err != nil {
 		return fmt.Errorf("error canonicalizing new reference: %s", err.Error())
@@ -722,53 +926,114 @@ func (r *DatasetRequests) Add(ref *repo.DatasetRef, res *repo.DatasetRef) (err e
 		}
 	}
 
-	fs, ok := r.repo.Store().(*ipfs.Filestore)
-	if !ok {
-		return fmt.Errorf("can only add datasets when running an IPFS filestore")
+	store := r.repo.Store()
+	fs, isIPFS := repo.BaseStore(store).(*ipfs.Filestore)
+	pinner, canPin := store.(cafs.Pinner)
+	if isIPFS {
+		pinner, canPin = fs, true
 	}
 
 	key := datastore.NewKey(strings.TrimSuffix(ref.Path, "/"+dsfs.PackageFileDataset.String()))
 
-	_, err = fs.Fetch(cafs.SourceAny, key)
-	if err != nil {
-		return fmt.Errorf("error fetching file: %s", err.Error())
+	if isIPFS {
+		_, err = fs.Fetch(cafs.SourceAny, key)
+		if err != nil {
+			return fmt.Errorf("error fetching file: %s", err.Error())
+		}
 	}
 
-	err = fs.Pin(key, true)
+	path := datastore.NewKey(key.String() + "/" + dsfs.PackageFileDataset.String())
+
+	ds, err := dsfs.LoadDataset(store, path)
 	if err != nil {
 		log.Debug(err.Error())
-		return fmt.Errorf("error pinning root key: %s", err.Error())
+		if !isIPFS {
+			// other stores can't fetch, they can only add datasets they already hold
+			return fmt.Errorf("can only add datasets when running an IPFS filestore")
+		}
+		return fmt.Errorf("error loading newly saved dataset path: %s", path.String())
 	}
 
-	path := datastore.NewKey(key.String() + "/" + dsfs.PackageFileDataset.String())
+	// the reference must name the dataset's author, it's commit signature is
+	// checked against their key both here & every time the dataset is read
+	if ref.ProfileID == "" {
+		if err := r.resolveAuthor(ref, path.String()); err != nil {
+			return err
+		}
+	}
 
-	profile, err := r.repo.Profile()
-	if err != nil {
+	ref.Dataset = ds
+	if _, err := actions.VerifyDataset(r.repo, ref); err != nil {
 		log.Debug(err.Error())
-		return fmt.Errorf("error getting profile: %s", err)
+	}
+	if r.Node != nil && r.Node.RequireSignatures {
+		if err := actions.CheckSignature(*ref); err != nil {
+			return fmt.Errorf("refusing to add dataset, signature verification failed: %s", err.Error())
+		}
+	}
+
+	if canPin {
+		if err = pinner.Pin(key, true); err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("error pinning root key: %s", err.Error())
+		}
 	}
 
-	ref.Peername = profile.Peername
-	ref.ProfileID = profile.ID
+	if ds.Meta != nil && ds.Meta.ReadmePath != "" {
+		// readmes are stored outside the dataset package, fetch them as well
+		readme := datastore.NewKey(ds.Meta.ReadmePath)
+		if isIPFS {
+			if _, err = fs.Fetch(cafs.SourceAny, readme); err != nil {
+				log.Debug(err.Error())
+				return fmt.Errorf("error fetching readme: %s", err.Error())
+			}
+		}
+		if canPin {
+			if err = pinner.Pin(readme, true); err != nil {
+				log.Debug(err.Error())
+				return fmt.Errorf("error pinning readme: %s", err.Error())
+			}
+		}
+	}
 
 	err = r.repo.PutRef(*ref)
 	if err != nil {
 		log.Debug(err.Error())
 		return fmt.Errorf("error putting dataset name in repo: %s", err.Error())
 	}
-
-	ds, err := dsfs.LoadDataset(fs, path)
-	if err != nil {
+	if err = r.repo.LogEvent(repo.ETDsAdded, *ref); err != nil {
 		log.Debug(err.Error())
-		return fmt.Errorf("error loading newly saved dataset path: %s", path.String())
+		return fmt.Errorf("error logging added dataset: %s", err.Error())
 	}
 
-	ref.Dataset = ds
-
 	*res = *ref
 	return
 }
 
+// resolveAuthor fills in the author of a reference that doesn't name one,
+// using the reference this repo holds for the dataset's path, or failing that
+// the reference a connected peer holds
+func (r *DatasetRequests) resolveAuthor(ref *repo.DatasetRef, path string) error {
+	got, err := r.repo.GetRef(repo.DatasetRef{Path: path})
+	if err != nil && r.Node != nil {
+		got = repo.DatasetRef{Path: path}
+		if err = r.Node.RequestDataset(&got); err == nil && got.ProfileID == "" {
+			err = repo.ErrNotFound
+		}
+	}
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("can't add %s: the dataset's author is unknown, add it by peername/name instead", path)
+	}
+
+	ref.Peername = got.Peername
+	ref.ProfileID = got.ProfileID
+	if ref.Name == "" {
+		ref.Name = got.Name
+	}
+	return nil
+}
+
 // ValidateDatasetParams defines paremeters for dataset
 // data validation
 type ValidateDatasetParams struct {
@@ -875,7 +1140,7 @@ type DiffParams struct {
 	// override flag to diff full dataset without having to specify each component
 	DiffAll bool
 	// if DiffAll is false, DiffComponents specifies which components of a dataset to diff
-	// currently supported components include "structure", "data", "meta", "transform", and "visConfig"
+	// currently supported components include "structure", "data", "meta", "transform", "visConfig", and "readme"
 	DiffComponents map[string]bool
 }
 
@@ -946,6 +1211,16 @@ func (r *DatasetRequests) Diff(p *DiffParams, diffs *map[string]*dsdiff.SubDiff)
 		*diffs = diffMap
 
 	}
+	if p.DiffAll || p.DiffComponents["readme"] {
+		readmeDiffs, err := r.diffReadme(p.DsLeft, p.DsRight)
+		if err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("error diffing readme: %s", err.Error())
+		}
+		if readmeDiffs != nil {
+			(*diffs)["readme"] = readmeDiffs
+		}
+	}
 	// Hack to examine data
 	if p.DiffAll || p.DiffComponents["data"] == true {
 		sd1Params := &StructuredDataParams{
@@ -990,3 +1265,132 @@ func (r *DatasetRequests) Diff(p *DiffParams, diffs *map[string]*dsdiff.SubDiff)
 	}
 	return nil
 }
+
+// diffReadme compares the readme text of two datasets line-by-line. if
+// neither dataset has a readme the resulting diff is nil
+func (r *DatasetRequests) diffReadme(left, right *dataset.Dataset) (*dsdiff.SubDiff, error) {
+	a, err := r.repo.LoadReadme(left)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.repo.LoadReadme(right)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil && b == nil {
+		return nil, nil
+	}
+
+	lines := func(md []byte) ([]byte, error) {
+		l := []string{}
+		if len(md) > 0 {
+			l = strings.Split(string(md), "\n")
+		}
+		return json.Marshal(map[string][]string{"readme": l})
+	}
+	aBytes, err := lines(a)
+	if err != nil {
+		return nil, err
+	}
+	bBytes, err := lines(b)
+	if err != nil {
+		return nil, err
+	}
+	return dsdiff.DiffJSON(aBytes, bBytes, "readme")
+}
+
+// ReadmeParams defines parameters for the Readme method
+type ReadmeParams struct {
+	Ref repo.DatasetRef
+	// HTML renders the readme as sanitized HTML instead of markdown
+	HTML bool
+}
+
+// Readme gets the readme of a dataset. Datasets without a readme give
+// empty results
+func (r *DatasetRequests) Readme(p *ReadmeParams, res *[]byte) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Readme", p, res)
+	}
+
+	ref := &repo.DatasetRef{}
+	if err := r.Get(&p.Ref, ref); err != nil {
+		return err
+	}
+
+	md, err := r.repo.LoadReadme(ref.Dataset)
+	if err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+	if p.HTML && md != nil {
+		md = actions.RenderReadme(md)
+	}
+	*res = md
+	return nil
+}
+
+// RenderParams defines parameters for the Render method
+type RenderParams struct {
+	Ref repo.DatasetRef
+	// Format is one of "svg", "png", or "html". defaults to svg
+	Format string
+	// Width & Height override the size set by the dataset's VisConfig
+	Width, Height int
+}
+
+// Render draws a chart of a dataset's data, as configured by it's VisConfig
+func (r *DatasetRequests) Render(p *RenderParams, res *[]byte) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Render", p, res)
+	}
+
+	format, err := render.ParseFormat(p.Format)
+	if err != nil {
+		return err
+	}
+
+	ref := &repo.DatasetRef{}
+	if err := r.Get(&p.Ref, ref); err != nil {
+		return err
+	}
+	ds := ref.Dataset
+
+	chart, err := render.ChartFromVisConfig(ds.VisConfig)
+	if err != nil {
+		return err
+	}
+	if p.Width > 0 {
+		chart.Width = p.Width
+	}
+	if p.Height > 0 {
+		chart.Height = p.Height
+	}
+	if err := render.CheckSize(chart.Width, chart.Height); err != nil {
+		return err
+	}
+
+	file, err := dsfs.LoadData(r.repo.Store(), ds)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error loading dataset data: %s", err.Error())
+	}
+	defer file.Close()
+
+	er, err := dsio.NewEntryReader(ds.Structure, file)
+	if err != nil {
+		return fmt.Errorf("error allocating data reader: %s", err.Error())
+	}
+	series, err := render.ReadSeries(chart, ds.Structure, er)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := render.Render(buf, format, chart, series); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error rendering chart: %s", err.Error())
+	}
+	*res = buf.Bytes()
+	return nil
+}
@@ -2,8 +2,11 @@ package core
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/ipfs/go-datastore"
@@ -14,6 +17,7 @@ import (
 	"github.com/qri-io/jsonschema"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/actions"
+	"github.com/qri-io/qri/repo/profile"
 	testrepo "github.com/qri-io/qri/repo/test"
 )
 
@@ -211,6 +215,98 @@ func TestDatasetRequestsSave(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsReadme(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	prev := repo.DatasetRef{}
+	if err := req.Get(&repo.DatasetRef{Peername: "peer", Name: "movies"}, &prev); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	saved := repo.DatasetRef{}
+	p := &SaveParams{Name: "movies", Peername: "peer", ReadmeFilename: "readme.md", Readme: strings.NewReader("# movies\n\nsome movies")}
+	if err := req.Save(p, &saved); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	md := []byte{}
+	if err := req.Readme(&ReadmeParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}}, &md); err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(md) != "# movies\n\nsome movies" {
+		t.Errorf("readme mismatch. got: %s", md)
+	}
+
+	html := []byte{}
+	if err := req.Readme(&ReadmeParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}, HTML: true}, &html); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.Contains(string(html), "<h1>movies</h1>") {
+		t.Errorf("expected readme rendered as html, got: %s", html)
+	}
+
+	diffs := map[string]*dsdiff.SubDiff{}
+	dp := &DiffParams{
+		DsLeft:         prev.Dataset,
+		DsRight:        saved.Dataset,
+		DiffComponents: map[string]bool{"readme": true},
+	}
+	if err := req.Diff(dp, &diffs); err != nil {
+		t.Fatal(err.Error())
+	}
+	if diffs["readme"] == nil || len(diffs["readme"].Deltas()) == 0 {
+		t.Error("expected readme diff")
+	}
+}
+
+func TestDatasetRequestsRender(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+	ref := repo.DatasetRef{Peername: "peer", Name: "movies"}
+
+	res := []byte{}
+	if err := req.Render(&RenderParams{Ref: ref}, &res); err == nil {
+		t.Error("expected rendering a dataset without a visconfig to error")
+	}
+
+	vis := `{"format":"chart","visualizations":{"type":"bar","x":"title","y":"duration"}}`
+	saved := repo.DatasetRef{}
+	if err := req.Save(&SaveParams{Name: "movies", Peername: "peer", VisConfig: strings.NewReader(vis)}, &saved); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		format string
+		prefix string
+		err    string
+	}{
+		{"", "<svg", ""},
+		{"html", "<!DOCTYPE html>", ""},
+		{"png", "\x89PNG", ""},
+		{"gif", "", "invalid render format 'gif'. must be one of svg, png, or html"},
+	}
+	for i, c := range cases {
+		res := []byte{}
+		err := req.Render(&RenderParams{Ref: ref, Format: c.format, Width: 300}, &res)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if !strings.HasPrefix(string(res), c.prefix) {
+			t.Errorf("case %d expected output to start with %q", i, c.prefix)
+		}
+	}
+}
+
 func TestDatasetRequestsRename(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
@@ -281,6 +377,130 @@ func TestDatasetRequestsRemove(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsRevert(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	first, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Errorf("error getting movies ref: %s", err.Error())
+		return
+	}
+	head := &repo.DatasetRef{}
+	if err := req.Save(&SaveParams{Name: "movies", Peername: "peer", MetadataFilename: "meta.json", Metadata: bytes.NewReader([]byte(`{"title":"movies!"}`))}, head); err != nil {
+		t.Errorf("error saving movies: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p   *repo.DatasetRef
+		err string
+	}{
+		{&repo.DatasetRef{Peername: "peer", Name: "movies"}, "both a dataset name and version path are required to revert: [peername]/[datasetname]@[path]"},
+		{&repo.DatasetRef{Peername: "peer", Name: "movies", Path: head.Path}, fmt.Sprintf("peer/movies is already at version %s", head.Path)},
+		{&repo.DatasetRef{Peername: "peer", Name: "movies", Path: first.Path}, ""},
+	}
+
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Revert(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if err == nil && got.Dataset.PreviousPath != head.Path {
+			t.Errorf("case %d previous path mismatch. expected: %s, got: %s", i, head.Path, got.Dataset.PreviousPath)
+		}
+	}
+}
+
+func TestDatasetRequestsReset(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	first, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Errorf("error getting movies ref: %s", err.Error())
+		return
+	}
+	head := &repo.DatasetRef{}
+	if err := req.Save(&SaveParams{Name: "movies", Peername: "peer", MetadataFilename: "meta.json", Metadata: bytes.NewReader([]byte(`{"title":"movies!"}`))}, head); err != nil {
+		t.Errorf("error saving movies: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p   *repo.DatasetRef
+		err string
+	}{
+		{&repo.DatasetRef{Peername: "peer", Name: "movies"}, "both a dataset name and version path are required to reset: [peername]/[datasetname]@[path]"},
+		{&repo.DatasetRef{Peername: "peer", Name: "movies", Path: first.Path}, ""},
+	}
+
+	for i, c := range cases {
+		got := &repo.DatasetRef{}
+		err := req.Reset(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if err == nil && got.Path != first.Path {
+			t.Errorf("case %d path mismatch. expected: %s, got: %s", i, first.Path, got.Path)
+		}
+	}
+}
+
+func TestDatasetRequestsGraph(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	req := NewDatasetRequests(mr, nil)
+
+	movies, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Errorf("error getting movies ref: %s", err.Error())
+		return
+	}
+
+	cases := []struct {
+		p    *GraphParams
+		root string
+		err  string
+	}{
+		{&GraphParams{}, "", ""},
+		{&GraphParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}, Ancestry: true}, movies.Path, ""},
+		{&GraphParams{Ref: repo.DatasetRef{Path: "/map/QmNotInTheGraph"}}, "", "isn't in this repo's graph"},
+	}
+
+	for i, c := range cases {
+		got := &repo.GraphData{}
+		err := req.Graph(c.p, got)
+		if !(err == nil && c.err == "" || err != nil && c.err != "" && strings.Contains(err.Error(), c.err)) {
+			t.Errorf("case %d error mismatch: expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.Root != c.root {
+			t.Errorf("case %d root mismatch. expected: %s, got: %s", i, c.root, got.Root)
+		}
+		if len(got.Nodes) == 0 {
+			t.Errorf("case %d expected graph to have nodes", i)
+		}
+	}
+}
+
 func TestDatasetRequestsStructuredData(t *testing.T) {
 
 	mr, err := testrepo.NewTestRepo()
@@ -578,3 +798,80 @@ var jobsByAutomationFile2 = cafs.NewMemfileBytes("jobs_ranked_by_automation_prob
 674,"0.98","53-3031","Driver/Sales Workers"
 673,"0.98","27-4013","Radio Operators"
 `))
+
+func TestDatasetRequestsAddPeerDataset(t *testing.T) {
+	author, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	p, err := author.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pub, err := author.PrivateKey().GetPublic().Bytes()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	authorPro := &profile.Profile{ID: p.ID, Peername: p.Peername, PubKey: base64.StdEncoding.EncodeToString(pub)}
+
+	// a second peer that shares the author's store, so the dataset doesn't need
+	// fetching, and knows the author's public key
+	ps := profile.MemStore{}
+	if err := ps.PutProfile(authorPro); err != nil {
+		t.Fatal(err.Error())
+	}
+	pro := &profile.Profile{Peername: "local", ID: profile.IDB58MustDecode("QmTwtwLMKHHKCrugNxyAaZ31nhBqRUQVysT2xK911n4m6F")}
+	mr, err := repo.NewMemRepo(pro, author.Store(), ps)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ref, err := author.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// a reference that only gives a path doesn't say who authored it, and
+	// must not be attributed to us
+	if err := NewDatasetRequests(mr, nil).Add(&repo.DatasetRef{Path: ref.Path}, &repo.DatasetRef{}); err == nil {
+		t.Error("expected adding a dataset with an unknown author to error")
+	}
+
+	added := &repo.DatasetRef{}
+	if err := NewDatasetRequests(mr, nil).Add(&ref, added); err != nil {
+		t.Fatal(err.Error())
+	}
+	if added.ProfileID != authorPro.ID || added.Peername != "peer" {
+		t.Errorf("expected added dataset to keep it's author, got: %s", added)
+	}
+
+	// once held, a path resolves to it's author
+	again := &repo.DatasetRef{}
+	if err := NewDatasetRequests(mr, nil).Add(&repo.DatasetRef{Path: ref.Path}, again); err != nil {
+		t.Fatal(err.Error())
+	}
+	if again.ProfileID != authorPro.ID || again.Name != "movies" {
+		t.Errorf("expected path to resolve to it's author, got: %s", again)
+	}
+
+	got := &repo.DatasetRef{}
+	if err := NewDatasetRequests(mr, nil).Get(&repo.DatasetRef{Peername: "peer", Name: "movies"}, got); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Verification != repo.SigValid {
+		t.Errorf("expected get to verify the author's signature, got: '%s'", got.Verification)
+	}
+
+	versions := []repo.DatasetRef{}
+	if err := NewHistoryRequests(mr, nil).Log(&LogParams{Ref: repo.DatasetRef{Peername: "peer", Name: "movies"}}, &versions); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(versions) == 0 {
+		t.Error("expected log to list the added dataset")
+	}
+	for _, r := range versions {
+		if r.Verification != repo.SigValid {
+			t.Errorf("expected log to verify %s, got: '%s'", r.Path, r.Verification)
+		}
+	}
+}
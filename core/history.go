@@ -85,6 +85,9 @@ func (d *HistoryRequests) Log(params *LogParams, res *[]repo.DatasetRef) (err er
 			log.Debug(err.Error())
 			return fmt.Errorf("error adding datasets to log: %s", err.Error())
 		}
+		if _, err := actions.VerifyDataset(d.repo.Repo, &ref); err != nil {
+			log.Debug(err.Error())
+		}
 		rlog = append(rlog, ref)
 
 		limit--
@@ -34,8 +34,18 @@ func NewPeerRequests(node *p2p.QriNode, cli *rpc.Client) *PeerRequests {
 	}
 }
 
+// PeerListParams defines parameters for the List method
+type PeerListParams struct {
+	ListParams
+	// Following limits results to profiles this peer follows
+	Following bool
+	// Trust limits results to profiles with a trust level. Blocked profiles
+	// are only listed when Trust is blocked
+	Trust profile.Trust
+}
+
 // List lists Peers on the qri network
-func (d *PeerRequests) List(p *ListParams, res *[]*profile.Profile) error {
+func (d *PeerRequests) List(p *PeerListParams, res *[]*profile.Profile) error {
 	if d.cli != nil {
 		return d.cli.Call("PeerRequests.List", p, res)
 	}
@@ -58,6 +68,11 @@ func (d *PeerRequests) List(p *ListParams, res *[]*profile.Profile) error {
 		return nil
 	}
 
+	rels, err := r.Profiles().Relationships()
+	if err != nil {
+		return fmt.Errorf("error listing relationships: %s", err.Error())
+	}
+
 	i := 0
 	for _, peer := range ps {
 		if i >= p.Limit {
@@ -66,6 +81,16 @@ func (d *PeerRequests) List(p *ListParams, res *[]*profile.Profile) error {
 		if peer == nil || peer.ID == user.ID {
 			continue
 		}
+		rel, ok := rels[peer.ID]
+		if !ok {
+			rel.Trust = profile.TrustNeutral
+		}
+		if p.Following && !rel.Following {
+			continue
+		}
+		if p.Trust == "" && rel.Trust == profile.TrustBlocked || p.Trust != "" && rel.Trust != p.Trust {
+			continue
+		}
 		replies[i] = &profile.Profile{Peername: peer.Peername, ID: peer.ID}
 		i++
 	}
@@ -174,6 +199,89 @@ func (d *PeerRequests) Info(p *PeerInfoParams, res *profile.Profile) error {
 	return repo.ErrNotFound
 }
 
+// RelationshipParams defines parameters for the Follow, Unfollow & SetTrust
+// methods. Profiles are identified by ProfileID or Peername
+type RelationshipParams struct {
+	Peername  string
+	ProfileID profile.ID
+	// Trust is the level SetTrust sets
+	Trust profile.Trust
+}
+
+// Follow adds a profile to this peer's follow list
+func (d *PeerRequests) Follow(p *RelationshipParams, res *profile.Relationship) error {
+	if d.cli != nil {
+		return d.cli.Call("PeerRequests.Follow", p, res)
+	}
+
+	return d.relate(p, res, func(rel *profile.Relationship) {
+		rel.Following = true
+	})
+}
+
+// Unfollow removes a profile from this peer's follow list
+func (d *PeerRequests) Unfollow(p *RelationshipParams, res *profile.Relationship) error {
+	if d.cli != nil {
+		return d.cli.Call("PeerRequests.Unfollow", p, res)
+	}
+
+	return d.relate(p, res, func(rel *profile.Relationship) {
+		rel.Following = false
+	})
+}
+
+// SetTrust sets how far this peer trusts a profile
+func (d *PeerRequests) SetTrust(p *RelationshipParams, res *profile.Relationship) error {
+	if d.cli != nil {
+		return d.cli.Call("PeerRequests.SetTrust", p, res)
+	}
+
+	trust, err := profile.ParseTrust(string(p.Trust))
+	if err != nil {
+		return err
+	}
+	return d.relate(p, res, func(rel *profile.Relationship) {
+		rel.Trust = trust
+	})
+}
+
+// relate applies a change to this peer's relationship with a profile.
+// Peernames must belong to a known profile, profile IDs needn't be
+func (d *PeerRequests) relate(p *RelationshipParams, res *profile.Relationship, change func(rel *profile.Relationship)) error {
+	r := d.qriNode.Repo
+	id := p.ProfileID
+	if id == "" && p.Peername == "" {
+		return fmt.Errorf("peername or profile ID is required")
+	} else if id == "" {
+		pro := &profile.Profile{}
+		if err := d.Info(&PeerInfoParams{Peername: p.Peername}, pro); err != nil {
+			if err == repo.ErrNotFound {
+				return fmt.Errorf("unknown peer: %s", p.Peername)
+			}
+			return err
+		}
+		id = pro.ID
+	}
+
+	if user, err := r.Profile(); err == nil && user.ID == id {
+		return fmt.Errorf("can't set a relationship with yourself")
+	}
+
+	rel, err := r.Profiles().Relationship(id)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error getting relationship: %s", err.Error())
+	}
+	change(&rel)
+	if err := r.Profiles().PutRelationship(id, rel); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error saving relationship: %s", err.Error())
+	}
+
+	*res = rel
+	return nil
+}
+
 // PeerRefsParams defines params for the GetNamespace method
 type PeerRefsParams struct {
 	PeerID string
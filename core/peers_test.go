@@ -11,11 +11,11 @@ import (
 
 func TestPeerRequestsList(t *testing.T) {
 	cases := []struct {
-		p   *ListParams
+		p   *PeerListParams
 		res []*profile.Profile
 		err string
 	}{
-		{&ListParams{}, nil, ""},
+		{&PeerListParams{}, nil, ""},
 		// {&ListParams{Data: badDataFile}, nil, "error determining dataset schema: no file extension provided"},
 		// {&ListParams{DataFilename: badDataFile.FileName(), Data: badDataFile}, nil, "error determining dataset schema: EOF"},
 		// {&ListParams{DataFilename: jobsByAutomationFile.FileName(), Data: jobsByAutomationFile}, nil, ""},
@@ -41,6 +41,73 @@ func TestPeerRequestsList(t *testing.T) {
 	}
 }
 
+func TestPeerRequestsRelationships(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+	other := &profile.Profile{
+		ID:       profile.IDB58MustDecode("QmRdexT18WuAKVX3vPusqmJTWLeNSeJgjmMbaF5QLGHna1"),
+		Peername: "other_peer",
+	}
+	if err := mr.Profiles().PutProfile(other); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := NewPeerRequests(&p2p.QriNode{Repo: mr}, nil)
+	list := func(p *PeerListParams) []*profile.Profile {
+		p.Limit = 100
+		got := []*profile.Profile{}
+		if err := req.List(p, &got); err != nil {
+			t.Fatal(err.Error())
+		}
+		return got
+	}
+
+	rel := &profile.Relationship{}
+	if err := req.Follow(&RelationshipParams{Peername: "unknown_peer"}, rel); err == nil {
+		t.Error("expected following an unknown peer to error")
+	}
+	if err := req.Follow(&RelationshipParams{Peername: "other_peer"}, rel); err != nil {
+		t.Errorf("follow error: %s", err.Error())
+		return
+	}
+	if !rel.Following || rel.Trust != profile.TrustNeutral {
+		t.Errorf("expected following & neutral, got: %#v", rel)
+	}
+	if got := list(&PeerListParams{Following: true}); len(got) != 1 || got[0].ID != other.ID {
+		t.Errorf("expected only other_peer to be followed, got: %d peers", len(got))
+	}
+
+	if err := req.SetTrust(&RelationshipParams{ProfileID: other.ID, Trust: profile.Trust("suspicious")}, rel); err == nil {
+		t.Error("expected an invalid trust level to error")
+	}
+	if err := req.SetTrust(&RelationshipParams{ProfileID: other.ID, Trust: profile.TrustBlocked}, rel); err != nil {
+		t.Errorf("set trust error: %s", err.Error())
+		return
+	}
+	if !rel.Following || rel.Trust != profile.TrustBlocked {
+		t.Errorf("expected setting trust to keep following, got: %#v", rel)
+	}
+	for _, pro := range list(&PeerListParams{}) {
+		if pro.ID == other.ID {
+			t.Error("blocked peers shouldn't be listed by default")
+		}
+	}
+	if got := list(&PeerListParams{Trust: profile.TrustBlocked}); len(got) != 1 || got[0].ID != other.ID {
+		t.Errorf("expected other_peer to be listed as blocked, got: %d peers", len(got))
+	}
+
+	if err := req.Unfollow(&RelationshipParams{Peername: "other_peer"}, rel); err != nil {
+		t.Errorf("unfollow error: %s", err.Error())
+		return
+	}
+	if rel.Following || rel.Trust != profile.TrustBlocked {
+		t.Errorf("expected unfollowed & blocked, got: %#v", rel)
+	}
+}
+
 func TestConnectedQriProfiles(t *testing.T) {
 	// TODO - we're going to need network simulation to test this properly
 	cases := []struct {
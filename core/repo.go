@@ -0,0 +1,128 @@
+package core
+
+import (
+	"fmt"
+	"net/rpc"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/actions"
+)
+
+// RepoRequests encapsulates business logic for maintaining a qri repository
+// as a whole, as opposed to individual datasets
+type RepoRequests struct {
+	repo repo.Repo
+	cli  *rpc.Client
+}
+
+// CoreRequestsName implements the Requets interface
+func (RepoRequests) CoreRequestsName() string { return "repo" }
+
+// NewRepoRequests creates a RepoRequests pointer from either a repo
+// or an rpc.Client
+func NewRepoRequests(r repo.Repo, cli *rpc.Client) *RepoRequests {
+	if r != nil && cli != nil {
+		panic(fmt.Errorf("both repo and client supplied to NewRepoRequests"))
+	}
+
+	return &RepoRequests{
+		repo: r,
+		cli:  cli,
+	}
+}
+
+// GCParams defines parameters for the GC method
+type GCParams struct {
+	// DryRun reports what would be removed without removing anything
+	DryRun bool
+	// IPFSGC runs ipfs' own garbage collection after unpinning, which removes
+	// every unpinned block in the ipfs repo, including ones qri never used
+	IPFSGC bool
+}
+
+// GC removes blocks from the repo's store that are no longer reachable from
+// any dataset reference, dataset history, or profile photo
+func (r *RepoRequests) GC(p *GCParams, res *actions.GCResult) error {
+	if r.cli != nil {
+		return r.cli.Call("RepoRequests.GC", p, res)
+	}
+
+	result, err := actions.CollectGarbage(r.repo, p.DryRun, p.IPFSGC)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error collecting garbage: %s", err.Error())
+	}
+
+	*res = *result
+	return nil
+}
+
+// FsckParams defines parameters for the Fsck method
+type FsckParams struct {
+	// Repair drops broken references & rebuilds the search index if needed
+	Repair bool
+}
+
+// Fsck checks the integrity of every reference in the repo
+func (r *RepoRequests) Fsck(p *FsckParams, res *actions.FsckResult) error {
+	if r.cli != nil {
+		return r.cli.Call("RepoRequests.Fsck", p, res)
+	}
+
+	result, err := actions.Fsck(r.repo, p.Repair)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error checking repo: %s", err.Error())
+	}
+
+	*res = *result
+	return nil
+}
+
+// DiskUsage reports the storage used by each dataset in the repo, and what gc
+// would free. in is unused, but must be non-nil to be sent over RPC
+func (r *RepoRequests) DiskUsage(in *bool, res *actions.UsageReport) error {
+	if r.cli != nil {
+		return r.cli.Call("RepoRequests.DiskUsage", in, res)
+	}
+
+	result, err := actions.DiskUsage(r.repo)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error calculating disk usage: %s", err.Error())
+	}
+
+	*res = *result
+	return nil
+}
+
+// StatsParams defines parameters for the Stats method
+type StatsParams struct {
+	// Days is how many days of usage to include, counting today. defaults
+	// to 30
+	Days int
+}
+
+// Stats reports how often each of the repo's datasets was served to peers,
+// read through the api & exported
+func (r *RepoRequests) Stats(p *StatsParams, res *[]*actions.DatasetStats) error {
+	if r.cli != nil {
+		return r.cli.Call("RepoRequests.Stats", p, res)
+	}
+
+	days := p.Days
+	if days <= 0 {
+		days = 30
+	}
+	since := time.Now().AddDate(0, 0, 1-days)
+
+	stats, err := actions.UsageStats(r.repo, since)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error getting usage stats: %s", err.Error())
+	}
+
+	*res = stats
+	return nil
+}
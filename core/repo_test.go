@@ -0,0 +1,159 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/actions"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestRepoRequestsGC(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	req := NewRepoRequests(mr, nil)
+
+	dry := &actions.GCResult{}
+	if err := req.GC(&GCParams{DryRun: true}, dry); err != nil {
+		t.Errorf("dry run error: %s", err.Error())
+		return
+	}
+	if !dry.DryRun {
+		t.Errorf("expected dry run result")
+	}
+
+	res := &actions.GCResult{}
+	if err := req.GC(&GCParams{}, res); err != nil {
+		t.Errorf("gc error: %s", err.Error())
+		return
+	}
+	if len(res.Removed) != len(dry.Removed) || res.BytesFreed != dry.BytesFreed {
+		t.Errorf("expected gc to free what dry run reported. dry run: %d blocks %d bytes, got: %d blocks %d bytes",
+			len(dry.Removed), dry.BytesFreed, len(res.Removed), res.BytesFreed)
+	}
+
+	refs, err := mr.References(10, 0)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	dsr := NewDatasetRequests(mr, nil)
+	for _, ref := range refs {
+		got := &repo.DatasetRef{}
+		if err := dsr.Get(&ref, got); err != nil {
+			t.Errorf("error getting %s after gc: %s", ref, err.Error())
+		}
+	}
+}
+
+func TestRepoRequestsFsck(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	req := NewRepoRequests(mr, nil)
+	res := &actions.FsckResult{}
+	if err := req.Fsck(&FsckParams{}, res); err != nil {
+		t.Errorf("fsck error: %s", err.Error())
+		return
+	}
+
+	count, err := mr.RefCount()
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if res.RefsChecked != count {
+		t.Errorf("expected %d refs checked, got: %d", count, res.RefsChecked)
+	}
+	if len(res.Problems) != 0 {
+		t.Errorf("expected no problems with test repo, got: %v", res.Problems)
+	}
+}
+
+func TestRepoRequestsDiskUsage(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	req := NewRepoRequests(mr, nil)
+	in := true
+	res := &actions.UsageReport{}
+	if err := req.DiskUsage(&in, res); err != nil {
+		t.Errorf("disk usage error: %s", err.Error())
+		return
+	}
+
+	count, err := mr.RefCount()
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(res.Datasets) != count {
+		t.Errorf("expected usage for %d datasets, got: %d", count, len(res.Datasets))
+	}
+	for i, ds := range res.Datasets {
+		if ds.HeadBytes <= 0 || ds.HistoryBytes < ds.HeadBytes {
+			t.Errorf("dataset %d %s: expected history to be at least head size, got head: %d history: %d", i, ds.Ref, ds.HeadBytes, ds.HistoryBytes)
+		}
+		if i > 0 && ds.HistoryBytes > res.Datasets[i-1].HistoryBytes {
+			t.Errorf("expected datasets to be sorted by size, largest first")
+		}
+	}
+	if !res.Collectable {
+		t.Errorf("expected test repo store to be collectable")
+	}
+}
+
+func TestRepoRequestsStats(t *testing.T) {
+	mr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Errorf("error allocating test repo: %s", err.Error())
+		return
+	}
+
+	cities, err := mr.GetRef(repo.DatasetRef{Peername: "peer", Name: "cities"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	actions.RecordUsage(mr, repo.UsageRead, cities)
+	if err := mr.Analytics().CountUsage(repo.UsageServed, time.Now().AddDate(0, 0, -10), cities); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	req := NewRepoRequests(mr, nil)
+	res := []*actions.DatasetStats{}
+	if err := req.Stats(&StatsParams{}, &res); err != nil {
+		t.Errorf("stats error: %s", err.Error())
+		return
+	}
+
+	count, err := mr.RefCount()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res) != count {
+		t.Errorf("expected stats for %d datasets, got: %d", count, len(res))
+		return
+	}
+	if res[0].Ref != "peer/cities" || res[0].Total.Read != 1 || res[0].Total.Served != 1 {
+		t.Errorf("expected peer/cities to be most used, got: %s %v", res[0].Ref, res[0].Total)
+	}
+
+	if err := req.Stats(&StatsParams{Days: 1}, &res); err != nil {
+		t.Errorf("stats error: %s", err.Error())
+		return
+	}
+	if res[0].Total.Served != 0 || res[0].Total.Read != 1 {
+		t.Errorf("expected only today's usage, got: %v", res[0].Total)
+	}
+}
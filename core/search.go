@@ -7,6 +7,7 @@ import (
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/fs"
+	"github.com/qri-io/qri/repo/profile"
 )
 
 // SearchRequests encapsulates business logic for the qri search
@@ -46,13 +47,27 @@ func (d *SearchRequests) Search(p *repo.SearchParams, res *[]repo.DatasetRef) er
 	// 		return err
 	// 	}
 
-	if searchable, ok := d.repo.(repo.Searchable); ok {
+	if searchable, ok := repo.Base(d.repo).(repo.Searchable); ok {
 		results, err := searchable.Search(*p)
 		if err != nil {
 			log.Debug(err.Error())
 			return fmt.Errorf("error searching: %s", err.Error())
 		}
-		*res = results
+
+		// drop results from profiles this peer has blocked
+		profiles := d.repo.Profiles()
+		refs := make([]repo.DatasetRef, 0, len(results))
+		for _, ref := range results {
+			id := ref.ProfileID
+			if id == "" {
+				id, _ = profiles.PeernameID(ref.Peername)
+			}
+			if id != "" && profile.Blocked(profiles, id) {
+				continue
+			}
+			refs = append(refs, ref)
+		}
+		*res = refs
 		return nil
 	}
 
@@ -71,7 +86,7 @@ func (d *SearchRequests) Reindex(p *ReindexSearchParams, done *bool) error {
 		return d.cli.Call("SearchRequests.Reindex", p, done)
 	}
 
-	if fsr, ok := d.repo.(*fsrepo.Repo); ok {
+	if fsr, ok := repo.Base(d.repo).(*fsrepo.Repo); ok {
 		err := fsr.UpdateSearchIndex(d.repo.Store())
 		if err != nil {
 			log.Debug(err.Error())
@@ -0,0 +1,200 @@
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+
+	peer "gx/ipfs/QmZoWKhxUmZ2seW4BzX6fJkNR8hh9PsGModr7q171yq2SS/go-libp2p-peer"
+)
+
+// MtChangeRequest proposes a new version of a dataset to it's owner, and
+// tells the proposing peer when the owner accepts or rejects it
+const MtChangeRequest = MsgType("change_request")
+
+// changeRequestReply acknowledges a change request message
+type changeRequestReply struct {
+	Error string `json:"error,omitempty"`
+}
+
+// ProposeChange sends a change request to the owner of the dataset it
+// targets. The owner must have a connected peer, and be able to fetch the
+// proposed version from this one
+func (n *QriNode) ProposeChange(cr *repo.ChangeRequest) error {
+	log.Debugf("%s ProposeChange %s", n.ID, cr.ID)
+
+	pids := n.connectedProfilePeers(cr.Target.ProfileID)
+	if len(pids) == 0 {
+		return fmt.Errorf("%s isn't connected", cr.Target.Peername)
+	}
+	// exchange profiles so the owner knows who the change is from
+	if _, err := n.RequestProfile(pids[0]); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error exchanging profiles with %s: %s", cr.Target.Peername, err.Error())
+	}
+
+	req, err := NewJSONBodyMessage(n.ID, MtChangeRequest, cr)
+	if err != nil {
+		return err
+	}
+	return n.sendChangeRequest(req.WithHeaders("phase", "propose"), pids[0])
+}
+
+// NotifyChange tells the peer that proposed a change request it's been
+// accepted or rejected. The proposing peer must be connected
+func (n *QriNode) NotifyChange(cr *repo.ChangeRequest) error {
+	log.Debugf("%s NotifyChange %s", n.ID, cr.ID)
+
+	pids := n.connectedProfilePeers(cr.From)
+	if len(pids) == 0 {
+		return fmt.Errorf("%s isn't connected", cr.FromPeername)
+	}
+
+	req, err := NewJSONBodyMessage(n.ID, MtChangeRequest, cr)
+	if err != nil {
+		return err
+	}
+	return n.sendChangeRequest(req.WithHeaders("phase", "update"), pids[0])
+}
+
+func (n *QriNode) sendChangeRequest(req Message, pid peer.ID) error {
+	replies := make(chan Message)
+	if err := n.SendMessage(req, replies, pid); err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+
+	res := <-replies
+	reply := changeRequestReply{}
+	if err := json.Unmarshal(res.Body, &reply); err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("invalid change request response: %s", err.Error())
+	}
+	if reply.Error != "" {
+		return fmt.Errorf("%s", reply.Error)
+	}
+	return nil
+}
+
+// connectedProfilePeers lists the connected peers of a profile
+func (n *QriNode) connectedProfilePeers(id profile.ID) (pids []peer.ID) {
+	if !n.Online {
+		return nil
+	}
+	ids, err := n.Repo.Profiles().PeerIDs(id)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil
+	}
+	for _, pid := range ids {
+		if pid != n.ID && len(n.Host.Network().ConnsToPeer(pid)) > 0 {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+func (n *QriNode) handleChangeRequest(ws *WrappedStream, msg Message) (hangup bool) {
+	hangup = true
+
+	var err error
+	switch msg.Header("phase") {
+	case "propose":
+		err = n.receiveChangeProposal(msg)
+	case "update":
+		err = n.receiveChangeUpdate(msg)
+	default:
+		return
+	}
+
+	reply := changeRequestReply{}
+	if err != nil {
+		log.Debug(err.Error())
+		reply.Error = err.Error()
+	}
+	res, err := msg.UpdateJSON(reply)
+	if err != nil {
+		log.Debug(err.Error())
+		return
+	}
+	res = res.WithHeaders("phase", "response")
+	if err := ws.sendMessage(res); err != nil {
+		log.Debug(err.Error())
+	}
+	return
+}
+
+// receiveChangeProposal stores a change request proposed to this peer
+func (n *QriNode) receiveChangeProposal(msg Message) error {
+	cr := &repo.ChangeRequest{}
+	if err := json.Unmarshal(msg.Body, cr); err != nil {
+		return fmt.Errorf("invalid change request: %s", err.Error())
+	}
+	if cr.ID == "" {
+		return fmt.Errorf("change request id is required")
+	}
+
+	pro, err := n.Repo.Profile()
+	if err != nil {
+		return err
+	}
+	if cr.Target.ProfileID != pro.ID {
+		return fmt.Errorf("%s isn't %s's dataset", cr.Target.AliasString(), pro.Peername)
+	}
+	if _, err := n.Repo.GetRef(repo.DatasetRef{Peername: pro.Peername, Name: cr.Target.Name}); err != nil {
+		return fmt.Errorf("unknown dataset: %s", cr.Target.AliasString())
+	}
+	if _, err := n.Repo.ChangeRequests().GetChangeRequest(cr.ID); err == nil {
+		return repo.ErrChangeRequestExists
+	}
+
+	// the change is from whoever sent it, not who the message claims
+	from, err := n.Repo.Profiles().PeerProfile(msg.provider)
+	if err != nil {
+		return fmt.Errorf("unknown peer %s", msg.provider.Pretty())
+	}
+
+	now := time.Now()
+	cr.From = from.ID
+	cr.FromPeername = from.Peername
+	cr.Target.Peername = pro.Peername
+	cr.Target.Dataset = nil
+	cr.Incoming = true
+	cr.Status = repo.CRStatusOpen
+	cr.Comment = ""
+	cr.Commit = ""
+	cr.Created = now
+	cr.Updated = now
+	return n.Repo.ChangeRequests().PutChangeRequest(cr)
+}
+
+// receiveChangeUpdate records the owner's decision on a change request this
+// peer proposed
+func (n *QriNode) receiveChangeUpdate(msg Message) error {
+	update := &repo.ChangeRequest{}
+	if err := json.Unmarshal(msg.Body, update); err != nil {
+		return fmt.Errorf("invalid change request: %s", err.Error())
+	}
+
+	cr, err := n.Repo.ChangeRequests().GetChangeRequest(update.ID)
+	if err != nil || cr.Incoming {
+		return fmt.Errorf("unknown change request: %s", update.ID)
+	}
+	// only the owner of the target dataset can review a change
+	from, err := n.Repo.Profiles().PeerProfile(msg.provider)
+	if err != nil || from.ID != cr.Target.ProfileID {
+		return fmt.Errorf("only %s can review this change request", cr.Target.Peername)
+	}
+	if update.Status != repo.CRStatusAccepted && update.Status != repo.CRStatusRejected {
+		return fmt.Errorf("invalid change request status: %s", update.Status)
+	}
+
+	cr.Status = update.Status
+	cr.Comment = update.Comment
+	cr.Commit = update.Commit
+	cr.Updated = time.Now()
+	return n.Repo.ChangeRequests().PutChangeRequest(cr)
+}
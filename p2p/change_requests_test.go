@@ -0,0 +1,87 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+func TestChangeRequests(t *testing.T) {
+	ctx := context.Background()
+	peers, err := NewTestNetwork(ctx, t, 2)
+	if err != nil {
+		t.Errorf("error creating network: %s", err.Error())
+		return
+	}
+	if err := connectNodes(ctx, peers); err != nil {
+		t.Errorf("error connecting peers: %s", err.Error())
+		return
+	}
+	proposer, owner := peers[0], peers[1]
+
+	ownerPro, err := owner.Repo.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	target := repo.DatasetRef{Peername: ownerPro.Peername, ProfileID: ownerPro.ID, Name: "cities", Path: "/map/QmHead"}
+	if err := owner.Repo.PutRef(target); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := proposer.RequestProfile(owner.ID); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cr := &repo.ChangeRequest{
+		ID:      "/map/QmProposed",
+		Target:  target,
+		Title:   "fix a typo",
+		Status:  repo.CRStatusOpen,
+		Created: time.Now(),
+	}
+	if err := proposer.ProposeChange(&repo.ChangeRequest{ID: "/map/QmOther", Target: repo.DatasetRef{Peername: ownerPro.Peername, ProfileID: ownerPro.ID, Name: "missing"}}); err == nil {
+		t.Error("expected proposing a change to a missing dataset to error")
+	}
+	if err := proposer.ProposeChange(cr); err != nil {
+		t.Errorf("ProposeChange error: %s", err.Error())
+		return
+	}
+	if err := proposer.ProposeChange(cr); err == nil {
+		t.Error("expected proposing the same change twice to error")
+	}
+	if err := proposer.Repo.ChangeRequests().PutChangeRequest(cr); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	proposerPro, err := proposer.Repo.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	incoming, err := owner.Repo.ChangeRequests().GetChangeRequest(cr.ID)
+	if err != nil {
+		t.Errorf("owner should have the change request: %s", err.Error())
+		return
+	}
+	if !incoming.Incoming || incoming.From != proposerPro.ID || incoming.Status != repo.CRStatusOpen {
+		t.Errorf("incoming change request mismatch: %#v", incoming)
+	}
+
+	incoming.Status = repo.CRStatusRejected
+	incoming.Comment = "no thanks"
+	if err := proposer.NotifyChange(incoming); err == nil {
+		t.Error("expected notifying from a peer that doesn't own the dataset to error")
+	}
+	if err := owner.NotifyChange(incoming); err != nil {
+		t.Errorf("NotifyChange error: %s", err.Error())
+		return
+	}
+
+	outgoing, err := proposer.Repo.ChangeRequests().GetChangeRequest(cr.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if outgoing.Status != repo.CRStatusRejected || outgoing.Comment != "no thanks" {
+		t.Errorf("outgoing change request should be updated, got: %#v", outgoing)
+	}
+}
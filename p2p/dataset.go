@@ -6,6 +6,7 @@ import (
 
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/actions"
+	"github.com/qri-io/qri/repo/profile"
 )
 
 // MtDatasetInfo gets info on a dataset
@@ -22,6 +23,16 @@ func (n *QriNode) RequestDataset(ref *repo.DatasetRef) (err error) {
 		return fmt.Errorf("path is required")
 	}
 
+	// refs may name a peer without an ID, resolve it so blocks still apply
+	profiles := n.Repo.Profiles()
+	id := ref.ProfileID
+	if id == "" {
+		id, _ = profiles.PeernameID(ref.Peername)
+	}
+	if id != "" && profile.Blocked(profiles, id) {
+		return fmt.Errorf("%s is blocked", ref.Peername)
+	}
+
 	act := actions.Dataset{n.Repo}
 
 	// if peer ID is *our* peer.ID check for local dataset
@@ -51,6 +62,7 @@ func (n *QriNode) RequestDataset(ref *repo.DatasetRef) (err error) {
 		return err
 	}
 
+	var refused error
 	for _, pid := range pids {
 
 		if err := n.SendMessage(req, replies, pid); err != nil {
@@ -62,12 +74,24 @@ func (n *QriNode) RequestDataset(ref *repo.DatasetRef) (err error) {
 		dsr := repo.DatasetRef{}
 		if err := json.Unmarshal(res.Body, &dsr); err == nil {
 			if dsr.Dataset != nil {
+				if _, err := actions.VerifyDataset(n.Repo, &dsr); err != nil {
+					log.Debug(err.Error())
+				}
+				if n.RequireSignatures {
+					if refused = actions.CheckSignature(dsr); refused != nil {
+						log.Debugf("refusing dataset from %s: %s", pid, refused)
+						continue
+					}
+				}
 				*ref = dsr
-				break
+				return nil
 			}
 		}
 	}
 
+	if refused != nil {
+		return fmt.Errorf("refusing dataset, signature verification failed: %s", refused)
+	}
 	return nil
 }
 
@@ -89,6 +113,8 @@ func (n *QriNode) handleDataset(ws *WrappedStream, msg Message) (hangup bool) {
 
 				if err := act.ReadDataset(&ref); err != nil {
 					log.Debug(err.Error())
+				} else {
+					actions.RecordUsage(n.Repo, repo.UsageServed, ref)
 				}
 
 				res, err = msg.UpdateJSON(ref)
@@ -2,10 +2,12 @@ package p2p
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"testing"
 
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
 )
 
 func TestRequestDatasetInfo(t *testing.T) {
@@ -49,3 +51,32 @@ func TestRequestDatasetInfo(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestRequestDatasetBlockedPeername(t *testing.T) {
+	ctx := context.Background()
+	peers, err := NewTestNetwork(ctx, t, 2)
+	if err != nil {
+		t.Errorf("error creating network: %s", err.Error())
+		return
+	}
+	if err := connectNodes(ctx, peers); err != nil {
+		t.Errorf("error connecting peers: %s", err.Error())
+		return
+	}
+	node, blocked := peers[0], peers[1]
+
+	pro, err := node.RequestProfile(blocked.ID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := node.Repo.Profiles().PutRelationship(pro.ID, profile.Relationship{Trust: profile.TrustBlocked}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// a ref with only a peername must still be refused
+	ref := repo.DatasetRef{Peername: pro.Peername, Name: "cities", Path: "/map/QmBlocked"}
+	err = node.RequestDataset(&ref)
+	if err == nil || !strings.Contains(err.Error(), "is blocked") {
+		t.Errorf("expected blocked error, got: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/actions"
 
 	peer "gx/ipfs/QmZoWKhxUmZ2seW4BzX6fJkNR8hh9PsGModr7q171yq2SS/go-libp2p-peer"
 )
@@ -74,6 +75,7 @@ func (n *QriNode) handleDatasetsList(ws *WrappedStream, msg Message) (hangup boo
 			log.Debug(err.Error())
 			return
 		}
+		actions.RecordUsage(n.Repo, repo.UsageListed, refs...)
 
 		// replies := make([]*repo.DatasetRef, p.Limit)
 		// i := 0
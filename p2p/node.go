@@ -54,6 +54,10 @@ type QriNode struct {
 	// BootstrapAddrs is a list of multiaddresses to bootrap *qri* from (not IPFS)
 	BootstrapAddrs []string
 
+	// RequireSignatures refuses datasets from the network unless their commit
+	// is validly signed by the author
+	RequireSignatures bool
+
 	// handlers maps this nodes registered handlers. This works in a way similary to a router
 	// in traditional client/server models, but messages are flying around all over the place
 	// instead of a request/response pattern
@@ -97,6 +101,7 @@ func NewQriNode(r repo.Repo, options ...func(o *config.P2P)) (node *QriNode, err
 		Repo:               r,
 		ctx:                context.Background(),
 		BootstrapAddrs:     cfg.QriBootstrapAddrs,
+		RequireSignatures:  cfg.RequireSignatures,
 		msgState:           &sync.Map{},
 		msgChan:            make(chan Message, 10),
 		profileReplication: cfg.ProfileReplication,
@@ -107,7 +112,7 @@ func NewQriNode(r repo.Repo, options ...func(o *config.P2P)) (node *QriNode, err
 		// If the underlying content-addressed-filestore is an ipfs
 		// node, it has built-in p2p, overlay the qri protocol
 		// on the ipfs node's p2p connections.
-		if ipfsfs, ok := store.(*ipfs_filestore.Filestore); ok {
+		if ipfsfs, ok := repo.BaseStore(store).(*ipfs_filestore.Filestore); ok {
 			ipfsnode := ipfsfs.Node()
 			if ipfsnode.PeerHost != nil {
 				node.Host = ipfsnode.PeerHost
@@ -172,7 +177,7 @@ func (n *QriNode) echoMessages() {
 
 // IPFSNode returns the underlying IPFS node if this Qri Node is running on IPFS
 func (n *QriNode) IPFSNode() (*core.IpfsNode, error) {
-	if ipfsfs, ok := n.Repo.Store().(*ipfs_filestore.Filestore); ok {
+	if ipfsfs, ok := repo.BaseStore(n.Repo.Store()).(*ipfs_filestore.Filestore); ok {
 		return ipfsfs.Node(), nil
 	}
 	return nil, fmt.Errorf("not using IPFS")
@@ -322,6 +327,13 @@ func (n *QriNode) handleStream(ws *WrappedStream, replies chan Message) {
 			break
 		}
 
+		// only incoming messages are dropped, replies to our own requests
+		// are always handled
+		if replies == nil && n.peerTrust(msg.provider) == profile.TrustBlocked {
+			log.Infof("peer %s is blocked, dropping '%s' message and hanging up", msg.provider.Pretty(), msg.Type)
+			break
+		}
+
 		if replies != nil {
 			go func() { replies <- msg }()
 		}
@@ -344,12 +356,13 @@ func (n *QriNode) handleStream(ws *WrappedStream, replies chan Message) {
 // MakeHandlers generates a map of MsgTypes to their corresponding handler functions
 func MakeHandlers(n *QriNode) map[MsgType]HandlerFunc {
 	return map[MsgType]HandlerFunc{
-		MtPing:        n.handlePing,
-		MtProfile:     n.handleProfile,
-		MtProfiles:    n.handleProfiles,
-		MtDatasetInfo: n.handleDataset,
-		MtDatasets:    n.handleDatasetsList,
-		MtEvents:      n.handleEvents,
+		MtPing:          n.handlePing,
+		MtProfile:       n.handleProfile,
+		MtProfiles:      n.handleProfiles,
+		MtDatasetInfo:   n.handleDataset,
+		MtDatasets:      n.handleDatasetsList,
+		MtEvents:        n.handleEvents,
+		MtChangeRequest: n.handleChangeRequest,
 		// MtSearch:
 		// MtPeers:
 		// MtNodes:
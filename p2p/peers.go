@@ -19,7 +19,7 @@ func (n *QriNode) ClosestConnectedPeers(id profile.ID, max int) (pid []peer.ID)
 		return []peer.ID{}
 	}
 
-	if ids, err := n.Repo.Profiles().PeerIDs(id); err == nil {
+	if ids, err := n.Repo.Profiles().PeerIDs(id); err == nil && !profile.Blocked(n.Repo.Profiles(), id) {
 		for _, id := range ids {
 			if len(n.Host.Network().ConnsToPeer(id)) > 0 {
 				added++
@@ -28,8 +28,21 @@ func (n *QriNode) ClosestConnectedPeers(id profile.ID, max int) (pid []peer.ID)
 		}
 	}
 
+	// ask trusted peers before everyone else, and never ask blocked peers
+	trusted, others := []peer.ID{}, []peer.ID{}
 	for _, conn := range n.Host.Network().Conns() {
-		pid = append(pid, conn.RemotePeer())
+		switch remote := conn.RemotePeer(); n.peerTrust(remote) {
+		case profile.TrustBlocked:
+			continue
+		case profile.TrustTrusted:
+			trusted = append(trusted, remote)
+		default:
+			others = append(others, remote)
+		}
+	}
+
+	for _, remote := range append(trusted, others...) {
+		pid = append(pid, remote)
 		added++
 		if added == max {
 			break
@@ -39,6 +52,21 @@ func (n *QriNode) ClosestConnectedPeers(id profile.ID, max int) (pid []peer.ID)
 	return
 }
 
+// peerTrust gives this node's trust level for the profile a peer belongs to.
+// peers without a known profile are neutral
+func (n *QriNode) peerTrust(pid peer.ID) profile.Trust {
+	pro, err := n.Repo.Profiles().PeerProfile(pid)
+	if err != nil {
+		return profile.TrustNeutral
+	}
+	rel, err := n.Repo.Profiles().Relationship(pro.ID)
+	if err != nil {
+		log.Debug(err.Error())
+		return profile.TrustNeutral
+	}
+	return rel.Trust
+}
+
 // AddQriPeer negotiates a connection with a peer to get their profile details
 // and peer list.
 func (n *QriNode) AddQriPeer(pinfo pstore.PeerInfo) error {
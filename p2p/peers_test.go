@@ -0,0 +1,48 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestClosestConnectedPeersTrust(t *testing.T) {
+	ctx := context.Background()
+	peers, err := NewTestNetwork(ctx, t, 3)
+	if err != nil {
+		t.Errorf("error creating network: %s", err.Error())
+		return
+	}
+	if err := connectNodes(ctx, peers); err != nil {
+		t.Errorf("error connecting peers: %s", err.Error())
+		return
+	}
+	node, blocked, trusted := peers[0], peers[1], peers[2]
+
+	relate := func(n *QriNode, trust profile.Trust) {
+		pro, err := node.RequestProfile(n.ID)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := node.Repo.Profiles().PutRelationship(pro.ID, profile.Relationship{Trust: trust}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	relate(blocked, profile.TrustBlocked)
+	relate(trusted, profile.TrustTrusted)
+
+	if got := node.peerTrust(blocked.ID); got != profile.TrustBlocked {
+		t.Errorf("expected blocked peer trust to be blocked, got: %s", got)
+	}
+
+	pids := node.ClosestConnectedPeers("", 15)
+	if len(pids) == 0 || pids[0] != trusted.ID {
+		t.Errorf("expected the trusted peer first, got: %v", pids)
+	}
+	for _, pid := range pids {
+		if pid == blocked.ID {
+			t.Errorf("blocked peer %s shouldn't be included", pid.Pretty())
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package render draws charts from dataset data, as configured by a dataset's
+// VisConfig. Charts render to SVG, PNG or a standalone HTML page without
+// needing a browser
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/qri-io/dataset"
+)
+
+// ChartType enumerates the kinds of chart render can draw
+type ChartType string
+
+const (
+	// ChartBar draws a bar for each x value
+	ChartBar = ChartType("bar")
+	// ChartLine connects points in the order they appear in the data
+	ChartLine = ChartType("line")
+	// ChartScatter draws a dot for each point
+	ChartScatter = ChartType("scatter")
+)
+
+// Aggregation combines the y values of rows that share an x value
+type Aggregation string
+
+const (
+	// AggNone plots every row as-is
+	AggNone = Aggregation("")
+	// AggSum adds y values together
+	AggSum = Aggregation("sum")
+	// AggCount counts rows, ignoring y
+	AggCount = Aggregation("count")
+	// AggMean averages y values
+	AggMean = Aggregation("mean")
+	// AggMin takes the smallest y value
+	AggMin = Aggregation("min")
+	// AggMax takes the largest y value
+	AggMax = Aggregation("max")
+)
+
+const (
+	// DefaultWidth is the width of charts that don't specify one, in pixels
+	DefaultWidth = 640
+	// DefaultHeight is the height of charts that don't specify one, in pixels
+	DefaultHeight = 400
+	// MaxWidth is the widest chart render will draw, in pixels
+	MaxWidth = 4096
+	// MaxHeight is the tallest chart render will draw, in pixels
+	MaxHeight = 4096
+)
+
+// ErrChartTooLarge is returned for charts bigger than MaxWidth x MaxHeight.
+// png charts are drawn in memory, so size must be bounded
+var ErrChartTooLarge = fmt.Errorf("chart can be at most %dx%d pixels", MaxWidth, MaxHeight)
+
+// Chart configures a chart drawn from dataset data. Charts are read from the
+// "visualizations" field of a dataset's VisConfig, eg:
+//
+//	{
+//	  "format": "chart",
+//	  "visualizations": {
+//	    "type": "bar",
+//	    "title": "population by city",
+//	    "x": "city",
+//	    "y": "pop",
+//	    "aggregate": "sum"
+//	  }
+//	}
+//
+// x & y name columns in the dataset's schema, or give a column index
+type Chart struct {
+	Type      ChartType   `json:"type"`
+	Title     string      `json:"title,omitempty"`
+	X         string      `json:"x"`
+	Y         string      `json:"y,omitempty"`
+	Aggregate Aggregation `json:"aggregate,omitempty"`
+	Width     int         `json:"width,omitempty"`
+	Height    int         `json:"height,omitempty"`
+}
+
+// ChartFromVisConfig reads chart configuration from a VisConfig. When
+// visualizations is a list, the first chart is used
+func ChartFromVisConfig(vc *dataset.VisConfig) (*Chart, error) {
+	if vc == nil || vc.Visualizations == nil {
+		return nil, fmt.Errorf("dataset has no visualization config")
+	}
+
+	data, err := json.Marshal(vc.Visualizations)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding visualizations: %s", err.Error())
+	}
+
+	c := &Chart{}
+	if len(data) > 0 && data[0] == '[' {
+		charts := []*Chart{}
+		if err := json.Unmarshal(data, &charts); err != nil {
+			return nil, fmt.Errorf("error reading visualizations: %s", err.Error())
+		}
+		if len(charts) == 0 || charts[0] == nil {
+			return nil, fmt.Errorf("dataset has no visualizations")
+		}
+		c = charts[0]
+	} else if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("error reading visualizations: %s", err.Error())
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks a chart is well-formed, filling in default dimensions
+func (c *Chart) Validate() error {
+	switch c.Type {
+	case ChartBar, ChartLine, ChartScatter:
+	case "":
+		return fmt.Errorf("chart type is required")
+	default:
+		return fmt.Errorf("unsupported chart type '%s'. must be one of bar, line, or scatter", c.Type)
+	}
+
+	switch c.Aggregate {
+	case AggNone, AggSum, AggCount, AggMean, AggMin, AggMax:
+	default:
+		return fmt.Errorf("unsupported aggregate '%s'. must be one of sum, count, mean, min, or max", c.Aggregate)
+	}
+
+	if c.X == "" {
+		return fmt.Errorf("chart x column is required")
+	}
+	if c.Y == "" && c.Aggregate != AggCount {
+		return fmt.Errorf("chart y column is required unless aggregate is count")
+	}
+
+	if c.Width <= 0 {
+		c.Width = DefaultWidth
+	}
+	if c.Height <= 0 {
+		c.Height = DefaultHeight
+	}
+	return CheckSize(c.Width, c.Height)
+}
+
+// CheckSize returns ErrChartTooLarge if width or height are over the limit
+func CheckSize(width, height int) error {
+	if width > MaxWidth || height > MaxHeight {
+		return ErrChartTooLarge
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package render
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// anchor sets which part of a string of text is placed at it's coordinates
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+// canvas is a surface charts are drawn onto. coordinates are in pixels from
+// the top left corner
+type canvas interface {
+	line(x1, y1, x2, y2 float64, c color.RGBA)
+	rect(x, y, w, h float64, c color.RGBA)
+	circle(cx, cy, r float64, c color.RGBA)
+	text(x, y float64, s string, a anchor)
+}
+
+var (
+	colorAxis = color.RGBA{0x66, 0x66, 0x66, 0xff}
+	colorGrid = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	colorMark = color.RGBA{0x2b, 0x8c, 0xbe, 0xff}
+)
+
+const (
+	marginTop    = 40
+	marginRight  = 20
+	marginBottom = 50
+	marginLeft   = 60
+	yTicks       = 5
+	maxXLabels   = 10
+)
+
+// drawChart lays out & draws a chart onto a canvas
+func drawChart(cv canvas, c *Chart, s Series) {
+	var (
+		left   = float64(marginLeft)
+		top    = float64(marginTop)
+		width  = float64(c.Width - marginLeft - marginRight)
+		height = float64(c.Height - marginTop - marginBottom)
+		bottom = top + height
+	)
+
+	if c.Title != "" {
+		cv.text(float64(c.Width)/2, top/2, c.Title, anchorMiddle)
+	}
+
+	ymin, ymax := yRange(c, s)
+	yscale := func(y float64) float64 {
+		return bottom - (y-ymin)/(ymax-ymin)*height
+	}
+
+	// y axis gridlines & labels
+	for i := 0; i <= yTicks; i++ {
+		v := ymin + (ymax-ymin)*float64(i)/yTicks
+		y := yscale(v)
+		cv.line(left, y, left+width, y, colorGrid)
+		cv.text(left-6, y+4, formatNum(v), anchorEnd)
+	}
+
+	// x positions are either scaled numbers, or evenly spaced categories
+	xs := make([]float64, len(s))
+	step := width
+	if len(s) > 0 {
+		step = width / float64(len(s))
+	}
+	if c.Type != ChartBar && s.numeric() {
+		xmin, xmax := s[0].X, s[0].X
+		for _, p := range s {
+			xmin = math.Min(xmin, p.X)
+			xmax = math.Max(xmax, p.X)
+		}
+		if xmin == xmax {
+			xmin, xmax = xmin-1, xmax+1
+		}
+		for i, p := range s {
+			xs[i] = left + (p.X-xmin)/(xmax-xmin)*width
+		}
+		for i := 0; i <= yTicks; i++ {
+			v := xmin + (xmax-xmin)*float64(i)/yTicks
+			cv.text(left+width*float64(i)/yTicks, bottom+16, formatNum(v), anchorMiddle)
+		}
+	} else {
+		every := 1
+		if len(s) > maxXLabels {
+			every = int(math.Ceil(float64(len(s)) / maxXLabels))
+		}
+		for i, p := range s {
+			xs[i] = left + step*(float64(i)+0.5)
+			if i%every == 0 {
+				cv.text(xs[i], bottom+16, truncate(p.Label, int(step*float64(every)/7)), anchorMiddle)
+			}
+		}
+	}
+
+	// marks
+	zero := yscale(math.Max(ymin, math.Min(0, ymax)))
+	for i, p := range s {
+		y := yscale(p.Y)
+		switch c.Type {
+		case ChartBar:
+			w := step * 0.8
+			cv.rect(xs[i]-w/2, math.Min(y, zero), w, math.Abs(zero-y), colorMark)
+		case ChartLine:
+			if i > 0 {
+				cv.line(xs[i-1], yscale(s[i-1].Y), xs[i], y, colorMark)
+			}
+		case ChartScatter:
+			cv.circle(xs[i], y, 3, colorMark)
+		}
+	}
+
+	// axes
+	cv.line(left, top, left, bottom, colorAxis)
+	cv.line(left, bottom, left+width, bottom, colorAxis)
+	cv.text(left+width/2, bottom+40, c.X, anchorMiddle)
+	ylabel := c.Y
+	if c.Aggregate != AggNone {
+		ylabel = string(c.Aggregate)
+		if c.Y != "" && c.Aggregate != AggCount {
+			ylabel += " of " + c.Y
+		}
+	}
+	cv.text(left, top-8, ylabel, anchorStart)
+}
+
+// yRange gives the span of y values to plot. Bar charts always include zero
+func yRange(c *Chart, s Series) (min, max float64) {
+	if len(s) == 0 {
+		return 0, 1
+	}
+	min, max = s[0].Y, s[0].Y
+	for _, p := range s {
+		min = math.Min(min, p.Y)
+		max = math.Max(max, p.Y)
+	}
+	if c.Type == ChartBar {
+		min = math.Min(min, 0)
+		max = math.Max(max, 0)
+	}
+	if min == max {
+		min, max = min-1, max+1
+	}
+	return
+}
+
+func formatNum(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', 4, 64)
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if n < 4 {
+		n = 4
+	}
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
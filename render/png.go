@@ -0,0 +1,75 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// pngCanvas draws charts onto an in-memory image
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func newPNGCanvas(width, height int) *pngCanvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.ZP, draw.Src)
+	return &pngCanvas{img: img}
+}
+
+func (cv *pngCanvas) line(x1, y1, x2, y2 float64, c color.RGBA) {
+	steps := math.Max(math.Abs(x2-x1), math.Abs(y2-y1))
+	if steps < 1 {
+		steps = 1
+	}
+	for i := 0.0; i <= steps; i++ {
+		t := i / steps
+		cv.img.Set(int(math.Round(x1+(x2-x1)*t)), int(math.Round(y1+(y2-y1)*t)), c)
+	}
+}
+
+func (cv *pngCanvas) rect(x, y, w, h float64, c color.RGBA) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	draw.Draw(cv.img, r, image.NewUniform(c), image.ZP, draw.Src)
+}
+
+func (cv *pngCanvas) circle(cx, cy, r float64, c color.RGBA) {
+	for y := cy - r; y <= cy+r; y++ {
+		for x := cx - r; x <= cx+r; x++ {
+			if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r {
+				cv.img.Set(int(math.Round(x)), int(math.Round(y)), c)
+			}
+		}
+	}
+}
+
+func (cv *pngCanvas) text(x, y float64, s string, a anchor) {
+	d := &font.Drawer{
+		Dst:  cv.img,
+		Src:  image.NewUniform(color.RGBA{0x33, 0x33, 0x33, 0xff}),
+		Face: basicfont.Face7x13,
+	}
+	w := d.MeasureString(s)
+	switch a {
+	case anchorMiddle:
+		x -= float64(w.Round()) / 2
+	case anchorEnd:
+		x -= float64(w.Round())
+	}
+	d.Dot = fixed.P(int(math.Round(x)), int(math.Round(y)))
+	d.DrawString(s)
+}
+
+// PNG writes a chart of a series as a png image
+func PNG(w io.Writer, c *Chart, s Series) error {
+	cv := newPNGCanvas(c.Width, c.Height)
+	drawChart(cv, c, s)
+	return png.Encode(w, cv.img)
+}
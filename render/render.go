@@ -0,0 +1,85 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// Format is a kind of output render can produce
+type Format string
+
+const (
+	// FormatSVG renders charts as svg documents
+	FormatSVG = Format("svg")
+	// FormatPNG renders charts as png images
+	FormatPNG = Format("png")
+	// FormatHTML renders charts as a standalone html page with an inline svg
+	FormatHTML = Format("html")
+)
+
+// ParseFormat reads a format from a string, defaulting to svg
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatSVG:
+		return FormatSVG, nil
+	case FormatPNG, FormatHTML:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("invalid render format '%s'. must be one of svg, png, or html", s)
+}
+
+// ContentType gives the mime type of a format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatPNG:
+		return "image/png"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	}
+	return "image/svg+xml"
+}
+
+// Render writes a chart of a series in the given format
+func Render(w io.Writer, f Format, c *Chart, s Series) error {
+	switch f {
+	case FormatSVG:
+		return SVG(w, c, s)
+	case FormatPNG:
+		return PNG(w, c, s)
+	case FormatHTML:
+		return HTML(w, c, s)
+	}
+	return fmt.Errorf("invalid render format '%s'", f)
+}
+
+var pageTmpl = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>body { margin: 2em; font-family: sans-serif; }</style>
+</head>
+<body>
+{{ .SVG }}
+</body>
+</html>
+`))
+
+// HTML writes a chart of a series as a standalone html page
+func HTML(w io.Writer, c *Chart, s Series) error {
+	buf := &bytes.Buffer{}
+	if err := SVG(buf, c, s); err != nil {
+		return err
+	}
+	title := c.Title
+	if title == "" {
+		title = "chart"
+	}
+	return pageTmpl.Execute(w, map[string]interface{}{
+		"Title": title,
+		// svg output is built from escaped text, so it's safe to include as-is
+		"SVG": template.HTML(buf.String()),
+	})
+}
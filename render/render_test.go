@@ -0,0 +1,148 @@
+package render
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+const citiesCSV = `city,pop,avg_age,in_usa
+toronto,40000000,55.5,false
+new york,8500000,44.4,true
+chicago,300000,44.4,true
+chatham,35000,65.25,true
+raleigh,250000,50.65,true
+`
+
+func citiesStructure(t *testing.T) *dataset.Structure {
+	st := &dataset.Structure{}
+	err := st.UnmarshalJSON([]byte(`{
+		"format": "csv",
+		"formatConfig": { "headerRow": true },
+		"schema": {
+			"type": "array",
+			"items": {
+				"type": "array",
+				"items": [
+					{"title": "city", "type": "string"},
+					{"title": "pop", "type": "integer"},
+					{"title": "avg_age", "type": "number"},
+					{"title": "in_usa", "type": "boolean"}
+				]
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return st
+}
+
+func TestChartFromVisConfig(t *testing.T) {
+	cases := []struct {
+		vis interface{}
+		err string
+	}{
+		{nil, "dataset has no visualization config"},
+		{map[string]interface{}{"type": "pie", "x": "city", "y": "pop"}, "unsupported chart type 'pie'. must be one of bar, line, or scatter"},
+		{map[string]interface{}{"type": "bar", "y": "pop"}, "chart x column is required"},
+		{map[string]interface{}{"type": "bar", "x": "city"}, "chart y column is required unless aggregate is count"},
+		{map[string]interface{}{"type": "bar", "x": "city", "aggregate": "median"}, "unsupported aggregate 'median'. must be one of sum, count, mean, min, or max"},
+		{map[string]interface{}{"type": "bar", "x": "city", "aggregate": "count"}, ""},
+		{[]interface{}{map[string]interface{}{"type": "line", "x": "city", "y": "pop"}}, ""},
+		{map[string]interface{}{"type": "bar", "x": "city", "aggregate": "count", "width": 100000}, ErrChartTooLarge.Error()},
+	}
+
+	for i, c := range cases {
+		got, err := ChartFromVisConfig(&dataset.VisConfig{Visualizations: c.vis})
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if err == nil && (got.Width != DefaultWidth || got.Height != DefaultHeight) {
+			t.Errorf("case %d expected default dimensions, got: %dx%d", i, got.Width, got.Height)
+		}
+	}
+}
+
+func TestReadSeries(t *testing.T) {
+	st := citiesStructure(t)
+	cases := []struct {
+		chart  *Chart
+		labels []string
+		ys     []float64
+	}{
+		{&Chart{Type: ChartBar, X: "city", Y: "pop"}, []string{"toronto", "new york", "chicago", "chatham", "raleigh"}, []float64{40000000, 8500000, 300000, 35000, 250000}},
+		{&Chart{Type: ChartBar, X: "in_usa", Aggregate: AggCount}, []string{"false", "true"}, []float64{1, 4}},
+		{&Chart{Type: ChartBar, X: "in_usa", Y: "avg_age", Aggregate: AggMax}, []string{"false", "true"}, []float64{55.5, 65.25}},
+		{&Chart{Type: ChartScatter, X: "2", Y: "1", Aggregate: AggSum}, []string{"55.5", "44.4", "65.25", "50.65"}, []float64{40000000, 8800000, 35000, 250000}},
+	}
+
+	for i, c := range cases {
+		r, err := dsio.NewEntryReader(st, strings.NewReader(citiesCSV))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		s, err := ReadSeries(c.chart, st, r)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if len(s) != len(c.labels) {
+			t.Errorf("case %d length mismatch. expected: %d, got: %d", i, len(c.labels), len(s))
+			continue
+		}
+		for j, p := range s {
+			if p.Label != c.labels[j] || p.Y != c.ys[j] {
+				t.Errorf("case %d point %d mismatch. expected: %s, %f. got: %s, %f", i, j, c.labels[j], c.ys[j], p.Label, p.Y)
+			}
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	c := &Chart{Type: ChartBar, Title: "cities <by population>", X: "city", Y: "pop"}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err.Error())
+	}
+	s := Series{{Label: "toronto", Y: 40000000}, {Label: "new york", Y: 8500000}}
+
+	svg := &bytes.Buffer{}
+	if err := Render(svg, FormatSVG, c, s); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.HasPrefix(svg.String(), "<svg") || strings.Count(svg.String(), "<rect") != 3 {
+		t.Errorf("expected svg with a background & two bars, got: %s", svg.String())
+	}
+	if !strings.Contains(svg.String(), "cities &lt;by population&gt;") {
+		t.Error("expected svg title to be escaped")
+	}
+
+	page := &bytes.Buffer{}
+	if err := Render(page, FormatHTML, c, s); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.Contains(page.String(), "<title>cities &lt;by population&gt;</title>") || !strings.Contains(page.String(), "<svg") {
+		t.Errorf("expected html page with inline svg, got: %s", page.String())
+	}
+
+	img := &bytes.Buffer{}
+	if err := Render(img, FormatPNG, c, s); err != nil {
+		t.Fatal(err.Error())
+	}
+	decoded, err := png.Decode(img)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if b := decoded.Bounds(); b.Dx() != DefaultWidth || b.Dy() != DefaultHeight {
+		t.Errorf("image size mismatch. got: %dx%d", b.Dx(), b.Dy())
+	}
+
+	if _, err := ParseFormat("gif"); err == nil {
+		t.Error("expected invalid format to error")
+	}
+}
@@ -0,0 +1,173 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// Point is a single value to plot. Label is the x value as text, X holds
+// the x value as a number when it can be read as one
+type Point struct {
+	Label   string
+	X       float64
+	Numeric bool
+	Y       float64
+}
+
+// Series is the list of points a chart plots, in data order
+type Series []Point
+
+// numeric reports whether every point has a numeric x value
+func (s Series) numeric() bool {
+	for _, p := range s {
+		if !p.Numeric {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+// ReadSeries reads the x & y columns a chart plots from an entry reader,
+// applying the chart's aggregation. Rows with a y value that isn't a number
+// are skipped
+func ReadSeries(c *Chart, st *dataset.Structure, r dsio.EntryReader) (Series, error) {
+	cols := columnNames(st)
+	s := Series{}
+	groups := map[string]int{}
+	counts := []int{}
+
+	for {
+		ent, err := r.ReadEntry()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return nil, fmt.Errorf("error reading data: %s", err.Error())
+		}
+
+		x, ok := column(ent.Value, cols, c.X)
+		if !ok {
+			return nil, fmt.Errorf("column '%s' not found", c.X)
+		}
+		y := 1.0
+		if c.Aggregate != AggCount {
+			yval, ok := column(ent.Value, cols, c.Y)
+			if !ok {
+				return nil, fmt.Errorf("column '%s' not found", c.Y)
+			}
+			if y, ok = toFloat(yval); !ok {
+				continue
+			}
+		}
+
+		p := Point{Label: fmt.Sprintf("%v", x), Y: y}
+		p.X, p.Numeric = toFloat(x)
+
+		if c.Aggregate == AggNone {
+			s = append(s, p)
+			continue
+		}
+
+		i, seen := groups[p.Label]
+		if !seen {
+			groups[p.Label] = len(s)
+			s = append(s, p)
+			counts = append(counts, 1)
+			continue
+		}
+		counts[i]++
+		switch c.Aggregate {
+		case AggSum, AggMean, AggCount:
+			s[i].Y += y
+		case AggMin:
+			if y < s[i].Y {
+				s[i].Y = y
+			}
+		case AggMax:
+			if y > s[i].Y {
+				s[i].Y = y
+			}
+		}
+	}
+
+	if c.Aggregate == AggMean {
+		for i := range s {
+			s[i].Y = s[i].Y / float64(counts[i])
+		}
+	}
+	return s, nil
+}
+
+// columnNames reads column titles from a structure's schema. Datasets with
+// rows of arrays (eg. csv data) list columns as schema items
+func columnNames(st *dataset.Structure) []string {
+	if st == nil || st.Schema == nil {
+		return nil
+	}
+	data, err := json.Marshal(st.Schema)
+	if err != nil {
+		return nil
+	}
+	sch := struct {
+		Items struct {
+			Items []struct {
+				Title string `json:"title"`
+			} `json:"items"`
+		} `json:"items"`
+	}{}
+	if err := json.Unmarshal(data, &sch); err != nil {
+		return nil
+	}
+
+	names := make([]string, len(sch.Items.Items))
+	for i, item := range sch.Items.Items {
+		names[i] = item.Title
+	}
+	return names
+}
+
+// column picks a named column from a row. rows that are arrays are
+// indexed by column name, or by a numeric index
+func column(row interface{}, cols []string, name string) (interface{}, bool) {
+	switch r := row.(type) {
+	case map[string]interface{}:
+		v, ok := r[name]
+		return v, ok
+	case []interface{}:
+		for i, col := range cols {
+			if col == name && i < len(r) {
+				return r[i], true
+			}
+		}
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(r) {
+			return r[i], true
+		}
+	}
+	return nil, false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// svgCanvas draws charts as svg elements
+type svgCanvas struct {
+	buf *bytes.Buffer
+}
+
+func newSVGCanvas(width, height int) *svgCanvas {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`, width, height, width, height)
+	fmt.Fprintf(buf, `<rect width="%d" height="%d" fill="#ffffff"/>`, width, height)
+	return &svgCanvas{buf: buf}
+}
+
+func (cv *svgCanvas) line(x1, y1, x2, y2 float64, c color.RGBA) {
+	fmt.Fprintf(cv.buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="1.5"/>`, x1, y1, x2, y2, hex(c))
+}
+
+func (cv *svgCanvas) rect(x, y, w, h float64, c color.RGBA) {
+	fmt.Fprintf(cv.buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`, x, y, w, h, hex(c))
+}
+
+func (cv *svgCanvas) circle(cx, cy, r float64, c color.RGBA) {
+	fmt.Fprintf(cv.buf, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`, cx, cy, r, hex(c))
+}
+
+func (cv *svgCanvas) text(x, y float64, s string, a anchor) {
+	anchors := map[anchor]string{anchorStart: "start", anchorMiddle: "middle", anchorEnd: "end"}
+	fmt.Fprintf(cv.buf, `<text x="%.1f" y="%.1f" text-anchor="%s" fill="#333333">`, x, y, anchors[a])
+	xml.EscapeText(cv.buf, []byte(s))
+	cv.buf.WriteString(`</text>`)
+}
+
+// SVG writes a chart of a series as an svg document
+func SVG(w io.Writer, c *Chart, s Series) error {
+	cv := newSVGCanvas(c.Width, c.Height)
+	drawChart(cv, c, s)
+	cv.buf.WriteString(`</svg>`)
+	_, err := cv.buf.WriteTo(w)
+	return err
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,106 @@
+package actions
+
+import (
+	"sort"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// RecordUsage counts a use of each ref the repo's profile owns. Datasets from
+// other peers aren't counted. Analytics are best-effort: errors are logged,
+// never returned
+func RecordUsage(r repo.Repo, kind repo.UsageKind, refs ...repo.DatasetRef) {
+	pro, err := r.Profile()
+	if err != nil {
+		log.Debug(err.Error())
+		return
+	}
+
+	owned := []repo.DatasetRef{}
+	for _, ref := range refs {
+		if ref.Name == "" && ref.Path != "" {
+			// path-only references are counted if they're the head of a dataset
+			got, err := r.GetRef(repo.DatasetRef{Path: ref.Path})
+			if err != nil {
+				continue
+			}
+			ref = got
+		}
+		if ref.Name != "" && (ref.ProfileID == pro.ID || ref.Peername == pro.Peername) {
+			ref.Peername = pro.Peername
+			owned = append(owned, ref)
+		}
+	}
+	if len(owned) == 0 {
+		return
+	}
+
+	if err := r.Analytics().CountUsage(kind, time.Now(), owned...); err != nil {
+		log.Debugf("error recording %s usage: %s", kind, err.Error())
+	}
+}
+
+// DatasetStats totals how a dataset was used, with a breakdown by day
+type DatasetStats struct {
+	// Ref is the dataset alias, eg: peer/cities
+	Ref string `json:"ref"`
+	// Total is the sum of each day's counts
+	Total repo.UsageCounts `json:"total"`
+	// Days lists days the dataset was used, oldest first
+	Days []*repo.DailyUsage `json:"days"`
+}
+
+// UsageStats totals usage of each dataset in the repo from the day since
+// falls on. Every dataset the repo's profile owns is listed, used or not,
+// most used first
+func UsageStats(r repo.Repo, since time.Time) ([]*DatasetStats, error) {
+	pro, err := r.Profile()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string]*DatasetStats{}
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		if ref.ProfileID == pro.ID || ref.Peername == pro.Peername {
+			alias := repo.DatasetRef{Peername: pro.Peername, Name: ref.Name}.AliasString()
+			stats[alias] = &DatasetStats{Ref: alias, Days: []*repo.DailyUsage{}}
+		}
+	}
+
+	// usage for removed or renamed datasets is listed too
+	usage, err := r.Analytics().Usage(since)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range usage {
+		s, ok := stats[u.Ref]
+		if !ok {
+			s = &DatasetStats{Ref: u.Ref, Days: []*repo.DailyUsage{}}
+			stats[u.Ref] = s
+		}
+		s.Total.Merge(u.UsageCounts)
+		s.Days = append(s.Days, u)
+	}
+
+	res := make([]*DatasetStats, 0, len(stats))
+	for _, s := range stats {
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i].Total.Total(), res[j].Total.Total()
+		if a == b {
+			return res[i].Ref < res[j].Ref
+		}
+		return a > b
+	})
+	return res, nil
+}
@@ -0,0 +1,68 @@
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestRecordUsage(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+
+	r, ref := createDataset(t, rmf)
+	other := repo.DatasetRef{Peername: "other_peer", Name: "cities", Path: "/map/QmOther"}
+
+	RecordUsage(r, repo.UsageServed, ref)
+	RecordUsage(r, repo.UsageServed, repo.DatasetRef{Path: ref.Path})
+	RecordUsage(r, repo.UsageListed, ref, other)
+	RecordUsage(r, repo.UsageRead, repo.DatasetRef{Path: "/map/QmNotAHead"})
+
+	stats, err := UsageStats(r, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(stats) != 1 {
+		t.Fatalf("expected stats for 1 dataset, got: %d", len(stats))
+	}
+	s := stats[0]
+	if s.Ref != ref.AliasString() {
+		t.Errorf("expected stats for %s, got: %s", ref.AliasString(), s.Ref)
+	}
+	expect := repo.UsageCounts{Served: 2, Listed: 1}
+	if s.Total != expect {
+		t.Errorf("total mismatch. expected: %v, got: %v", expect, s.Total)
+	}
+	if len(s.Days) != 1 {
+		t.Errorf("expected 1 day of usage, got: %d", len(s.Days))
+	}
+}
+
+func TestUsageStatsUnused(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+
+	r, ref := createDataset(t, rmf)
+	stats, err := UsageStats(r, time.Now())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(stats) != 1 || stats[0].Ref != ref.AliasString() || stats[0].Total.Total() != 0 {
+		t.Errorf("expected unused dataset to be listed with no usage, got: %v", stats)
+	}
+}
@@ -0,0 +1,549 @@
+package actions
+
+import (
+	"archive/zip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	"golang.org/x/crypto/scrypt"
+)
+
+// BackupVersion is the version of the backup archive format
+const BackupVersion = 1
+
+const (
+	backupManifestFile = "backup.json"
+	backupConfigFile   = "config.yaml"
+	backupKeysFile     = "keys.enc"
+	backupRefsFile     = "refs.json"
+	backupProfilesFile = "profiles.json"
+	backupEventsFile   = "events.json"
+	backupLineageFile  = "lineage.json"
+	backupBlocksDir    = "blocks"
+)
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	Version   int       `json:"version"`
+	Created   time.Time `json:"created"`
+	Peername  string    `json:"peername"`
+	ProfileID string    `json:"profileID"`
+	// HasConfig is true if the archive includes a config file
+	HasConfig bool `json:"hasConfig"`
+	// HasKeys is true if the archive includes encrypted private keys
+	HasKeys bool `json:"hasKeys"`
+	// Blocks lists every block in the archive
+	Blocks []*BackupBlock `json:"blocks"`
+}
+
+// BackupBlock is a block from the store. Directory blocks list each file
+// they contain, file blocks have a single file with an empty name
+type BackupBlock struct {
+	Path  string        `json:"path"`
+	Dir   bool          `json:"dir,omitempty"`
+	Files []*BackupFile `json:"files"`
+}
+
+// BackupFile is a file within a block, with a checksum of it's content
+type BackupFile struct {
+	Name   string `json:"name,omitempty"`
+	SHA256 string `json:"sha256"`
+}
+
+// BackupParams configures what's written to a backup beyond the repo itself
+type BackupParams struct {
+	// Config is the repo's configuration file. Private keys should be removed
+	// from Config and provided as Keys instead
+	Config []byte
+	// Keys is written to the archive encrypted with Passphrase. Keys are
+	// dropped if Passphrase is empty
+	Keys       []byte
+	Passphrase string
+}
+
+// lineageRecord is a dataset's lineage, as stored in a backup
+type lineageRecord struct {
+	Ref       string   `json:"ref"`
+	Upstreams []string `json:"upstreams"`
+	Stale     bool     `json:"stale,omitempty"`
+}
+
+// Backup writes a zip archive of a repo to w: references, profiles, the
+// event log, lineage, and every block reachable from a reference
+func Backup(r repo.Repo, w io.Writer, p BackupParams) (*BackupManifest, error) {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+
+	pro, err := r.Profile()
+	if err != nil {
+		return nil, err
+	}
+	mf := &BackupManifest{
+		Version:   BackupVersion,
+		Created:   time.Now(),
+		Peername:  pro.Peername,
+		ProfileID: pro.ID.String(),
+		Blocks:    []*BackupBlock{},
+	}
+
+	zw := zip.NewWriter(w)
+
+	if p.Config != nil {
+		mf.HasConfig = true
+		if err := writeZipFile(zw, backupConfigFile, p.Config); err != nil {
+			return nil, err
+		}
+	}
+	if p.Keys != nil && p.Passphrase != "" {
+		sealed, err := encrypt(p.Passphrase, p.Keys)
+		if err != nil {
+			return nil, err
+		}
+		mf.HasKeys = true
+		if err := writeZipFile(zw, backupKeysFile, sealed); err != nil {
+			return nil, err
+		}
+	}
+
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return nil, err
+	}
+	refstrs := make([]string, len(refs))
+	lineage := []*lineageRecord{}
+	for i, ref := range refs {
+		refstrs[i] = ref.String()
+
+		ups, err := r.Upstreams(ref)
+		if err != nil && err != repo.ErrNotFound {
+			return nil, err
+		}
+		if len(ups) > 0 {
+			stale, _ := r.IsStale(ref)
+			rec := &lineageRecord{Ref: ref.AliasString(), Stale: stale}
+			for _, up := range ups {
+				rec.Upstreams = append(rec.Upstreams, up.AliasString())
+			}
+			lineage = append(lineage, rec)
+		}
+	}
+	if err := writeZipJSON(zw, backupRefsFile, refstrs); err != nil {
+		return nil, err
+	}
+	if err := writeZipJSON(zw, backupLineageFile, lineage); err != nil {
+		return nil, err
+	}
+
+	profiles, err := r.Profiles().List()
+	if err != nil {
+		return nil, err
+	}
+	pros := make([]*profile.Profile, 0, len(profiles))
+	for _, p := range profiles {
+		pros = append(pros, p)
+	}
+	if err := writeZipJSON(zw, backupProfilesFile, pros); err != nil {
+		return nil, err
+	}
+
+	events, err := r.EventsSince(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeZipJSON(zw, backupEventsFile, events); err != nil {
+		return nil, err
+	}
+
+	reachable, err := ReachablePaths(r)
+	if err != nil {
+		return nil, err
+	}
+	roots := map[string]bool{}
+	for path := range reachable {
+		roots[rootPath(path)] = true
+	}
+	paths := make([]string, 0, len(roots))
+	for path := range roots {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		block, err := backupBlock(zw, r.Store(), path)
+		if err != nil {
+			return nil, err
+		}
+		mf.Blocks = append(mf.Blocks, block)
+	}
+
+	if err := writeZipJSON(zw, backupManifestFile, mf); err != nil {
+		return nil, err
+	}
+	return mf, zw.Close()
+}
+
+// backupBlock copies a block from the store into the archive
+func backupBlock(zw *zip.Writer, store cafs.Filestore, path string) (*BackupBlock, error) {
+	f, err := store.Get(datastore.NewKey(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading block %s: %s", path, err.Error())
+	}
+	defer f.Close()
+
+	block := &BackupBlock{Path: path, Dir: f.IsDirectory(), Files: []*BackupFile{}}
+	if !block.Dir {
+		sum, err := writeZipBlock(zw, blockEntry(path, ""), f)
+		if err != nil {
+			return nil, err
+		}
+		block.Files = append(block.Files, &BackupFile{SHA256: sum})
+		return block, nil
+	}
+
+	for {
+		child, err := f.NextFile()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading block %s: %s", path, err.Error())
+		}
+		if child.IsDirectory() {
+			return nil, fmt.Errorf("error reading block %s: nested directories aren't supported", path)
+		}
+		sum, err := writeZipBlock(zw, blockEntry(path, child.FileName()), child)
+		child.Close()
+		if err != nil {
+			return nil, err
+		}
+		block.Files = append(block.Files, &BackupFile{Name: child.FileName(), SHA256: sum})
+	}
+	return block, nil
+}
+
+// BackupArchive is an opened backup
+type BackupArchive struct {
+	Manifest *BackupManifest
+	// Config is the backed up configuration file, if any
+	Config []byte
+	zr     *zip.Reader
+}
+
+// OpenBackup reads the manifest & config of a backup archive
+func OpenBackup(ra io.ReaderAt, size int64) (*BackupArchive, error) {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("error opening backup: %s", err.Error())
+	}
+	b := &BackupArchive{zr: zr, Manifest: &BackupManifest{}}
+	if err := b.readJSON(backupManifestFile, b.Manifest); err != nil {
+		return nil, err
+	}
+	if b.Manifest.Version > BackupVersion {
+		return nil, fmt.Errorf("backup is version %d, this version of qri only reads up to version %d", b.Manifest.Version, BackupVersion)
+	}
+	if b.Manifest.HasConfig {
+		if b.Config, err = b.read(backupConfigFile); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Keys decrypts the private keys stored in the backup, returning nil if the
+// backup doesn't have any
+func (b *BackupArchive) Keys(passphrase string) ([]byte, error) {
+	if !b.Manifest.HasKeys {
+		return nil, nil
+	}
+	sealed, err := b.read(backupKeysFile)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(passphrase, sealed)
+}
+
+// RestoreResult counts what was restored from a backup
+type RestoreResult struct {
+	Blocks   int
+	Refs     int
+	Profiles int
+	Events   int
+}
+
+// EventImporter is an opt-in interface for event logs that can record
+// events with their original timestamps
+type EventImporter interface {
+	ImportEvents(events []*repo.Event) error
+}
+
+// Restore adds the contents of a backup to a repo. Every block is checked
+// against it's checksum, and must be stored at the path it was backed up from
+func (b *BackupArchive) Restore(r repo.Repo) (*RestoreResult, error) {
+	if !repo.Writable(r) {
+		return nil, repo.ErrReadOnly
+	}
+
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+
+	res := &RestoreResult{}
+	store := r.Store()
+	for _, block := range b.Manifest.Blocks {
+		if err := b.restoreBlock(store, block); err != nil {
+			return res, err
+		}
+		res.Blocks++
+	}
+
+	pros := []*profile.Profile{}
+	if err := b.readJSON(backupProfilesFile, &pros); err != nil {
+		return res, err
+	}
+	for _, p := range pros {
+		if err := r.Profiles().PutProfile(p); err != nil {
+			return res, err
+		}
+		res.Profiles++
+	}
+
+	refstrs := []string{}
+	if err := b.readJSON(backupRefsFile, &refstrs); err != nil {
+		return res, err
+	}
+	for _, str := range refstrs {
+		ref, err := repo.ParseDatasetRef(str)
+		if err != nil {
+			return res, err
+		}
+		if err := r.PutRef(ref); err == repo.ErrNameTaken {
+			// keep datasets that already exist in the repo
+			continue
+		} else if err != nil {
+			return res, fmt.Errorf("error restoring reference %s: %s", str, err.Error())
+		}
+		res.Refs++
+	}
+
+	lineage := []*lineageRecord{}
+	if err := b.readJSON(backupLineageFile, &lineage); err != nil {
+		return res, err
+	}
+	for _, rec := range lineage {
+		ref, err := repo.ParseDatasetRef(rec.Ref)
+		if err != nil {
+			return res, err
+		}
+		ups := make([]repo.DatasetRef, len(rec.Upstreams))
+		for i, up := range rec.Upstreams {
+			if ups[i], err = repo.ParseDatasetRef(up); err != nil {
+				return res, err
+			}
+		}
+		if err := r.PutUpstreams(ref, ups); err != nil {
+			return res, err
+		}
+		if err := r.SetStale(ref, rec.Stale); err != nil {
+			return res, err
+		}
+	}
+
+	events := []*repo.Event{}
+	if err := b.readJSON(backupEventsFile, &events); err != nil {
+		return res, err
+	}
+	if imp, ok := repo.Base(r).(EventImporter); ok {
+		if err := imp.ImportEvents(events); err != nil {
+			return res, err
+		}
+	} else {
+		// event logs that can't import lose original timestamps
+		for _, e := range events {
+			if err := r.LogEvent(e.Type, e.Ref); err != nil {
+				return res, err
+			}
+		}
+	}
+	res.Events = len(events)
+
+	return res, nil
+}
+
+// restoreBlock checks a block's files against their checksums & adds the
+// block to the store
+func (b *BackupArchive) restoreBlock(store cafs.Filestore, block *BackupBlock) error {
+	var file cafs.File
+	if block.Dir {
+		dir := cafs.NewMemdir("/package")
+		for _, f := range block.Files {
+			data, err := b.readBlockFile(block, f)
+			if err != nil {
+				return err
+			}
+			dir.AddChildren(cafs.NewMemfileBytes(f.Name, data))
+		}
+		file = dir
+	} else {
+		if len(block.Files) != 1 {
+			return fmt.Errorf("backup is corrupt: block %s has no data", block.Path)
+		}
+		data, err := b.readBlockFile(block, block.Files[0])
+		if err != nil {
+			return err
+		}
+		file = cafs.NewMemfileBytes("data", data)
+	}
+
+	key, err := store.Put(file, true)
+	if err != nil {
+		return fmt.Errorf("error restoring block %s: %s", block.Path, err.Error())
+	}
+	if key.String() != block.Path {
+		return fmt.Errorf("integrity check failed: block %s was stored as %s", block.Path, key.String())
+	}
+	return nil
+}
+
+func (b *BackupArchive) readBlockFile(block *BackupBlock, f *BackupFile) ([]byte, error) {
+	data, err := b.read(blockEntry(block.Path, f.Name))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != f.SHA256 {
+		return nil, fmt.Errorf("integrity check failed: checksum mismatch for block %s %s", block.Path, f.Name)
+	}
+	return data, nil
+}
+
+func (b *BackupArchive) read(name string) ([]byte, error) {
+	for _, f := range b.zr.File {
+		if f.Name != name {
+			continue
+		}
+		rdr, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rdr.Close()
+		return ioutil.ReadAll(rdr)
+	}
+	return nil, fmt.Errorf("backup is missing %s", name)
+}
+
+func (b *BackupArchive) readJSON(name string, v interface{}) error {
+	data, err := b.read(name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error reading %s from backup: %s", name, err.Error())
+	}
+	return nil
+}
+
+// blockEntry gives the archive filename of a file within a block
+func blockEntry(path, name string) string {
+	entry := backupBlocksDir + "/" + strings.Trim(path, "/")
+	if name != "" {
+		entry += "/" + name
+	}
+	return entry
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeZipFile(zw, name, data)
+}
+
+// writeZipBlock copies r into the archive, returning the hex sha256 of it's
+// content
+func writeZipBlock(zw *zip.Writer, name string, r io.Reader) (string, error) {
+	w, err := zw.Create(name)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), r); err != nil {
+		return "", fmt.Errorf("error writing %s to backup: %s", name, err.Error())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// encrypt seals data with a key derived from passphrase. output is the scrypt
+// salt, followed by the AES-GCM nonce & ciphertext
+func encrypt(passphrase string, data []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return append(append(salt, nonce...), gcm.Seal(nil, nonce, data, nil)...), nil
+}
+
+func decrypt(passphrase string, sealed []byte) ([]byte, error) {
+	if len(sealed) < 16 {
+		return nil, fmt.Errorf("backup keys are corrupt")
+	}
+	gcm, err := passphraseCipher(passphrase, sealed[:16])
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[16:]
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("backup keys are corrupt")
+	}
+	data, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting backup keys, is the passphrase correct?")
+	}
+	return data, nil
+}
+
+func passphraseCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
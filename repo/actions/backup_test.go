@@ -0,0 +1,71 @@
+package actions
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestBackupRestore(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+
+	r, ref := createDataset(t, rmf)
+
+	buf := &bytes.Buffer{}
+	mf, err := Backup(r, buf, BackupParams{
+		Config:     []byte("profile: {}"),
+		Keys:       []byte("secret keys"),
+		Passphrase: "correct horse",
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !mf.HasConfig || !mf.HasKeys || len(mf.Blocks) == 0 {
+		t.Errorf("expected manifest to list config, keys & blocks, got: %#v", mf)
+	}
+
+	b, err := OpenBackup(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(b.Config) != "profile: {}" {
+		t.Errorf("config mismatch, got: %s", string(b.Config))
+	}
+	if _, err := b.Keys("wrong"); err == nil {
+		t.Error("expected decrypting keys with the wrong passphrase to error")
+	}
+	if keys, err := b.Keys("correct horse"); err != nil || string(keys) != "secret keys" {
+		t.Errorf("keys mismatch. got: %s, err: %v", string(keys), err)
+	}
+
+	dst := rmf(t)
+	res, err := b.Restore(dst)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if res.Refs != 1 || res.Blocks != len(mf.Blocks) {
+		t.Errorf("expected 1 ref & %d blocks restored, got: %#v", len(mf.Blocks), res)
+	}
+
+	got, err := dst.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	act := Dataset{dst}
+	if err := act.ReadDataset(&got); err != nil {
+		t.Errorf("expected restored dataset to load: %s", err.Error())
+	}
+	if reachable, err := ReachablePaths(dst); err != nil || len(reachable) == 0 {
+		t.Errorf("expected restored repo to have reachable blocks. err: %v", err)
+	}
+}
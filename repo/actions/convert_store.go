@@ -0,0 +1,276 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// ConvertResult summarizes a store conversion
+type ConvertResult struct {
+	// Datasets is the number of references repointed at the new store
+	Datasets int `json:"datasets"`
+	// Versions is the number of dataset versions copied
+	Versions int `json:"versions"`
+	// Paths maps dataset paths in the old store to their copies
+	Paths map[string]string `json:"paths"`
+}
+
+// ConvertStore copies every dataset version & profile photo in a repo to dst,
+// repointing references at the copies. Stores hash content differently, so
+// each version is rewritten with it's previous path set to the copy of the
+// version before it. The repo's current store isn't modified. The event log
+// keeps the paths events were recorded with, and records each reference's
+// new path with a converted event.
+//
+// Everything is copied before any reference changes. Once every reference
+// & profile points into dst, switchStore is called to start using it. If
+// repointing or switchStore fails, references & profiles are rolled back
+func ConvertStore(r repo.Repo, dst cafs.Filestore, switchStore func() error) (*ConvertResult, error) {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+
+	src := r.Store()
+	res := &ConvertResult{Paths: map[string]string{}}
+
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		// convert history oldest-first so each version can point to the copy of
+		// it's previous version
+		history := []string{}
+		for path := ref.Path; path != "" && path != "/"; {
+			if _, ok := res.Paths[path]; ok {
+				break
+			}
+			history = append([]string{path}, history...)
+			ds, err := dsfs.LoadDatasetRefs(src, datastore.NewKey(path))
+			if err != nil {
+				log.Debug(err.Error())
+				return res, fmt.Errorf("error loading dataset %s: %s", path, err.Error())
+			}
+			path = ds.PreviousPath
+		}
+
+		for _, path := range history {
+			converted, err := convertVersion(src, dst, path, res.Paths)
+			if err != nil {
+				return res, err
+			}
+			res.Paths[path] = converted
+			res.Versions++
+		}
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		return res, err
+	}
+	profiles, err := r.Profiles().List()
+	if err != nil {
+		return res, err
+	}
+	self := *pro
+	if err := convertProfilePhotos(src, dst, &self); err != nil {
+		return res, err
+	}
+	others := []*profile.Profile{}
+	prevOthers := []*profile.Profile{}
+	for _, p := range profiles {
+		if p.ID == pro.ID {
+			continue
+		}
+		converted := *p
+		if err := convertProfilePhotos(src, dst, &converted); err != nil {
+			return res, err
+		}
+		others = append(others, &converted)
+		prevOthers = append(prevOthers, p)
+	}
+
+	// everything is in dst, repoint the repo at it
+	sw := &storeSwitch{r: r}
+	err = sw.apply(refs, res, &self, others)
+	if err == nil && switchStore != nil {
+		err = switchStore()
+	}
+	if err != nil {
+		sw.rollback(pro, prevOthers)
+		return res, err
+	}
+
+	return res, nil
+}
+
+// storeSwitch tracks the changes ConvertStore makes to a repo, so they can
+// be rolled back
+type storeSwitch struct {
+	r repo.Repo
+	// moved pairs each original reference with it's converted copy
+	moved    [][2]repo.DatasetRef
+	profiles int
+	self     bool
+}
+
+func (sw *storeSwitch) apply(refs []repo.DatasetRef, res *ConvertResult, self *profile.Profile, others []*profile.Profile) error {
+	for _, ref := range refs {
+		if ref.Path == "" {
+			continue
+		}
+		to := ref
+		to.Path = res.Paths[ref.Path]
+		if err := sw.r.DeleteRef(ref); err != nil {
+			return err
+		}
+		if err := sw.r.PutRef(to); err != nil {
+			// put the original back before rolling back the rest
+			if err := sw.r.PutRef(ref); err != nil {
+				log.Debug(err.Error())
+			}
+			return fmt.Errorf("error updating reference %s: %s", ref.AliasString(), err.Error())
+		}
+		sw.moved = append(sw.moved, [2]repo.DatasetRef{ref, to})
+		if err := sw.r.LogEvent(repo.ETDsConverted, to); err != nil {
+			return err
+		}
+		res.Datasets++
+	}
+
+	if err := sw.r.SetProfile(self); err != nil {
+		return err
+	}
+	sw.self = true
+	for _, p := range others {
+		if err := sw.r.Profiles().PutProfile(p); err != nil {
+			return err
+		}
+		sw.profiles++
+	}
+	return nil
+}
+
+// rollback restores every reference & profile apply changed. The event log
+// only appends, so converted references are recorded as deleted. errors are
+// logged, rollback carries on restoring what it can
+func (sw *storeSwitch) rollback(self *profile.Profile, others []*profile.Profile) {
+	for _, p := range others[:sw.profiles] {
+		if err := sw.r.Profiles().PutProfile(p); err != nil {
+			log.Debug(err.Error())
+		}
+	}
+	if sw.self {
+		if err := sw.r.SetProfile(self); err != nil {
+			log.Debug(err.Error())
+		}
+	}
+	for i := len(sw.moved) - 1; i >= 0; i-- {
+		from, to := sw.moved[i][0], sw.moved[i][1]
+		if err := sw.r.DeleteRef(to); err != nil {
+			log.Debug(err.Error())
+		}
+		if err := sw.r.LogEvent(repo.ETDsDeleted, to); err != nil {
+			log.Debug(err.Error())
+		}
+		if err := sw.r.PutRef(from); err != nil {
+			log.Debug(err.Error())
+		}
+	}
+}
+
+// convertVersion writes a single dataset version from src to dst, returning
+// the path of the copy. converted must hold the copy of the version's
+// previous path
+func convertVersion(src, dst cafs.Filestore, path string, converted map[string]string) (string, error) {
+	ds, err := dsfs.LoadDataset(src, datastore.NewKey(path))
+	if err != nil {
+		log.Debug(err.Error())
+		return "", fmt.Errorf("error loading dataset %s: %s", path, err.Error())
+	}
+	data, err := dsfs.LoadData(src, ds)
+	if err != nil {
+		log.Debug(err.Error())
+		return "", fmt.Errorf("error loading data for dataset %s: %s", path, err.Error())
+	}
+
+	if ds.PreviousPath != "" && ds.PreviousPath != "/" {
+		ds.PreviousPath = converted[ds.PreviousPath]
+	}
+	if ds.Meta != nil && ds.Meta.ReadmePath != "" {
+		if ds.Meta.ReadmePath, err = copyFile(src, dst, ds.Meta.ReadmePath); err != nil {
+			return "", err
+		}
+	}
+	clearComponentPaths(ds)
+
+	key, err := dsfs.WriteDataset(dst, ds, data, true)
+	if err != nil {
+		log.Debug(err.Error())
+		return "", fmt.Errorf("error writing dataset %s: %s", path, err.Error())
+	}
+	return key.String(), nil
+}
+
+// clearComponentPaths drops the paths of a dataset's components, which point
+// into the store the dataset was loaded from, so components are written
+// in full
+func clearComponentPaths(ds *dataset.Dataset) {
+	if ds.Commit != nil {
+		ds.Commit.SetPath("")
+	}
+	if ds.Meta != nil {
+		ds.Meta.SetPath("")
+	}
+	if ds.Structure != nil {
+		ds.Structure.SetPath("")
+	}
+	if ds.Transform != nil {
+		ds.Transform.SetPath("")
+	}
+	if ds.AbstractTransform != nil {
+		ds.AbstractTransform.SetPath("")
+	}
+	if ds.VisConfig != nil {
+		ds.VisConfig.SetPath("")
+	}
+}
+
+func convertProfilePhotos(src, dst cafs.Filestore, p *profile.Profile) error {
+	for _, key := range []*datastore.Key{&p.Thumb, &p.Profile, &p.Poster} {
+		if key.String() == "" || key.String() == "/" {
+			continue
+		}
+		path, err := copyFile(src, dst, key.String())
+		if err != nil {
+			return err
+		}
+		*key = datastore.NewKey(path)
+	}
+	return nil
+}
+
+// copyFile puts the file at path in src into dst, returning it's new path
+func copyFile(src, dst cafs.Filestore, path string) (string, error) {
+	f, err := src.Get(datastore.NewKey(path))
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %s", path, err.Error())
+	}
+	defer f.Close()
+
+	key, err := dst.Put(f, true)
+	if err != nil {
+		return "", fmt.Errorf("error copying %s: %s", path, err.Error())
+	}
+	return key.String(), nil
+}
@@ -0,0 +1,134 @@
+package actions
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	"github.com/qri-io/qri/store/localfs"
+)
+
+func TestConvertStore(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+
+	r, prev, head := createDatasetHistory(t, rmf)
+
+	path, err := ioutil.TempDir("", "qri_convert_store")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	dst, err := localfs.NewFilestore(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	failed := fmt.Errorf("can't switch stores")
+	if _, err := ConvertStore(r, dst, func() error { return failed }); err != failed {
+		t.Fatalf("expected switch error, got: %v", err)
+	}
+	got, err := r.GetRef(repo.DatasetRef{Peername: head.Peername, Name: head.Name})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Path != head.Path {
+		t.Errorf("expected reference to be rolled back to %s, got: %s", head.Path, got.Path)
+	}
+
+	switched := false
+	res, err := ConvertStore(r, dst, func() error {
+		switched = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !switched {
+		t.Error("expected switchStore to be called")
+	}
+	if res.Datasets != 1 || res.Versions != 2 {
+		t.Errorf("expected 1 dataset & 2 versions converted, got: %#v", res)
+	}
+
+	got, err = r.GetRef(repo.DatasetRef{Peername: head.Peername, Name: head.Name})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Path != res.Paths[head.Path] || !strings.HasPrefix(got.Path, "/local/") {
+		t.Errorf("expected reference to point to converted head, got: %s", got.Path)
+	}
+
+	ds, err := dsfs.LoadDataset(dst, datastore.NewKey(got.Path))
+	if err != nil {
+		t.Fatalf("converted dataset should load from the new store: %s", err.Error())
+	}
+	if ds.Meta == nil || ds.Meta.Title != "updated cities" {
+		t.Errorf("converted dataset lost it's metadata: %#v", ds.Meta)
+	}
+	if ds.PreviousPath != res.Paths[prev.Path] {
+		t.Errorf("expected previous path %s, got: %s", res.Paths[prev.Path], ds.PreviousPath)
+	}
+	if _, err := dsfs.LoadDataset(dst, datastore.NewKey(ds.PreviousPath)); err != nil {
+		t.Errorf("converted history should load from the new store: %s", err.Error())
+	}
+	if _, err := dsfs.LoadData(dst, ds); err != nil {
+		t.Errorf("converted data should load from the new store: %s", err.Error())
+	}
+}
+
+// swapStore is a filestore that can be switched out from under a repo
+type swapStore struct {
+	cafs.Filestore
+}
+
+func TestConvertStoreFsck(t *testing.T) {
+	store := &swapStore{cafs.NewMapstore()}
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, store, profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+	r, _, _ := createDatasetHistory(t, rmf)
+
+	path, err := ioutil.TempDir("", "qri_convert_store_fsck")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	dst, err := localfs.NewFilestore(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err := ConvertStore(r, dst, func() error {
+		store.Filestore = dst
+		return nil
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	res, err := Fsck(r, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Problems) != 0 {
+		t.Errorf("expected converted repo to have no problems, got: %v", res.Problems)
+	}
+}
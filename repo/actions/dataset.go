@@ -1,6 +1,9 @@
 package actions
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/dataset"
@@ -15,8 +18,17 @@ type Dataset struct {
 	repo.Repo
 }
 
-// CreateDataset initializes a dataset from a dataset pointer and data file
+// CreateDataset initializes a dataset from a dataset pointer and data file.
+// Datasets derived from the dataset are re-run or marked stale
 func (act Dataset) CreateDataset(name string, ds *dataset.Dataset, data cafs.File, pin bool) (ref repo.DatasetRef, err error) {
+	if ref, err = act.createDataset(name, ds, data, pin); err != nil {
+		return
+	}
+	act.updateDerived(ref, map[string]bool{ref.AliasString(): true})
+	return
+}
+
+func (act Dataset) createDataset(name string, ds *dataset.Dataset, data cafs.File, pin bool) (ref repo.DatasetRef, err error) {
 	var (
 		path datastore.Key
 		pro  *profile.Profile
@@ -26,6 +38,9 @@ func (act Dataset) CreateDataset(name string, ds *dataset.Dataset, data cafs.Fil
 		return
 	}
 
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+
 	path, err = dsfs.CreateDataset(act.Store(), ds, data, act.PrivateKey(), pin)
 	if err != nil {
 		return
@@ -60,6 +75,11 @@ func (act Dataset) CreateDataset(name string, ds *dataset.Dataset, data cafs.Fil
 		return
 	}
 
+	if err = act.recordUpstreams(ref, ds); err != nil {
+		log.Error(err.Error())
+		err = nil
+	}
+
 	_, storeIsPinner := act.Store().(cafs.Pinner)
 	if pin && storeIsPinner {
 		act.LogEvent(repo.ETDsPinned, ref)
@@ -85,14 +105,21 @@ func (act Dataset) RenameDataset(a, b repo.DatasetRef) (err error) {
 	if err = act.PutRef(b); err != nil {
 		return err
 	}
+	if err = act.renameLineage(a, b); err != nil {
+		log.Error(err.Error())
+	}
 
 	return act.LogEvent(repo.ETDsRenamed, b)
 }
 
 // PinDataset marks a dataset for retention in a store
 func (act Dataset) PinDataset(ref repo.DatasetRef) error {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+
 	if pinner, ok := act.Store().(cafs.Pinner); ok {
 		pinner.Pin(datastore.NewKey(ref.Path), true)
+		PinReadme(act.Store(), ref.Path, true)
 		return act.LogEvent(repo.ETDsPinned, ref)
 	}
 	return repo.ErrNotPinner
@@ -101,6 +128,9 @@ func (act Dataset) PinDataset(ref repo.DatasetRef) error {
 // UnpinDataset unmarks a dataset for retention in a store
 func (act Dataset) UnpinDataset(ref repo.DatasetRef) error {
 	if pinner, ok := act.Store().(cafs.Pinner); ok {
+		if err := UnpinReadme(act.Repo, ref); err != nil {
+			log.Debug(err.Error())
+		}
 		pinner.Unpin(datastore.NewKey(ref.Path), true)
 		return act.LogEvent(repo.ETDsUnpinned, ref)
 	}
@@ -115,6 +145,114 @@ func (act Dataset) DeleteDataset(ref repo.DatasetRef) error {
 	if err := act.UnpinDataset(ref); err != nil && err != repo.ErrNotPinner {
 		return err
 	}
+	if err := act.DeleteLineage(ref); err != nil {
+		return err
+	}
 
 	return act.LogEvent(repo.ETDsDeleted, ref)
 }
+
+// RevertDataset creates a new version of a dataset whose components equal
+// an earlier version in that dataset's history. ref must specify the name of
+// the dataset and the path of the version to revert to
+func (act Dataset) RevertDataset(ref repo.DatasetRef, pin bool) (res repo.DatasetRef, err error) {
+	head, err := act.headRef(ref)
+	if err != nil {
+		return
+	}
+
+	target, err := dsfs.LoadDataset(act.Store(), datastore.NewKey(ref.Path))
+	if err != nil {
+		log.Debug(err.Error())
+		return res, fmt.Errorf("error loading dataset version %s: %s", ref.Path, err.Error())
+	}
+
+	data, err := dsfs.LoadData(act.Store(), target)
+	if err != nil {
+		log.Debug(err.Error())
+		return res, fmt.Errorf("error loading data for version %s: %s", ref.Path, err.Error())
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(target)
+	ds.PreviousPath = head.Path
+	ds.Commit = &dataset.Commit{
+		Title:   fmt.Sprintf("revert to %s", ref.Path),
+		Message: fmt.Sprintf("this version restores %s to the state of version %s", head.AliasString(), ref.Path),
+	}
+	if target.Commit != nil && !target.Commit.Timestamp.IsZero() {
+		ds.Commit.Message = fmt.Sprintf("%s, saved %s", ds.Commit.Message, target.Commit.Timestamp.Format(time.RFC3339))
+	}
+	// clear component paths so dsfs compares the content of each component
+	// against head, instead of assuming components with paths are unchanged
+	clearComponentPaths(ds)
+
+	return act.CreateDataset(head.Name, ds, data, pin)
+}
+
+// ResetDataset moves the head of a dataset back to an earlier version in
+// it's history. Versions after ref are no longer referenced by the refstore
+func (act Dataset) ResetDataset(ref repo.DatasetRef) (res repo.DatasetRef, err error) {
+	head, err := act.headRef(ref)
+	if err != nil {
+		return
+	}
+
+	res = repo.DatasetRef{
+		ProfileID: head.ProfileID,
+		Peername:  head.Peername,
+		Name:      head.Name,
+		Path:      ref.Path,
+	}
+
+	if err = act.DeleteRef(head); err != nil {
+		return
+	}
+	if err = act.PutRef(res); err != nil {
+		// put the old head back so the dataset isn't left without a reference
+		if e := act.PutRef(head); e != nil {
+			log.Debug(e.Error())
+		}
+		return
+	}
+
+	if err = act.LogEvent(repo.ETDsReset, res); err != nil {
+		return
+	}
+	act.updateDerived(res, map[string]bool{res.AliasString(): true})
+	return
+}
+
+// headRef fetches the current head of the dataset ref refers to, confirming
+// the path ref specifies is an earlier version in that dataset's history
+func (act Dataset) headRef(ref repo.DatasetRef) (head repo.DatasetRef, err error) {
+	if ref.Path == "" {
+		return head, repo.ErrPathRequired
+	}
+	if ref.Name == "" {
+		return head, repo.ErrNameRequired
+	}
+
+	head, err = act.GetRef(repo.DatasetRef{Peername: ref.Peername, ProfileID: ref.ProfileID, Name: ref.Name})
+	if err != nil {
+		return
+	}
+	if head.Path == ref.Path {
+		return head, fmt.Errorf("%s is already at version %s", head.AliasString(), ref.Path)
+	}
+
+	path := head.Path
+	for path != "" && path != "/" {
+		ds, e := dsfs.LoadDataset(act.Store(), datastore.NewKey(path))
+		if e != nil {
+			log.Debug(e.Error())
+			return head, fmt.Errorf("error loading dataset history: %s", e.Error())
+		}
+		if ds.PreviousPath == ref.Path {
+			return head, nil
+		}
+		path = ds.PreviousPath
+	}
+
+	return head, fmt.Errorf("version %s is not in the history of %s", ref.Path, head.AliasString())
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/libp2p/go-libp2p-crypto"
 	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/dataset/dstest"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
@@ -67,6 +70,9 @@ func DatasetTests(t *testing.T, rmf RepoMakerFunc) {
 		testDatasetPinning,
 		testDeleteDataset,
 		testEventsLog,
+		testRevertDataset,
+		testRevertTransform,
+		testResetDataset,
 	} {
 		test(t, rmf)
 	}
@@ -248,3 +254,140 @@ func testEventsLog(t *testing.T, rmf RepoMakerFunc) {
 		}
 	}
 }
+
+func createDatasetHistory(t *testing.T, rmf RepoMakerFunc) (repo.Repo, repo.DatasetRef, repo.DatasetRef) {
+	r, ref := createDataset(t, rmf)
+	act := Dataset{r}
+
+	if err := act.ReadDataset(&ref); err != nil {
+		t.Error(err.Error())
+		return r, ref, ref
+	}
+
+	data, err := dsfs.LoadData(r.Store(), ref.Dataset)
+	if err != nil {
+		t.Error(err.Error())
+		return r, ref, ref
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(ref.Dataset)
+	ds.PreviousPath = ref.Path
+	ds.Meta = &dataset.Meta{Title: "updated cities"}
+	ds.Commit = &dataset.Commit{Title: "update meta"}
+
+	head, err := act.CreateDataset(ref.Name, ds, data, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	return r, ref, head
+}
+
+func testRevertDataset(t *testing.T, rmf RepoMakerFunc) {
+	r, first, head := createDatasetHistory(t, rmf)
+	act := Dataset{r}
+
+	if _, err := act.RevertDataset(head, true); err == nil {
+		t.Error("expected reverting to the current version to error")
+		return
+	}
+
+	res, err := act.RevertDataset(first, true)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	if err := act.ReadDataset(&res); err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if res.Dataset.PreviousPath != head.Path {
+		t.Errorf("expected revert previous path to equal %s, got: %s", head.Path, res.Dataset.PreviousPath)
+	}
+	if res.Dataset.Meta == nil || res.Dataset.Meta.Title == "updated cities" {
+		t.Errorf("expected reverted metadata to match version %s", first.Path)
+	}
+	if !strings.Contains(res.Dataset.Commit.Title, first.Path) {
+		t.Errorf("expected commit title to reference %s, got: %s", first.Path, res.Dataset.Commit.Title)
+	}
+}
+
+func testRevertTransform(t *testing.T, rmf RepoMakerFunc) {
+	r, ref := createDataset(t, rmf)
+	act := Dataset{r}
+
+	saveTransform := func(prev repo.DatasetRef, query string) repo.DatasetRef {
+		if err := act.ReadDataset(&prev); err != nil {
+			t.Fatal(err.Error())
+		}
+		data, err := dsfs.LoadData(r.Store(), prev.Dataset)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ds := &dataset.Dataset{}
+		ds.Assign(prev.Dataset)
+		ds.PreviousPath = prev.Path
+		ds.Commit = &dataset.Commit{Title: query}
+		ds.Transform = &dataset.Transform{Syntax: "sql", Data: query}
+		next, err := act.CreateDataset(prev.Name, ds, data, true)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return next
+	}
+
+	first := saveTransform(ref, "select * from cities")
+	head := saveTransform(first, "select city from cities")
+
+	res, err := act.RevertDataset(first, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if res.Path == head.Path {
+		t.Fatal("expected revert to create a new version")
+	}
+	if err := act.ReadDataset(&res); err != nil {
+		t.Fatal(err.Error())
+	}
+	if res.Dataset.Transform == nil || res.Dataset.Transform.Data != "select * from cities" {
+		t.Errorf("expected reverted transform to match version %s, got: %#v", first.Path, res.Dataset.Transform)
+	}
+}
+
+func testResetDataset(t *testing.T, rmf RepoMakerFunc) {
+	r, first, head := createDatasetHistory(t, rmf)
+	act := Dataset{r}
+
+	if _, err := act.ResetDataset(repo.DatasetRef{Name: head.Name, Peername: head.Peername, ProfileID: head.ProfileID, Path: "/map/QmNotInHistory"}); err == nil {
+		t.Error("expected resetting to a version outside dataset history to error")
+		return
+	}
+
+	res, err := act.ResetDataset(first)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if res.Path != first.Path {
+		t.Errorf("expected reset path to equal %s, got: %s", first.Path, res.Path)
+	}
+
+	got, err := r.GetRef(repo.DatasetRef{Peername: head.Peername, Name: head.Name})
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if got.Path != first.Path {
+		t.Errorf("expected refstore path to equal %s, got: %s", first.Path, got.Path)
+	}
+
+	events, err := r.Events(1, 0)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if len(events) != 1 || events[0].Type != repo.ETDsReset {
+		t.Errorf("expected most recent event to be %s", repo.ETDsReset)
+	}
+}
@@ -0,0 +1,257 @@
+package actions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+// FsckCheck names a class of integrity check performed by Fsck
+type FsckCheck string
+
+const (
+	// FsckLoad checks a referenced dataset & it's components load from the store
+	FsckLoad = FsckCheck("load")
+	// FsckHistory checks every previous version of a dataset loads
+	FsckHistory = FsckCheck("history")
+	// FsckSignature checks commit signatures against the author's public key
+	FsckSignature = FsckCheck("signature")
+	// FsckIndex checks the search index agrees with the refstore
+	FsckIndex = FsckCheck("index")
+	// FsckEventLog checks the event log agrees with the refstore
+	FsckEventLog = FsckCheck("eventlog")
+)
+
+// FsckProblem describes a single integrity issue found by Fsck
+type FsckProblem struct {
+	Check    FsckCheck
+	Ref      repo.DatasetRef
+	Message  string
+	Repaired bool
+}
+
+// FsckResult is the outcome of checking a repo's integrity
+type FsckResult struct {
+	RefsChecked int
+	Problems    []FsckProblem
+}
+
+// add records a problem, returning it's index
+func (res *FsckResult) add(check FsckCheck, ref repo.DatasetRef, msg string, params ...interface{}) int {
+	res.Problems = append(res.Problems, FsckProblem{Check: check, Ref: ref, Message: fmt.Sprintf(msg, params...)})
+	return len(res.Problems) - 1
+}
+
+// Fsck checks every reference in a repo's refstore, confirming each dataset
+// and it's components load, history chains resolve, and commit signatures
+// verify. Repos that implement repo.Indexer have their search index checked
+// against the refstore. With repair set, references that don't load are
+// dropped from the refstore and the search index is rebuilt if needed
+func Fsck(r repo.Repo, repair bool) (*FsckResult, error) {
+	if repair && !repo.Writable(r) {
+		return nil, repo.ErrReadOnly
+	}
+
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &FsckResult{RefsChecked: len(refs), Problems: []FsckProblem{}}
+
+	broken := []repo.DatasetRef{}
+	for _, ref := range refs {
+		if err := checkDatasetLoads(r, ref.Path); err != nil {
+			res.add(FsckLoad, ref, err.Error())
+			broken = append(broken, ref)
+			continue
+		}
+
+		// we can only verify signatures for authors who's key we know
+		pub, err := PublicKey(r, ref.ProfileID)
+		verify := err == nil
+		path := ref.Path
+		for path != "" && path != "/" {
+			ds, err := dsfs.LoadDataset(r.Store(), datastore.NewKey(path))
+			if err != nil {
+				res.add(FsckHistory, ref, "version %s doesn't load: %s", path, err.Error())
+				break
+			}
+			if verify {
+				if err := VerifyCommit(pub, ds.Commit); err != nil {
+					res.add(FsckSignature, ref, "version %s: %s", path, err.Error())
+				}
+			}
+			path = ds.PreviousPath
+		}
+	}
+
+	if repair {
+		for _, ref := range broken {
+			if err := r.DeleteRef(ref); err != nil {
+				return res, fmt.Errorf("error dropping reference %s: %s", ref, err.Error())
+			}
+			if err := r.LogEvent(repo.ETDsDeleted, ref); err != nil {
+				return res, err
+			}
+		}
+		for i, p := range res.Problems {
+			if p.Check == FsckLoad {
+				res.Problems[i].Repaired = true
+			}
+		}
+	}
+
+	if err := checkEventLog(r, refs, res); err != nil {
+		return res, err
+	}
+
+	if indexer, ok := repo.Base(r).(repo.Indexer); ok {
+		if err := checkIndex(indexer, refs, broken, repair, res); err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// checkDatasetLoads confirms a dataset and all of it's components can be
+// loaded from the store
+func checkDatasetLoads(r repo.Repo, path string) error {
+	store := r.Store()
+	ds, err := dsfs.LoadDataset(store, datastore.NewKey(path))
+	if err != nil {
+		return fmt.Errorf("dataset doesn't load: %s", err.Error())
+	}
+	if ds.Commit == nil {
+		return fmt.Errorf("dataset has no commit")
+	}
+	if ds.Structure == nil {
+		return fmt.Errorf("dataset has no structure")
+	}
+	if ds.DataPath != "" {
+		has, err := store.Has(datastore.NewKey(ds.DataPath))
+		if err != nil {
+			return fmt.Errorf("error checking data %s: %s", ds.DataPath, err.Error())
+		}
+		if !has {
+			return fmt.Errorf("data %s is missing from the store", ds.DataPath)
+		}
+	}
+	if ds.Meta != nil && ds.Meta.ReadmePath != "" {
+		has, err := store.Has(datastore.NewKey(ds.Meta.ReadmePath))
+		if err != nil {
+			return fmt.Errorf("error checking readme %s: %s", ds.Meta.ReadmePath, err.Error())
+		}
+		if !has {
+			return fmt.Errorf("readme %s is missing from the store", ds.Meta.ReadmePath)
+		}
+	}
+	return nil
+}
+
+// ArchiveReader is an opt-in interface for event logs that can read events
+// archived out of the live log
+type ArchiveReader interface {
+	// ArchivedEvents gives archived events, oldest first
+	ArchivedEvents() ([]*repo.Event, error)
+}
+
+// checkEventLog makes sure every reference has been recorded in the event
+// log, and that the most recent event for a reference isn't a deletion.
+// References created long ago may only be recorded in archived events
+func checkEventLog(r repo.Repo, refs []repo.DatasetRef, res *FsckResult) error {
+	events, err := r.EventsSince(time.Time{})
+	if err != nil {
+		return err
+	}
+	if ar, ok := repo.Base(r).(ArchiveReader); ok {
+		archived, err := ar.ArchivedEvents()
+		if err != nil {
+			return err
+		}
+		events = append(archived, events...)
+	}
+
+	latest := map[string]repo.EventType{}
+	for _, e := range events {
+		// EventsSince orders oldest first, so later events overwrite
+		latest[e.Ref.Path] = e.Type
+	}
+
+	for _, ref := range refs {
+		et, ok := latest[ref.Path]
+		if !ok {
+			res.add(FsckEventLog, ref, "no event log entry for %s", ref.Path)
+		} else if et == repo.ETDsDeleted {
+			res.add(FsckEventLog, ref, "event log records %s as deleted", ref.Path)
+		}
+	}
+	return nil
+}
+
+// checkIndex compares the search index with the refstore. An index that
+// can't be read is reported as a problem rather than failing the check
+func checkIndex(indexer repo.Indexer, refs, broken []repo.DatasetRef, repair bool, res *FsckResult) error {
+	dropped := map[string]bool{}
+	if repair {
+		for _, ref := range broken {
+			dropped[ref.Path] = true
+		}
+	}
+
+	issues := []int{}
+	indexed := 0
+	for _, ref := range refs {
+		if dropped[ref.Path] {
+			continue
+		}
+		has, err := indexer.IndexHas(ref.Path)
+		if err != nil {
+			unreadable := res.add(FsckIndex, repo.DatasetRef{}, "search index can't be read: %s", err.Error())
+			reindex(indexer, repair, []int{unreadable}, res)
+			return nil
+		}
+		if !has {
+			issues = append(issues, res.add(FsckIndex, ref, "%s is missing from the search index", ref.Path))
+			continue
+		}
+		indexed++
+	}
+
+	count, err := indexer.IndexCount()
+	if err != nil {
+		unreadable := res.add(FsckIndex, repo.DatasetRef{}, "search index can't be read: %s", err.Error())
+		reindex(indexer, repair, []int{unreadable}, res)
+		return nil
+	}
+	if count > indexed {
+		issues = append(issues, res.add(FsckIndex, repo.DatasetRef{}, "search index has %d entries that aren't in the refstore", count-indexed))
+	}
+
+	reindex(indexer, repair, issues, res)
+	return nil
+}
+
+// reindex rebuilds the search index when repairing index problems, marking
+// them repaired. A failed rebuild is recorded as another problem
+func reindex(indexer repo.Indexer, repair bool, issues []int, res *FsckResult) {
+	if !repair || len(issues) == 0 {
+		return
+	}
+	if err := indexer.Reindex(); err != nil {
+		log.Debug(err.Error())
+		res.add(FsckIndex, repo.DatasetRef{}, "error rebuilding search index: %s", err.Error())
+		return
+	}
+	for _, i := range issues {
+		res.Problems[i].Repaired = true
+	}
+}
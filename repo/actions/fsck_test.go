@@ -0,0 +1,131 @@
+package actions
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestFsck(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+
+	r, ref := createDataset(t, rmf)
+
+	res, err := Fsck(r, false)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if res.RefsChecked != 1 {
+		t.Errorf("expected 1 ref checked, got: %d", res.RefsChecked)
+	}
+	if len(res.Problems) != 0 {
+		t.Errorf("expected healthy repo to have no problems, got: %v", res.Problems)
+	}
+
+	bad := repo.DatasetRef{
+		ProfileID: ref.ProfileID,
+		Peername:  ref.Peername,
+		Name:      "broken",
+		Path:      "/map/QmNotInTheStore",
+	}
+	if err := r.PutRef(bad); err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	res, err = Fsck(r, false)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	loadProblems := 0
+	for _, p := range res.Problems {
+		if p.Check == FsckLoad {
+			loadProblems++
+			if p.Ref.Path != bad.Path {
+				t.Errorf("expected load problem for %s, got: %s", bad.Path, p.Ref.Path)
+			}
+			if p.Repaired {
+				t.Error("problems shouldn't be repaired without repair set")
+			}
+		}
+	}
+	if loadProblems != 1 {
+		t.Errorf("expected 1 load problem, got: %d", loadProblems)
+	}
+
+	res, err = Fsck(r, true)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	for _, p := range res.Problems {
+		if p.Check == FsckLoad && !p.Repaired {
+			t.Errorf("expected load problem to be repaired: %s", p.Message)
+		}
+	}
+
+	if _, err := r.GetRef(bad); err != repo.ErrNotFound {
+		t.Errorf("expected broken ref to be dropped, got: %v", err)
+	}
+	if _, err := r.GetRef(ref); err != nil {
+		t.Errorf("expected healthy ref to remain: %s", err.Error())
+	}
+}
+
+func TestFsckPeerDataset(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+	author, ref := createDataset(t, rmf)
+
+	data, err := privKey.GetPublic().Bytes()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ps := profile.MemStore{}
+	if err := ps.PutProfile(&profile.Profile{ID: testPeerProfile.ID, Peername: testPeerProfile.Peername, PubKey: base64.StdEncoding.EncodeToString(data)}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// a repo belonging to someone else holding the author's dataset
+	local := &profile.Profile{ID: "QmTwtwLMKHHKCrugNxyAaZ31nhBqRUQVysT2xK911n4m6F", Peername: "local"}
+	r, err := repo.NewMemRepo(local, author.Store(), ps)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.PutRef(ref); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.LogEvent(repo.ETDsAdded, ref); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if status, err := VerifyDataset(r, &ref); err != nil || status != repo.SigValid {
+		t.Fatalf("expected the author's signature to verify, got: '%s', %v", status, err)
+	}
+
+	res, err := Fsck(r, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, p := range res.Problems {
+		t.Errorf("unexpected problem: %s %s", p.Check, p.Message)
+	}
+}
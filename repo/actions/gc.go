@@ -0,0 +1,336 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	ipfs "github.com/qri-io/cafs/ipfs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+// ErrNotCollectable is returned when attempting to garbage collect a store
+// that cannot enumerate it's contents
+var ErrNotCollectable = fmt.Errorf("backing store doesn't support garbage collection")
+
+// storeLock coordinates writes to a repo's store with garbage collection.
+// Writers take a read lock so they can proceed in parallel, collection takes
+// the write lock so it never sweeps blocks that haven't been referenced yet
+var storeLock = newGCLock()
+
+// gcLock is a readers-writer lock where readers never wait on a writer that
+// hasn't got the lock yet, so read locks can nest. A write that spans several
+// actions holds a read lock across all of them, and each action takes it's
+// own read lock inside that
+type gcLock struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	readers    int
+	collecting bool
+}
+
+func newGCLock() *gcLock {
+	l := &gcLock{}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *gcLock) RLock() {
+	l.mu.Lock()
+	for l.collecting {
+		l.cond.Wait()
+	}
+	l.readers++
+	l.mu.Unlock()
+}
+
+func (l *gcLock) RUnlock() {
+	l.mu.Lock()
+	l.readers--
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+func (l *gcLock) Lock() {
+	l.mu.Lock()
+	for l.collecting || l.readers > 0 {
+		l.cond.Wait()
+	}
+	l.collecting = true
+	l.mu.Unlock()
+}
+
+func (l *gcLock) Unlock() {
+	l.mu.Lock()
+	l.collecting = false
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// HoldStore holds off garbage collection until the returned func is called.
+// Writes that put blocks in the store before referencing them across more
+// than one step, like writing a readme and then the dataset that uses it,
+// should hold the store for the whole write
+func HoldStore() (release func()) {
+	storeLock.RLock()
+	return storeLock.RUnlock
+}
+
+// KeyLister is an opt-in interface for cafs.Filestore implementations that
+// can enumerate every key they hold. Stores must be listable to be collected
+type KeyLister interface {
+	Keys() ([]datastore.Key, error)
+}
+
+// EventArchiver is an opt-in interface for repos that archive old events
+// into their store. Archived events are reachable
+type EventArchiver interface {
+	EventArchives() ([]string, error)
+}
+
+// RepoLocker is an opt-in interface for repos that can lock their on-disk
+// state against other processes
+type RepoLocker interface {
+	WithExclusiveLock(fn func() error) error
+}
+
+// GCResult describes the outcome of a garbage collection pass
+type GCResult struct {
+	// DryRun is true if no blocks were actually removed
+	DryRun bool
+	// Removed lists the keys of unreferenced blocks
+	Removed []string
+	// BytesFreed is the total size of all removed blocks
+	BytesFreed int64
+	// Pending is true when removed blocks were only unpinned from an ipfs
+	// store, & are left on disk until ipfs' own garbage collection runs
+	Pending bool
+}
+
+// CollectGarbage removes every block from a repo's store that can't be
+// reached from a reference in the refstore, the history of those references,
+// or the photos of known profiles. With dryRun set nothing is removed, but
+// the result reports what would be. ipfs stores only have blocks qri pinned
+// unpinned, ipfsGC runs ipfs' own garbage collection afterward
+func CollectGarbage(r repo.Repo, dryRun, ipfsGC bool) (res *GCResult, err error) {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+
+	if !dryRun && !repo.Writable(r) {
+		return nil, repo.ErrReadOnly
+	}
+
+	// storeLock only covers this process. sweeping takes the on-disk repo
+	// lock as well so no other qri process can write to the store mid-sweep
+	locker, ok := repo.Base(r).(RepoLocker)
+	if dryRun || !ok {
+		return collectGarbage(r, dryRun, ipfsGC)
+	}
+	err = locker.WithExclusiveLock(func() error {
+		res, err = collectGarbage(r, dryRun, ipfsGC)
+		return err
+	})
+	return res, err
+}
+
+func collectGarbage(r repo.Repo, dryRun, ipfsGC bool) (*GCResult, error) {
+	store := r.Store()
+	if ipfsStore, ok := repo.BaseStore(store).(*ipfs.Filestore); ok {
+		return collectIPFS(r, ipfsStore, dryRun, ipfsGC)
+	}
+
+	keys, err := storeKeys(repo.BaseStore(store))
+	if err != nil {
+		return nil, err
+	}
+
+	reachable, err := ReachablePaths(r)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &GCResult{DryRun: dryRun, Removed: []string{}}
+	for _, key := range keys {
+		if isReachable(reachable, key.String()) {
+			continue
+		}
+
+		size, err := blockSize(store, key)
+		if err != nil {
+			log.Debug(err.Error())
+			return nil, fmt.Errorf("error reading block %s: %s", key.String(), err.Error())
+		}
+
+		if !dryRun {
+			if pinner, ok := store.(cafs.Pinner); ok {
+				// blocks may not be pinned, so unpin errors are expected
+				pinner.Unpin(key, false)
+			}
+			if err := store.Delete(key); err != nil {
+				log.Debug(err.Error())
+				return nil, fmt.Errorf("error removing block %s: %s", key.String(), err.Error())
+			}
+		}
+
+		res.Removed = append(res.Removed, key.String())
+		res.BytesFreed += size
+	}
+
+	return res, nil
+}
+
+// ReachablePaths gives the set of store paths that are referenced by a repo,
+// either directly in the refstore, in the history of those references, or as
+// profile photos
+func ReachablePaths(r repo.Repo) (map[string]bool, error) {
+	reachable := map[string]bool{}
+
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := markHistories(r.Store(), reachable, refs); err != nil {
+		return nil, err
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		return nil, err
+	}
+	markProfilePhotos(reachable, pro.Thumb, pro.Profile, pro.Poster)
+
+	profiles, err := r.Profiles().List()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range profiles {
+		markProfilePhotos(reachable, p.Thumb, p.Profile, p.Poster)
+	}
+
+	if ea, ok := repo.Base(r).(EventArchiver); ok {
+		paths, err := ea.EventArchives()
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			markPath(reachable, path)
+		}
+	}
+
+	return reachable, nil
+}
+
+// markHistories marks every version in the history of each ref reachable
+func markHistories(store cafs.Filestore, reachable map[string]bool, refs []repo.DatasetRef) error {
+	for _, ref := range refs {
+		path := ref.Path
+		for path != "" && path != "/" && !reachable[path] {
+			ds, err := dsfs.LoadDatasetRefs(store, datastore.NewKey(path))
+			if err != nil {
+				log.Debug(err.Error())
+				return fmt.Errorf("error loading dataset %s: %s", path, err.Error())
+			}
+			markDataset(reachable, path, ds)
+			if ds.Meta != nil {
+				// readmes are referenced from within metadata
+				markPath(reachable, readmePath(store, path))
+			}
+			path = ds.PreviousPath
+		}
+	}
+	return nil
+}
+
+func markDataset(reachable map[string]bool, path string, ds *dataset.Dataset) {
+	markPath(reachable, path)
+	markPath(reachable, ds.DataPath)
+	if ds.Commit != nil {
+		markPath(reachable, ds.Commit.Path().String())
+	}
+	if ds.Meta != nil {
+		markPath(reachable, ds.Meta.Path().String())
+	}
+	if ds.Structure != nil {
+		markPath(reachable, ds.Structure.Path().String())
+	}
+	if ds.Transform != nil {
+		markPath(reachable, ds.Transform.Path().String())
+	}
+	if ds.AbstractTransform != nil {
+		markPath(reachable, ds.AbstractTransform.Path().String())
+	}
+	if ds.VisConfig != nil {
+		markPath(reachable, ds.VisConfig.Path().String())
+	}
+}
+
+func markProfilePhotos(reachable map[string]bool, keys ...datastore.Key) {
+	for _, key := range keys {
+		markPath(reachable, key.String())
+	}
+}
+
+// markPath adds a path to the reachable set. Paths within a content-addressed
+// directory (eg. /ipfs/QmHash/dataset.json) keep the directory alive as well
+func markPath(reachable map[string]bool, path string) {
+	if path == "" || path == "/" {
+		return
+	}
+	reachable[path] = true
+	if root := rootPath(path); root != path {
+		reachable[root] = true
+	}
+}
+
+// isReachable checks a store key against the reachable set. Any key within a
+// reachable directory is also reachable
+func isReachable(reachable map[string]bool, key string) bool {
+	return reachable[key] || reachable[rootPath(key)]
+}
+
+// rootPath trims a path to it's first two elements: /network/hash
+func rootPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) <= 2 {
+		return path
+	}
+	return "/" + strings.Join(parts[:2], "/")
+}
+
+func storeKeys(store cafs.Filestore) ([]datastore.Key, error) {
+	switch s := store.(type) {
+	case KeyLister:
+		return s.Keys()
+	case *cafs.MapStore:
+		keys := make([]datastore.Key, 0, len(s.Files))
+		for key := range s.Files {
+			keys = append(keys, key)
+		}
+		return keys, nil
+	default:
+		return nil, ErrNotCollectable
+	}
+}
+
+func blockSize(store cafs.Filestore, key datastore.Key) (int64, error) {
+	f, err := store.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if f.IsDirectory() {
+		return 0, nil
+	}
+	return io.Copy(ioutil.Discard, f)
+}
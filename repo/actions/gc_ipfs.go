@@ -0,0 +1,132 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	ipfs "github.com/qri-io/cafs/ipfs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+
+	corerepo "gx/ipfs/QmatUACvrFK3xYg1nd2iLAKfz7Yy5YB56tnzBYHpqiUuhn/go-ipfs/core/corerepo"
+)
+
+// collectIPFS garbage collects an ipfs store. ipfs can't list the blocks it
+// holds by path, so collection works on pins instead. The ipfs repo is shared
+// with anything else the user does with ipfs, so only roots qri pinned itself
+// are considered: any of those that isn't reachable from the repo is unpinned.
+// Unpinned blocks stay on disk until ipfs' own repo gc runs, which also removes
+// unpinned content that has nothing to do with qri, so it's only run when
+// ipfsGC is set
+func collectIPFS(r repo.Repo, store *ipfs.Filestore, dryRun, ipfsGC bool) (*GCResult, error) {
+	node := store.Node()
+
+	recursive := []datastore.Key{}
+	for _, c := range node.Pinning.RecursiveKeys() {
+		recursive = append(recursive, datastore.NewKey("/ipfs/"+c.String()))
+	}
+	direct := []datastore.Key{}
+	for _, c := range node.Pinning.DirectKeys() {
+		direct = append(direct, datastore.NewKey("/ipfs/"+c.String()))
+	}
+	pinned := map[string]bool{}
+	for _, key := range append(recursive, direct...) {
+		pinned[key.String()] = true
+	}
+
+	reachable, err := ReachablePaths(r)
+	if err != nil {
+		return nil, err
+	}
+	pinnedByQri, err := qriPins(r, pinned)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &GCResult{DryRun: dryRun, Removed: []string{}, Pending: !ipfsGC}
+	sweep := func(recursive bool, keys []datastore.Key) error {
+		for _, key := range keys {
+			if !isReachable(pinnedByQri, key.String()) || isReachable(reachable, key.String()) {
+				continue
+			}
+
+			size, err := pathSize(store, key)
+			if err != nil {
+				log.Debug(err.Error())
+				return fmt.Errorf("error reading block %s: %s", key.String(), err.Error())
+			}
+
+			if !dryRun {
+				if err := store.Unpin(key, recursive); err != nil {
+					log.Debug(err.Error())
+					return fmt.Errorf("error unpinning %s: %s", key.String(), err.Error())
+				}
+			}
+
+			res.Removed = append(res.Removed, key.String())
+			res.BytesFreed += size
+		}
+		return nil
+	}
+
+	if err := sweep(true, recursive); err != nil {
+		return nil, err
+	}
+	if err := sweep(false, direct); err != nil {
+		return nil, err
+	}
+
+	if !dryRun && ipfsGC {
+		if err := corerepo.GarbageCollect(node, context.Background()); err != nil {
+			log.Debug(err.Error())
+			return nil, fmt.Errorf("error running ipfs gc: %s", err.Error())
+		}
+	}
+
+	return res, nil
+}
+
+// qriPins gives the set of paths qri has pinned. Every dataset version qri
+// creates or adds is recorded in the event log, so the set is made up of each
+// path the event log (including archived events) has a record of, along with
+// the components & readme of those that are still pinned
+func qriPins(r repo.Repo, pinned map[string]bool) (map[string]bool, error) {
+	events, err := r.EventsSince(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if ar, ok := repo.Base(r).(ArchiveReader); ok {
+		archived, err := ar.ArchivedEvents()
+		if err != nil {
+			return nil, err
+		}
+		events = append(archived, events...)
+	}
+
+	paths := map[string]bool{}
+	for _, e := range events {
+		path := e.Ref.Path
+		if path == "" || paths[path] {
+			continue
+		}
+		markPath(paths, path)
+
+		// only pinned datasets are sure to be held locally, loading anything
+		// else could go looking for it on the network
+		if !pinned[rootPath(path)] {
+			continue
+		}
+		ds, err := dsfs.LoadDatasetRefs(r.Store(), datastore.NewKey(path))
+		if err != nil {
+			log.Debug(err.Error())
+			continue
+		}
+		markDataset(paths, path, ds)
+		if ds.Meta != nil {
+			markPath(paths, readmePath(r.Store(), path))
+		}
+	}
+	return paths, nil
+}
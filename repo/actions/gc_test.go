@@ -0,0 +1,78 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+
+	r, ref := createDataset(t, rmf)
+	stray, err := r.Store().Put(cafs.NewMemfileBytes("stray.json", []byte(`{"unreferenced":true}`)), false)
+	if err != nil {
+		t.Errorf("error putting stray file: %s", err.Error())
+		return
+	}
+
+	res, err := CollectGarbage(r, true, false)
+	if err != nil {
+		t.Errorf("dry run error: %s", err.Error())
+		return
+	}
+	if !res.DryRun {
+		t.Error("expected dry run result to report DryRun")
+	}
+	if !containsKey(res.Removed, stray.String()) {
+		t.Errorf("expected dry run to report stray block %s for removal", stray.String())
+	}
+	if res.BytesFreed <= 0 {
+		t.Errorf("expected dry run to report bytes to free, got: %d", res.BytesFreed)
+	}
+	if exists, _ := r.Store().Has(stray); !exists {
+		t.Error("dry run shouldn't remove blocks")
+	}
+
+	res, err = CollectGarbage(r, false, false)
+	if err != nil {
+		t.Errorf("collection error: %s", err.Error())
+		return
+	}
+	if exists, _ := r.Store().Has(stray); exists {
+		t.Errorf("expected stray block %s to be removed", stray.String())
+	}
+
+	if _, err := dsfs.LoadDataset(r.Store(), datastore.NewKey(ref.Path)); err != nil {
+		t.Errorf("referenced dataset should still load after collection: %s", err.Error())
+	}
+
+	res, err = CollectGarbage(r, false, false)
+	if err != nil {
+		t.Errorf("collection error: %s", err.Error())
+		return
+	}
+	if len(res.Removed) != 0 {
+		t.Errorf("expected second collection to remove nothing, removed %d blocks", len(res.Removed))
+	}
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
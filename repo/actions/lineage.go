@@ -0,0 +1,246 @@
+package actions
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+// TransformRunner executes the transform of a derived dataset, producing the
+// dataset & data for a new version. ds.Transform.Resources point at the
+// current heads of it's upstream datasets
+type TransformRunner func(r repo.Repo, ds *dataset.Dataset) (*dataset.Dataset, cafs.File, error)
+
+var (
+	// RerunDerived configures CreateDataset to re-run the transforms of
+	// datasets derived from the dataset being created, instead of marking them
+	// stale. Datasets are still marked stale when no runner is registered for
+	// their transform's syntax, or re-running fails
+	RerunDerived bool
+
+	runnersLock sync.Mutex
+	// runners holds transform runners by transform syntax
+	runners = map[string]TransformRunner{}
+)
+
+// RegisterTransformRunner sets the runner for transforms written in syntax,
+// replacing any runner already registered for it
+func RegisterTransformRunner(syntax string, run TransformRunner) {
+	runnersLock.Lock()
+	defer runnersLock.Unlock()
+	runners[syntax] = run
+}
+
+// transformRunner gives the runner registered for syntax, nil if there isn't
+// one
+func transformRunner(syntax string) TransformRunner {
+	runnersLock.Lock()
+	defer runnersLock.Unlock()
+	return runners[syntax]
+}
+
+// recordUpstreams records the datasets ds was derived from, as named by it's
+// transform resources. Resources that aren't in this repo are skipped
+func (act Dataset) recordUpstreams(ref repo.DatasetRef, ds *dataset.Dataset) error {
+	if ds.Transform == nil || len(ds.Transform.Resources) == 0 {
+		return nil
+	}
+
+	ups := []repo.DatasetRef{}
+	for _, res := range ds.Transform.Resources {
+		if res == nil {
+			continue
+		}
+		up, err := act.refForPath(res.Path().String())
+		if err != nil {
+			log.Debugf("resource %s isn't a dataset in this repo: %s", res.Path(), err)
+			continue
+		}
+		ups = append(ups, up)
+	}
+
+	if err := act.PutUpstreams(ref, ups); err != nil {
+		return err
+	}
+	return act.SetStale(ref, false)
+}
+
+// updateDerived responds to a new head for ref by re-running or marking stale
+// every dataset derived from it. Each re-run is logged as a rerun event, &
+// updates the datasets derived from it in turn, so the event log records the
+// whole chain. seen guards against re-visiting datasets when lineage forms a
+// diamond (or a cycle)
+func (act Dataset) updateDerived(ref repo.DatasetRef, seen map[string]bool) {
+	downs, err := act.Downstreams(ref)
+	if err != nil {
+		log.Debug(err.Error())
+		return
+	}
+
+	for _, down := range downs {
+		if seen[down.AliasString()] {
+			continue
+		}
+		seen[down.AliasString()] = true
+
+		if RerunDerived {
+			updated, err := act.rerun(down)
+			if err == nil {
+				act.updateDerived(updated, seen)
+				continue
+			}
+			log.Infof("error re-running transform for %s, marking stale: %s", down.AliasString(), err.Error())
+		}
+
+		if err := act.markStale(down); err != nil {
+			log.Debug(err.Error())
+			continue
+		}
+		act.updateDerived(down, seen)
+	}
+}
+
+// markStale flags a derived dataset as out of date with it's upstreams
+func (act Dataset) markStale(ref repo.DatasetRef) error {
+	head, err := act.GetRef(ref)
+	if err != nil {
+		return err
+	}
+	if err := act.SetStale(head, true); err != nil {
+		return err
+	}
+	return act.LogEvent(repo.ETDsStale, head)
+}
+
+// rerun re-executes the transform of a derived dataset against the current
+// heads of it's upstreams, saving the result as a new version
+func (act Dataset) rerun(ref repo.DatasetRef) (res repo.DatasetRef, err error) {
+	head, err := act.GetRef(ref)
+	if err != nil {
+		return
+	}
+
+	prev, err := dsfs.LoadDataset(act.Store(), datastore.NewKey(head.Path))
+	if err != nil {
+		return
+	}
+	if prev.Transform == nil {
+		return res, fmt.Errorf("%s has no transform", head.AliasString())
+	}
+	run := transformRunner(prev.Transform.Syntax)
+	if run == nil {
+		return res, fmt.Errorf("no runner for '%s' transforms", prev.Transform.Syntax)
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(prev)
+	ds.Transform = &dataset.Transform{}
+	ds.Transform.Assign(prev.Transform)
+	ds.Transform.Resources = map[string]*dataset.Dataset{}
+	for name, r := range prev.Transform.Resources {
+		up, e := act.refForPath(r.Path().String())
+		if e != nil {
+			ds.Transform.Resources[name] = r
+			continue
+		}
+		ds.Transform.Resources[name] = dataset.NewDatasetRef(datastore.NewKey(up.Path))
+	}
+
+	next, data, err := run(act.Repo, ds)
+	if err != nil {
+		return
+	}
+	next.PreviousPath = head.Path
+	next.Commit = &dataset.Commit{
+		Title:   "re-run transform",
+		Message: fmt.Sprintf("an upstream of %s changed, this version re-runs it's transform", head.AliasString()),
+	}
+
+	if res, err = act.createDataset(head.Name, next, data, true); err != nil {
+		return
+	}
+	err = act.LogEvent(repo.ETDsRerun, res)
+	return
+}
+
+// refForPath finds the dataset a version path belongs to, giving the current
+// head of that dataset. path may be any version in the dataset's history
+func (act Dataset) refForPath(path string) (repo.DatasetRef, error) {
+	if ref, err := act.GetRef(repo.DatasetRef{Path: path}); err == nil {
+		return ref, nil
+	}
+
+	count, err := act.RefCount()
+	if err != nil {
+		return repo.DatasetRef{}, err
+	}
+	refs, err := act.References(count, 0)
+	if err != nil {
+		return repo.DatasetRef{}, err
+	}
+
+	for _, ref := range refs {
+		p := ref.Path
+		for p != "" && p != "/" {
+			ds, err := dsfs.LoadDatasetRefs(act.Store(), datastore.NewKey(p))
+			if err != nil {
+				break
+			}
+			if ds.PreviousPath == path {
+				return ref, nil
+			}
+			p = ds.PreviousPath
+		}
+	}
+	return repo.DatasetRef{}, repo.ErrNotFound
+}
+
+// renameLineage moves the lineage record of a onto b, updating datasets
+// derived from a to point at b
+func (act Dataset) renameLineage(a, b repo.DatasetRef) error {
+	ups, err := act.Upstreams(a)
+	if err != nil {
+		return err
+	}
+	stale, err := act.IsStale(a)
+	if err != nil {
+		return err
+	}
+	downs, err := act.Downstreams(a)
+	if err != nil {
+		return err
+	}
+
+	if len(ups) > 0 {
+		if err := act.DeleteLineage(a); err != nil {
+			return err
+		}
+		if err := act.PutUpstreams(b, ups); err != nil {
+			return err
+		}
+		if err := act.SetStale(b, stale); err != nil {
+			return err
+		}
+	}
+
+	for _, down := range downs {
+		dups, err := act.Upstreams(down)
+		if err != nil {
+			return err
+		}
+		for i, up := range dups {
+			if up.AliasString() == a.AliasString() {
+				dups[i] = b
+			}
+		}
+		if err := act.PutUpstreams(down, dups); err != nil {
+			return err
+		}
+	}
+	return nil
+}
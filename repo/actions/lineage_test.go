@@ -0,0 +1,219 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestDerivedDatasets(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		return mr
+	}
+
+	r, upstream, derived := createDerivedDataset(t, rmf)
+	act := Dataset{r}
+
+	ups, err := r.Upstreams(derived)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(ups) != 1 || ups[0].AliasString() != upstream.AliasString() {
+		t.Fatalf("expected %s to be derived from %s, got: %v", derived.AliasString(), upstream.AliasString(), ups)
+	}
+
+	updateUpstream(t, act, upstream)
+	if stale, err := r.IsStale(derived); err != nil || !stale {
+		t.Errorf("expected derived dataset to be stale after upstream update. err: %v", err)
+	}
+	if es, err := r.Events(1, 0); err != nil || len(es) != 1 || es[0].Type != repo.ETDsStale {
+		t.Errorf("expected most recent event to mark derived dataset stale. err: %v", err)
+	}
+
+	// renaming the upstream must keep the derived dataset's lineage intact
+	renamed := repo.DatasetRef{ProfileID: upstream.ProfileID, Peername: upstream.Peername, Name: "renamed_cities"}
+	if err := act.RenameDataset(upstream, renamed); err != nil {
+		t.Fatal(err.Error())
+	}
+	if downs, _ := r.Downstreams(renamed); len(downs) != 1 {
+		t.Errorf("expected renamed upstream to keep 1 downstream, got: %d", len(downs))
+	}
+}
+
+func TestDerivedDatasetsRerun(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		return mr
+	}
+
+	ran := 0
+	RerunDerived = true
+	RegisterTransformRunner("sql", func(r repo.Repo, ds *dataset.Dataset) (*dataset.Dataset, cafs.File, error) {
+		ran++
+		next := &dataset.Dataset{}
+		next.Assign(ds)
+		next.Meta = &dataset.Meta{Title: "re-run"}
+		data, err := dsfs.LoadData(r.Store(), ds)
+		return next, data, err
+	})
+	defer func() {
+		RerunDerived = false
+		RegisterTransformRunner("sql", nil)
+	}()
+
+	r, upstream, derived := createDerivedDataset(t, rmf)
+	act := Dataset{r}
+
+	// derive a second dataset from the derived one, so re-runs chain
+	if err := act.ReadDataset(&derived); err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := dsfs.LoadData(r.Store(), derived.Dataset)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ds := &dataset.Dataset{}
+	ds.Assign(derived.Dataset)
+	ds.PreviousPath = ""
+	ds.Commit = &dataset.Commit{Title: "derive from derived cities"}
+	ds.Transform = &dataset.Transform{
+		Syntax: "sql",
+		Data:   "select * from derived_cities",
+		Resources: map[string]*dataset.Dataset{
+			"derived_cities": dataset.NewDatasetRef(datastore.NewKey(derived.Path)),
+		},
+	}
+	second, err := act.CreateDataset("second_cities", ds, data, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	head := updateUpstream(t, act, upstream)
+	if ran != 2 {
+		t.Errorf("expected transforms to run twice, ran: %d", ran)
+	}
+	for _, ref := range []repo.DatasetRef{derived, second} {
+		if stale, _ := r.IsStale(ref); stale {
+			t.Errorf("expected re-run %s not to be stale", ref.AliasString())
+		}
+	}
+
+	rerun, err := r.GetRef(repo.DatasetRef{Peername: derived.Peername, Name: derived.Name})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if rerun.Path == derived.Path {
+		t.Error("expected re-run to create a new version of the derived dataset")
+	}
+	got, err := dsfs.LoadDataset(r.Store(), datastore.NewKey(rerun.Path))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Transform == nil || got.Transform.Resources["cities"] == nil || got.Transform.Resources["cities"].Path().String() != head.Path {
+		t.Errorf("expected re-run transform to draw on the upstream head %s", head.Path)
+	}
+
+	// the event log records the chain of re-runs, newest first
+	events, err := r.Events(20, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	chain := []string{}
+	for _, e := range events {
+		if e.Type == repo.ETDsRerun {
+			chain = append(chain, e.Ref.Name)
+		}
+	}
+	if len(chain) != 2 || chain[0] != "second_cities" || chain[1] != "derived_cities" {
+		t.Errorf("expected re-run events for derived_cities then second_cities, got: %v", chain)
+	}
+}
+
+func TestDerivedDatasetsRerunNoRunner(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		return mr
+	}
+
+	RerunDerived = true
+	defer func() { RerunDerived = false }()
+
+	r, upstream, derived := createDerivedDataset(t, rmf)
+	updateUpstream(t, Dataset{r}, upstream)
+	if stale, err := r.IsStale(derived); err != nil || !stale {
+		t.Errorf("expected derived dataset without a transform runner to be marked stale. err: %v", err)
+	}
+}
+
+// createDerivedDataset creates the cities dataset, and a dataset derived from
+// it with a transform
+func createDerivedDataset(t *testing.T, rmf RepoMakerFunc) (r repo.Repo, upstream, derived repo.DatasetRef) {
+	r, upstream = createDataset(t, rmf)
+	act := Dataset{r}
+
+	if err := act.ReadDataset(&upstream); err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := dsfs.LoadData(r.Store(), upstream.Dataset)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(upstream.Dataset)
+	ds.PreviousPath = ""
+	ds.Meta = &dataset.Meta{Title: "derived cities"}
+	ds.Commit = &dataset.Commit{Title: "derive from cities"}
+	ds.Transform = &dataset.Transform{
+		Syntax: "sql",
+		Data:   "select * from cities",
+		Resources: map[string]*dataset.Dataset{
+			"cities": dataset.NewDatasetRef(datastore.NewKey(upstream.Path)),
+		},
+	}
+
+	derived, err = act.CreateDataset("derived_cities", ds, data, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return
+}
+
+// updateUpstream saves a new version of the upstream dataset, returning the
+// new head
+func updateUpstream(t *testing.T, act Dataset, upstream repo.DatasetRef) repo.DatasetRef {
+	if err := act.ReadDataset(&upstream); err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := dsfs.LoadData(act.Store(), upstream.Dataset)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ds := &dataset.Dataset{}
+	ds.Assign(upstream.Dataset)
+	ds.PreviousPath = upstream.Path
+	ds.Meta = &dataset.Meta{Title: "updated cities"}
+	ds.Commit = &dataset.Commit{Title: "update meta"}
+
+	head, err := act.CreateDataset(upstream.Name, ds, data, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return head
+}
@@ -0,0 +1,115 @@
+package actions
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"gopkg.in/russross/blackfriday.v2"
+)
+
+// ReadmeFilename is the name readme files are stored under
+const ReadmeFilename = "readme.md"
+
+// SaveReadme writes markdown-formatted readme text to the store, returning
+// the path to set as a dataset's Meta.ReadmePath. Readmes are stored outside
+// the dataset package, so they're pinned on their own. PinReadme keeps the
+// readme's pin in step with the dataset's
+func (act Dataset) SaveReadme(md []byte) (string, error) {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+
+	key, err := act.Store().Put(cafs.NewMemfileBytes(ReadmeFilename, md), true)
+	if err != nil {
+		return "", fmt.Errorf("error putting readme in store: %s", err.Error())
+	}
+	return key.String(), nil
+}
+
+// LoadReadme reads the markdown readme of a dataset from the store. datasets
+// without a readme return nil bytes and no error
+func (act Dataset) LoadReadme(ds *dataset.Dataset) ([]byte, error) {
+	if ds == nil || ds.Meta == nil || ds.Meta.ReadmePath == "" {
+		return nil, nil
+	}
+
+	f, err := act.Store().Get(datastore.NewKey(ds.Meta.ReadmePath))
+	if err != nil {
+		return nil, fmt.Errorf("error loading readme: %s", err.Error())
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// RenderReadme converts markdown readme text to HTML. Output is sanitized,
+// making it safe to include in a web page
+func RenderReadme(md []byte) []byte {
+	unsafe := blackfriday.Run(md)
+	return bluemonday.UGCPolicy().SanitizeBytes(unsafe)
+}
+
+// readmePath gives the readme path of the dataset at path, if any
+func readmePath(store cafs.Filestore, path string) string {
+	ds, err := dsfs.LoadDataset(store, datastore.NewKey(path))
+	if err != nil || ds.Meta == nil {
+		return ""
+	}
+	return ds.Meta.ReadmePath
+}
+
+// PinReadme pins or unpins the readme of the dataset at path, if it has one.
+// Readmes aren't part of the dataset package, so pinning a dataset alone
+// doesn't retain them
+func PinReadme(store cafs.Filestore, path string, pin bool) error {
+	pinner, ok := store.(cafs.Pinner)
+	rp := readmePath(store, path)
+	if !ok || rp == "" {
+		return nil
+	}
+	if pin {
+		return pinner.Pin(datastore.NewKey(rp), true)
+	}
+	return pinner.Unpin(datastore.NewKey(rp), true)
+}
+
+// UnpinReadme unpins the readme of a dataset, if it has one. Readmes are
+// content-addressed & carried forward between versions, so one readme can be
+// used by many versions & datasets. It's only unpinned once no version
+// reachable from any other reference uses it
+func UnpinReadme(r repo.Repo, ref repo.DatasetRef) error {
+	store := r.Store()
+	pinner, ok := store.(cafs.Pinner)
+	rp := readmePath(store, ref.Path)
+	if !ok || rp == "" {
+		return nil
+	}
+
+	count, err := r.RefCount()
+	if err != nil {
+		return err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return err
+	}
+	others := []repo.DatasetRef{}
+	for _, other := range refs {
+		if other.AliasString() == ref.AliasString() {
+			continue
+		}
+		others = append(others, other)
+	}
+	used := map[string]bool{}
+	if err := markHistories(store, used, others); err != nil {
+		return err
+	}
+	if used[rp] {
+		return nil
+	}
+	return pinner.Unpin(datastore.NewKey(rp), true)
+}
@@ -0,0 +1,179 @@
+package actions
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	"github.com/qri-io/qri/store/localfs"
+)
+
+func TestReadme(t *testing.T) {
+	r, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	act := Dataset{r}
+
+	md, err := act.LoadReadme(&dataset.Dataset{Meta: &dataset.Meta{}})
+	if err != nil || md != nil {
+		t.Errorf("expected dataset without a readme to load nothing. got: %s, err: %v", md, err)
+	}
+
+	path, err := act.SaveReadme([]byte("# cities\n\na list of cities"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	md, err = act.LoadReadme(&dataset.Dataset{Meta: &dataset.Meta{ReadmePath: path}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if string(md) != "# cities\n\na list of cities" {
+		t.Errorf("readme mismatch. got: %s", md)
+	}
+}
+
+func TestRenderReadme(t *testing.T) {
+	html := string(RenderReadme([]byte("# cities\n\n<script>alert('hi')</script>")))
+	if !strings.Contains(html, "<h1>cities</h1>") {
+		t.Errorf("expected rendered heading, got: %s", html)
+	}
+	if strings.Contains(html, "<script>") {
+		t.Errorf("expected script tags to be sanitized, got: %s", html)
+	}
+}
+
+func TestPinReadme(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_pin_readme")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	store, err := localfs.NewFilestore(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	r, err := repo.NewMemRepo(testPeerProfile, store, profile.MemStore{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	r.SetPrivateKey(privKey)
+	act := Dataset{r}
+
+	readme, err := act.SaveReadme([]byte("# cities"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tc, err := dstest.NewTestCaseFromDir(testdataPath("cities"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if tc.Input.Meta == nil {
+		tc.Input.Meta = &dataset.Meta{}
+	}
+	tc.Input.Meta.ReadmePath = readme
+	ref, err := act.CreateDataset(tc.Name, tc.Input, tc.DataFile(), true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	pinned := func() bool {
+		keys, err := store.Pinned()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for _, key := range keys {
+			if key.String() == readme {
+				return true
+			}
+		}
+		return false
+	}
+	if !pinned() {
+		t.Error("expected readme to be pinned")
+	}
+	if err := act.UnpinDataset(ref); err != nil {
+		t.Fatal(err.Error())
+	}
+	if pinned() {
+		t.Error("expected readme to be unpinned along with it's dataset")
+	}
+	if err := act.PinDataset(ref); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !pinned() {
+		t.Error("expected readme to be pinned along with it's dataset")
+	}
+}
+
+func TestUnpinSharedReadme(t *testing.T) {
+	path, err := ioutil.TempDir("", "qri_shared_readme")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	store, err := localfs.NewFilestore(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	r, err := repo.NewMemRepo(testPeerProfile, store, profile.MemStore{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	r.SetPrivateKey(privKey)
+	act := Dataset{r}
+
+	readme, err := act.SaveReadme([]byte("# shared"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	refs := []repo.DatasetRef{}
+	for _, name := range []string{"cities", "movies"} {
+		tc, err := dstest.NewTestCaseFromDir(testdataPath(name))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if tc.Input.Meta == nil {
+			tc.Input.Meta = &dataset.Meta{}
+		}
+		tc.Input.Meta.ReadmePath = readme
+		ref, err := act.CreateDataset(tc.Name, tc.Input, tc.DataFile(), true)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		refs = append(refs, ref)
+	}
+
+	pinned := func() bool {
+		keys, err := store.Pinned()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for _, key := range keys {
+			if key.String() == readme {
+				return true
+			}
+		}
+		return false
+	}
+
+	if err := act.DeleteDataset(refs[0]); err != nil {
+		t.Fatal(err.Error())
+	}
+	if !pinned() {
+		t.Error("expected readme to stay pinned while another dataset uses it")
+	}
+	if err := act.DeleteDataset(refs[1]); err != nil {
+		t.Fatal(err.Error())
+	}
+	if pinned() {
+		t.Error("expected readme to be unpinned once no dataset uses it")
+	}
+}
@@ -0,0 +1,188 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+)
+
+// DatasetUsage is the storage used by a single dataset reference
+type DatasetUsage struct {
+	// Ref is the dataset alias, eg: peer/movies
+	Ref string `json:"ref"`
+	// Path is the path of the latest version
+	Path string `json:"path"`
+	// Versions is the number of versions in the dataset's history
+	Versions int `json:"versions"`
+	// HeadBytes is the size of the blocks the latest version uses
+	HeadBytes int64 `json:"headBytes"`
+	// HistoryBytes is the size of the blocks any version uses, counting each
+	// block once
+	HistoryBytes int64 `json:"historyBytes"`
+	// SharedBytes is the part of HistoryBytes in blocks other datasets use too
+	SharedBytes int64 `json:"sharedBytes"`
+}
+
+// UsageReport breaks down the storage a repo's datasets use
+type UsageReport struct {
+	// Datasets is sorted by HistoryBytes, largest first
+	Datasets []*DatasetUsage `json:"datasets"`
+	// TotalBytes is the size of the blocks any dataset uses, counting each
+	// block once. profile photos aren't included
+	TotalBytes int64 `json:"totalBytes"`
+	// Collectable is false if the store can't be garbage collected, leaving
+	// UnreferencedBytes unknown
+	Collectable bool `json:"collectable"`
+	// UnreferencedBytes is the size of the blocks gc would remove
+	UnreferencedBytes int64 `json:"unreferencedBytes"`
+}
+
+// DiskUsage works out how much storage each dataset in a repo uses, from the
+// sizes of the blocks every version of the dataset references. Blocks used by
+// more than one dataset count toward each of them, and are reported as shared
+func DiskUsage(r repo.Repo) (*UsageReport, error) {
+	res, err := datasetUsage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	gc, err := CollectGarbage(r, true, false)
+	if err == ErrNotCollectable {
+		return res, nil
+	} else if err != nil {
+		return nil, err
+	}
+	res.Collectable = true
+	res.UnreferencedBytes = gc.BytesFreed
+	return res, nil
+}
+
+func datasetUsage(r repo.Repo) (*UsageReport, error) {
+	storeLock.RLock()
+	defer storeLock.RUnlock()
+
+	count, err := r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.References(count, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	store := r.Store()
+	heads := make([]map[string]bool, len(refs))
+	histories := make([]map[string]bool, len(refs))
+	// users counts the datasets that use each block
+	users := map[string]int{}
+	res := &UsageReport{Datasets: make([]*DatasetUsage, len(refs))}
+
+	for i, ref := range refs {
+		usage := &DatasetUsage{Ref: ref.AliasString(), Path: ref.Path}
+		histories[i] = map[string]bool{}
+		// visited guards against cycles in malformed history
+		visited := map[string]bool{}
+
+		for path := ref.Path; path != "" && path != "/" && !visited[path]; {
+			visited[path] = true
+			ds, err := dsfs.LoadDatasetRefs(store, datastore.NewKey(path))
+			if err != nil {
+				log.Debug(err.Error())
+				return nil, fmt.Errorf("error loading dataset %s: %s", path, err.Error())
+			}
+			version := map[string]bool{}
+			markDataset(version, path, ds)
+			if ds.Meta != nil {
+				markPath(version, readmePath(store, path))
+			}
+			blocks := map[string]bool{}
+			for p := range version {
+				blocks[rootPath(p)] = true
+			}
+			if heads[i] == nil {
+				heads[i] = blocks
+			}
+			for b := range blocks {
+				histories[i][b] = true
+			}
+			usage.Versions++
+			path = ds.PreviousPath
+		}
+
+		for b := range histories[i] {
+			users[b]++
+		}
+		res.Datasets[i] = usage
+	}
+
+	sizes := map[string]int64{}
+	for b := range users {
+		size, err := pathSize(store, datastore.NewKey(b))
+		if err != nil {
+			log.Debug(err.Error())
+			return nil, fmt.Errorf("error reading block %s: %s", b, err.Error())
+		}
+		sizes[b] = size
+		res.TotalBytes += size
+	}
+
+	for i, usage := range res.Datasets {
+		for b := range heads[i] {
+			usage.HeadBytes += sizes[b]
+		}
+		for b := range histories[i] {
+			usage.HistoryBytes += sizes[b]
+			if users[b] > 1 {
+				usage.SharedBytes += sizes[b]
+			}
+		}
+	}
+
+	sort.Slice(res.Datasets, func(i, j int) bool {
+		a, b := res.Datasets[i], res.Datasets[j]
+		if a.HistoryBytes == b.HistoryBytes {
+			return a.Ref < b.Ref
+		}
+		return a.HistoryBytes > b.HistoryBytes
+	})
+	return res, nil
+}
+
+// pathSize gives the size of the file at key, or the total size of every file
+// in a directory
+func pathSize(store cafs.Filestore, key datastore.Key) (int64, error) {
+	f, err := store.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return fileSize(f)
+}
+
+func fileSize(f cafs.File) (int64, error) {
+	if !f.IsDirectory() {
+		return io.Copy(ioutil.Discard, f)
+	}
+
+	var total int64
+	for {
+		child, err := f.NextFile()
+		if err == io.EOF {
+			return total, nil
+		} else if err != nil {
+			return 0, err
+		}
+		size, err := fileSize(child)
+		child.Close()
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+}
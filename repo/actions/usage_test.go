@@ -0,0 +1,97 @@
+package actions
+
+import (
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestDiskUsage(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+
+	r, _, head := createDatasetHistory(t, rmf)
+	if _, err := r.Store().Put(cafs.NewMemfileBytes("stray.json", []byte(`{"unreferenced":true}`)), false); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	res, err := DiskUsage(r)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Datasets) != 1 {
+		t.Fatalf("expected usage for 1 dataset, got: %d", len(res.Datasets))
+	}
+
+	ds := res.Datasets[0]
+	if ds.Ref != head.AliasString() || ds.Path != head.Path || ds.Versions != 2 {
+		t.Errorf("expected %s at %s with 2 versions, got: %#v", head.AliasString(), head.Path, ds)
+	}
+	if ds.HeadBytes <= 0 || ds.HistoryBytes <= ds.HeadBytes {
+		t.Errorf("expected history to be larger than head, got head: %d history: %d", ds.HeadBytes, ds.HistoryBytes)
+	}
+	if ds.SharedBytes != 0 {
+		t.Errorf("expected no shared bytes for a single dataset, got: %d", ds.SharedBytes)
+	}
+	if res.TotalBytes != ds.HistoryBytes {
+		t.Errorf("expected total to equal the only dataset's history. total: %d history: %d", res.TotalBytes, ds.HistoryBytes)
+	}
+
+	gc, err := CollectGarbage(r, true, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !res.Collectable || res.UnreferencedBytes != gc.BytesFreed || res.UnreferencedBytes <= 0 {
+		t.Errorf("expected unreferenced bytes to match gc dry run of %d bytes, got: %d", gc.BytesFreed, res.UnreferencedBytes)
+	}
+}
+
+func TestDiskUsageShared(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+
+	r, _ := createDataset(t, rmf)
+
+	// a second dataset with the same data shares the data block
+	tc, err := dstest.NewTestCaseFromDir(testdataPath("cities"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tc.Input.Meta = &dataset.Meta{Title: "more cities"}
+	if _, err := (Dataset{r}).CreateDataset("more_cities", tc.Input, tc.DataFile(), true); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	res, err := DiskUsage(r)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(res.Datasets) != 2 {
+		t.Fatalf("expected usage for 2 datasets, got: %d", len(res.Datasets))
+	}
+	a, b := res.Datasets[0], res.Datasets[1]
+	for _, ds := range res.Datasets {
+		if ds.SharedBytes <= 0 || ds.SharedBytes >= ds.HistoryBytes {
+			t.Errorf("%s: expected the data block to be shared, got shared: %d history: %d", ds.Ref, ds.SharedBytes, ds.HistoryBytes)
+		}
+	}
+	if res.TotalBytes != a.HistoryBytes+b.HistoryBytes-a.SharedBytes {
+		t.Errorf("expected shared blocks to be counted once in total. total: %d, datasets: %#v %#v", res.TotalBytes, a, b)
+	}
+}
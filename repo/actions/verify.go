@@ -0,0 +1,128 @@
+package actions
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/mr-tron/base58/base58"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+var (
+	// ErrUnsigned is returned when verifying a commit that carries no signature
+	ErrUnsigned = fmt.Errorf("commit is not signed")
+	// ErrBadSignature is returned when a commit signature doesn't match the
+	// public key it's checked against
+	ErrBadSignature = fmt.Errorf("commit signature is invalid")
+	// ErrUnknownKey is returned when the public key of a dataset's author
+	// can't be found
+	ErrUnknownKey = fmt.Errorf("author public key is unknown")
+)
+
+// Verifier checks a signature against data. It's the subset of a libp2p
+// public key needed for verification, which lets keys from both the config
+// & profile packages satisfy it
+type Verifier interface {
+	Verify(data, sig []byte) (bool, error)
+}
+
+// VerifyCommit checks a commit's signature against the public key of the
+// profile that claims to have authored it
+func VerifyCommit(pub Verifier, cm *dataset.Commit) error {
+	if cm == nil || cm.Signature == "" {
+		return ErrUnsigned
+	}
+
+	sig, err := base58.Decode(cm.Signature)
+	if err != nil {
+		log.Debug(err.Error())
+		return ErrBadSignature
+	}
+
+	ok, err := pub.Verify(cm.SignableBytes(), sig)
+	if err != nil {
+		log.Debug(err.Error())
+		return ErrBadSignature
+	}
+	if !ok {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// PublicKey finds the public key for a profile ID. This repo's own key is
+// always known, other profiles must have shared their key with us
+func PublicKey(r repo.Repo, id profile.ID) (Verifier, error) {
+	if id == "" {
+		return nil, ErrUnknownKey
+	}
+
+	if pro, err := r.Profile(); err == nil && pro.ID == id {
+		if pk := r.PrivateKey(); pk != nil {
+			return pk.GetPublic(), nil
+		}
+	}
+
+	pro, err := r.Profiles().GetProfile(id)
+	if err != nil {
+		return nil, ErrUnknownKey
+	}
+	pub, err := pro.PublicKey()
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, ErrUnknownKey
+	}
+	return pub, nil
+}
+
+// VerifyDataset checks the commit signature of a referenced dataset against
+// the public key of the profile the reference claims as it's author, setting
+// ref.Verification to the result. The dataset is loaded from the store if
+// ref.Dataset is nil
+func VerifyDataset(r repo.Repo, ref *repo.DatasetRef) (repo.SignatureStatus, error) {
+	ds := ref.Dataset
+	if ds == nil {
+		var err error
+		if ds, err = dsfs.LoadDataset(r.Store(), datastore.NewKey(ref.Path)); err != nil {
+			return repo.SigUnchecked, err
+		}
+	}
+
+	pub, err := PublicKey(r, ref.ProfileID)
+	if err != nil {
+		if ds.Commit == nil || ds.Commit.Signature == "" {
+			ref.Verification = repo.SigUnsigned
+		} else {
+			ref.Verification = repo.SigUnknownKey
+		}
+		return ref.Verification, nil
+	}
+
+	switch VerifyCommit(pub, ds.Commit) {
+	case nil:
+		ref.Verification = repo.SigValid
+	case ErrUnsigned:
+		ref.Verification = repo.SigUnsigned
+	default:
+		ref.Verification = repo.SigInvalid
+	}
+	return ref.Verification, nil
+}
+
+// CheckSignature returns an error describing why a verified reference
+// should be refused when signatures are required, nil if it's acceptable
+func CheckSignature(ref repo.DatasetRef) error {
+	switch ref.Verification {
+	case repo.SigValid:
+		return nil
+	case repo.SigUnsigned:
+		return fmt.Errorf("%s: %s", ref, ErrUnsigned)
+	case repo.SigUnknownKey:
+		return fmt.Errorf("%s: %s", ref, ErrUnknownKey)
+	default:
+		return fmt.Errorf("%s: %s", ref, ErrBadSignature)
+	}
+}
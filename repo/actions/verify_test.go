@@ -0,0 +1,84 @@
+package actions
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestVerifyDataset(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		mr, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			panic(err)
+		}
+		mr.SetPrivateKey(privKey)
+		return mr
+	}
+
+	r, ref := createDataset(t, rmf)
+	if err := (Dataset{r}).ReadDataset(&ref); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	withCommit := func(ref repo.DatasetRef, sig string) repo.DatasetRef {
+		ds := &dataset.Dataset{}
+		ds.Assign(ref.Dataset)
+		ds.Commit = &dataset.Commit{}
+		ds.Commit.Assign(ref.Dataset.Commit)
+		ds.Commit.Signature = sig
+		ref.Dataset = ds
+		return ref
+	}
+
+	stranger := ref
+	stranger.ProfileID = "QmTwtwLMKHHKCrugNxyAaZ31nhBqRUQVysT2xK911n4m6F"
+
+	cases := []struct {
+		ref    repo.DatasetRef
+		expect repo.SignatureStatus
+	}{
+		{ref, repo.SigValid},
+		{withCommit(ref, ""), repo.SigUnsigned},
+		{withCommit(ref, "bad"), repo.SigInvalid},
+		{stranger, repo.SigUnknownKey},
+		{withCommit(stranger, ""), repo.SigUnsigned},
+	}
+
+	for i, c := range cases {
+		got, err := VerifyDataset(r, &c.ref)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("case %d status mismatch. expected: '%s', got: '%s'", i, c.expect, got)
+		}
+		if c.ref.Verification != got {
+			t.Errorf("case %d expected ref verification to be set", i)
+		}
+		if err := CheckSignature(c.ref); (err == nil) != (c.expect == repo.SigValid) {
+			t.Errorf("case %d check signature mismatch: %v", i, err)
+		}
+	}
+
+	// once a stranger's profile (with it's public key) is known, their
+	// signatures can be checked
+	data, err := privKey.GetPublic().Bytes()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pro := &profile.Profile{ID: ref.ProfileID, Peername: "peer", PubKey: base64.StdEncoding.EncodeToString(data)}
+	if _, err := pro.PublicKey(); err != nil {
+		t.Fatalf("unexpected error decoding public key: %s", err.Error())
+	}
+
+	pro.ID = stranger.ProfileID
+	if _, err := pro.PublicKey(); err == nil {
+		t.Error("expected public key that doesn't match profile ID to error")
+	}
+}
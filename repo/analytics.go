@@ -0,0 +1,134 @@
+package repo
+
+import (
+	"sort"
+	"time"
+)
+
+// UsageDayFormat is the layout of the UTC day usage is counted under
+const UsageDayFormat = "2006-01-02"
+
+// UsageKind is a way a dataset can be used
+type UsageKind string
+
+const (
+	// UsageServed is a dataset sent to a peer that asked for it
+	UsageServed = UsageKind("served")
+	// UsageListed is a dataset included in a list of datasets sent to a peer
+	UsageListed = UsageKind("listed")
+	// UsageRead is dataset data read through the api
+	UsageRead = UsageKind("read")
+	// UsageExported is a dataset exported from the repo
+	UsageExported = UsageKind("exported")
+)
+
+// UsageCounts tallies the ways a dataset was used
+type UsageCounts struct {
+	Served   int `json:"served"`
+	Listed   int `json:"listed"`
+	Read     int `json:"read"`
+	Exported int `json:"exported"`
+}
+
+// Add adds n to the count for kind
+func (c *UsageCounts) Add(kind UsageKind, n int) {
+	switch kind {
+	case UsageServed:
+		c.Served += n
+	case UsageListed:
+		c.Listed += n
+	case UsageRead:
+		c.Read += n
+	case UsageExported:
+		c.Exported += n
+	}
+}
+
+// Merge adds every count in o to c
+func (c *UsageCounts) Merge(o UsageCounts) {
+	c.Served += o.Served
+	c.Listed += o.Listed
+	c.Read += o.Read
+	c.Exported += o.Exported
+}
+
+// Total sums all counts
+func (c UsageCounts) Total() int {
+	return c.Served + c.Listed + c.Read + c.Exported
+}
+
+// DailyUsage is how a dataset was used on a single day
+type DailyUsage struct {
+	// Day is the UTC day, formatted with UsageDayFormat
+	Day string `json:"day"`
+	// Ref is the dataset alias, eg: peer/cities
+	Ref string `json:"ref"`
+	UsageCounts
+}
+
+// Analytics keeps counts of how a repo's datasets are used, bucketed by day.
+// Datasets are identified by alias, so counts hold across versions. Only
+// counts are kept, never who used a dataset
+type Analytics interface {
+	// CountUsage adds one to the count of kind for each ref, on the day t
+	// falls on
+	CountUsage(kind UsageKind, t time.Time, refs ...DatasetRef) error
+	// Usage lists daily usage from the day since falls on, oldest first
+	Usage(since time.Time) ([]*DailyUsage, error)
+}
+
+// MemAnalytics is an in-memory implementation of the Analytics interface,
+// mapping days to dataset aliases to counts. it isn't safe for concurrent use
+type MemAnalytics map[string]map[string]*UsageCounts
+
+// CountUsage implements the Analytics interface
+func (m *MemAnalytics) CountUsage(kind UsageKind, t time.Time, refs ...DatasetRef) error {
+	for _, ref := range refs {
+		if ref.Peername == "" {
+			return ErrPeernameRequired
+		} else if ref.Name == "" {
+			return ErrNameRequired
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	if *m == nil {
+		*m = MemAnalytics{}
+	}
+
+	day := t.UTC().Format(UsageDayFormat)
+	if (*m)[day] == nil {
+		(*m)[day] = map[string]*UsageCounts{}
+	}
+	for _, ref := range refs {
+		alias := ref.AliasString()
+		if (*m)[day][alias] == nil {
+			(*m)[day][alias] = &UsageCounts{}
+		}
+		(*m)[day][alias].Add(kind, 1)
+	}
+	return nil
+}
+
+// Usage implements the Analytics interface
+func (m *MemAnalytics) Usage(since time.Time) ([]*DailyUsage, error) {
+	from := since.UTC().Format(UsageDayFormat)
+	usage := []*DailyUsage{}
+	for day, refs := range *m {
+		// days sort lexically
+		if day < from {
+			continue
+		}
+		for alias, counts := range refs {
+			usage = append(usage, &DailyUsage{Day: day, Ref: alias, UsageCounts: *counts})
+		}
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Day == usage[j].Day {
+			return usage[i].Ref < usage[j].Ref
+		}
+		return usage[i].Day < usage[j].Day
+	})
+	return usage, nil
+}
@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// ErrChangeRequestExists is returned when proposing a version that already
+// has a change request
+var ErrChangeRequestExists = fmt.Errorf("repo: a change request for this version already exists")
+
+// ChangeRequestStatus is the state of a change request
+type ChangeRequestStatus string
+
+const (
+	// CRStatusOpen is a change request waiting for the owner to review it
+	CRStatusOpen = ChangeRequestStatus("open")
+	// CRStatusAccepted is a change request the owner has committed to their
+	// dataset
+	CRStatusAccepted = ChangeRequestStatus("accepted")
+	// CRStatusRejected is a change request the owner has turned down
+	CRStatusRejected = ChangeRequestStatus("rejected")
+)
+
+// ChangeRequest proposes a new version of a dataset to it's owner, much like
+// a pull request. The same change request is kept by both peers: the owner
+// has it as incoming, the peer that proposed it as outgoing
+type ChangeRequest struct {
+	// ID identifies the change request. It's the path of the proposed version
+	ID string `json:"id"`
+	// Target is the dataset the change is proposed for, with the path of it's
+	// head when the change was proposed
+	Target DatasetRef `json:"target"`
+	// From is the profile ID of the peer that proposed the change
+	From profile.ID `json:"from"`
+	// FromPeername is the peername of the peer that proposed the change
+	FromPeername string `json:"fromPeername"`
+	// Title briefly describes the change
+	Title string `json:"title"`
+	// Incoming is true for change requests proposed to this repo
+	Incoming bool `json:"incoming"`
+	// Status is one of open, accepted or rejected
+	Status ChangeRequestStatus `json:"status"`
+	// Comment is the owner's reason for rejecting or accepting the change
+	Comment string `json:"comment,omitempty"`
+	// Commit is the path of the version created when the change was accepted
+	Commit string `json:"commit,omitempty"`
+	// Created is when the change was proposed
+	Created time.Time `json:"created"`
+	// Updated is when the change was last reviewed
+	Updated time.Time `json:"updated"`
+}
+
+// ChangeRequestStore keeps change requests made to & from a repo
+type ChangeRequestStore interface {
+	// PutChangeRequest adds a change request, replacing any with the same ID
+	PutChangeRequest(cr *ChangeRequest) error
+	// GetChangeRequest gets a change request by ID, returning ErrNotFound if
+	// none exists
+	GetChangeRequest(id string) (*ChangeRequest, error)
+	// ListChangeRequests lists change requests, newest first
+	ListChangeRequests(limit, offset int) ([]*ChangeRequest, error)
+	// DeleteChangeRequest removes a change request
+	DeleteChangeRequest(id string) error
+}
+
+// MemChangeRequests is an in-memory implementation of the ChangeRequestStore
+// interface, mapping IDs to change requests. it isn't safe for concurrent use
+type MemChangeRequests map[string]*ChangeRequest
+
+// PutChangeRequest implements the ChangeRequestStore interface
+func (m *MemChangeRequests) PutChangeRequest(cr *ChangeRequest) error {
+	if cr.ID == "" {
+		return ErrPathRequired
+	}
+	if *m == nil {
+		*m = MemChangeRequests{}
+	}
+	c := *cr
+	(*m)[cr.ID] = &c
+	return nil
+}
+
+// GetChangeRequest implements the ChangeRequestStore interface
+func (m *MemChangeRequests) GetChangeRequest(id string) (*ChangeRequest, error) {
+	cr, ok := (*m)[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *cr
+	return &c, nil
+}
+
+// ListChangeRequests implements the ChangeRequestStore interface
+func (m *MemChangeRequests) ListChangeRequests(limit, offset int) ([]*ChangeRequest, error) {
+	crs := make([]*ChangeRequest, 0, len(*m))
+	for _, cr := range *m {
+		c := *cr
+		crs = append(crs, &c)
+	}
+	sort.Slice(crs, func(i, j int) bool {
+		if crs[i].Created.Equal(crs[j].Created) {
+			return crs[i].ID < crs[j].ID
+		}
+		return crs[i].Created.After(crs[j].Created)
+	})
+
+	if offset >= len(crs) {
+		return []*ChangeRequest{}, nil
+	}
+	crs = crs[offset:]
+	if limit >= 0 && limit < len(crs) {
+		crs = crs[:limit]
+	}
+	return crs, nil
+}
+
+// DeleteChangeRequest implements the ChangeRequestStore interface
+func (m *MemChangeRequests) DeleteChangeRequest(id string) error {
+	if _, ok := (*m)[id]; !ok {
+		return ErrNotFound
+	}
+	delete(*m, id)
+	return nil
+}
@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"sync"
+)
+
+// EventSubscriber delivers events to subscribers as they're logged
+type EventSubscriber interface {
+	// Subscribe starts delivering events that pass a filter. Callers must
+	// Close the subscription when they're done with it
+	Subscribe(f EventFilter) *Subscription
+}
+
+// EventFilter selects the events a subscription receives. The zero value
+// matches every event
+type EventFilter struct {
+	// Types limits events to a set of types
+	Types []EventType
+	// Ref limits events to those about a dataset, matched by path or by
+	// peername/profileID & name
+	Ref *DatasetRef
+}
+
+// Match returns true if an event passes the filter
+func (f EventFilter) Match(e *Event) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == e.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.Ref != nil {
+		if f.Ref.Path != "" && f.Ref.Path == e.Ref.Path {
+			return true
+		}
+		if f.Ref.Name == "" || f.Ref.Name != e.Ref.Name {
+			return false
+		}
+		if f.Ref.Peername != "" && f.Ref.Peername != e.Ref.Peername {
+			return false
+		}
+		if f.Ref.ProfileID != "" && f.Ref.ProfileID != e.Ref.ProfileID {
+			return false
+		}
+	}
+	return true
+}
+
+// SubscriptionBufferSize is the number of events a subscription holds for a
+// slow reader before it starts dropping events
+var SubscriptionBufferSize = 64
+
+// EventBus is an implementation of the EventSubscriber interface. Repos embed
+// an EventBus and Publish events as they're logged. Publishing never blocks,
+// subscribers that fall SubscriptionBufferSize events behind miss events
+type EventBus struct {
+	lk   sync.Mutex
+	subs map[*Subscription]bool
+}
+
+// NewEventBus allocates an EventBus
+func NewEventBus() *EventBus {
+	return &EventBus{subs: map[*Subscription]bool{}}
+}
+
+// Subscribe implements the EventSubscriber interface
+func (b *EventBus) Subscribe(f EventFilter) *Subscription {
+	s := &Subscription{
+		filter: f,
+		events: make(chan *Event, SubscriptionBufferSize),
+		bus:    b,
+	}
+
+	b.lk.Lock()
+	b.subs[s] = true
+	b.lk.Unlock()
+	return s
+}
+
+// Publish delivers an event to every subscription who's filter it passes
+func (b *EventBus) Publish(e *Event) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			// subscriber is behind, drop the event
+		}
+	}
+}
+
+// Subscription is a stream of events from an EventBus
+type Subscription struct {
+	filter EventFilter
+	events chan *Event
+	bus    *EventBus
+	once   sync.Once
+}
+
+// Events gives the channel events are delivered on. The channel is closed
+// when the subscription is
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+// Close ends the subscription. It's safe to call Close more than once
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.lk.Lock()
+		delete(s.bus.subs, s)
+		s.bus.lk.Unlock()
+		close(s.events)
+	})
+}
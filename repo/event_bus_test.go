@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	b := NewEventBus()
+	all := b.Subscribe(EventFilter{})
+	renames := b.Subscribe(EventFilter{Types: []EventType{ETDsRenamed}, Ref: &DatasetRef{Peername: "peer", Name: "movies"}})
+
+	events := []*Event{
+		{Type: ETDsCreated, Ref: DatasetRef{Peername: "peer", Name: "movies"}},
+		{Type: ETDsRenamed, Ref: DatasetRef{Peername: "peer", Name: "cities"}},
+		{Type: ETDsRenamed, Ref: DatasetRef{Peername: "peer", Name: "movies"}},
+	}
+	for _, e := range events {
+		b.Publish(e)
+	}
+
+	if got := len(all.Events()); got != 3 {
+		t.Errorf("expected unfiltered subscription to receive 3 events, got: %d", got)
+	}
+	if got := len(renames.Events()); got != 1 {
+		t.Fatalf("expected filtered subscription to receive 1 event, got: %d", got)
+	}
+	if e := <-renames.Events(); e != events[2] {
+		t.Errorf("filtered event mismatch, got: %v", e)
+	}
+
+	// closing more than once is fine
+	all.Close()
+	all.Close()
+	if len(b.subs) != 1 {
+		t.Errorf("expected closed subscription to be removed, have %d subscriptions", len(b.subs))
+	}
+
+	// publishing to a full subscription must not block
+	for i := 0; i < SubscriptionBufferSize+1; i++ {
+		b.Publish(events[2])
+	}
+	if got := len(renames.Events()); got != SubscriptionBufferSize {
+		t.Errorf("expected a full subscription buffer, got: %d", got)
+	}
+}
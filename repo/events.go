@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"fmt"
 	"sort"
 	"time"
 )
@@ -35,8 +36,40 @@ const (
 	ETDsUnpinned = EventType("ds_unpinned")
 	// ETDsAdded represents adding a reference to another peer's dataset to their node
 	ETDsAdded = EventType("ds_added")
+	// ETDsReset represents moving a dataset's head back to an earlier version in it's history
+	ETDsReset = EventType("ds_reset")
+	// ETDsStale represents a derived dataset falling behind a dataset it was derived from
+	ETDsStale = EventType("ds_stale")
+	// ETDsRerun represents re-running a derived dataset's transform after an upstream change
+	ETDsRerun = EventType("ds_rerun")
+	// ETDsConverted represents copying a dataset into a different content store, giving it a new path
+	ETDsConverted = EventType("ds_converted")
 )
 
+// EventTypes lists every type of event that can be logged
+var EventTypes = []EventType{
+	ETDsCreated,
+	ETDsDeleted,
+	ETDsRenamed,
+	ETDsPinned,
+	ETDsUnpinned,
+	ETDsAdded,
+	ETDsReset,
+	ETDsStale,
+	ETDsRerun,
+	ETDsConverted,
+}
+
+// ParseEventType checks a string names a known event type
+func ParseEventType(s string) (EventType, error) {
+	for _, t := range EventTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown event type: '%s'", s)
+}
+
 // MemEventLog is an in-memory implementation of the
 // EventLog interface
 type MemEventLog []*Event
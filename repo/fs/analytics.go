@@ -0,0 +1,63 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// analyticsLock serializes read-modify-write cycles on analytics files
+var analyticsLock sync.Mutex
+
+// Analytics is a file-based implementation of the repo.Analytics interface
+type Analytics struct {
+	basepath
+	file File
+}
+
+// CountUsage implements the repo.Analytics interface
+func (a Analytics) CountUsage(kind repo.UsageKind, t time.Time, refs ...repo.DatasetRef) error {
+	analyticsLock.Lock()
+	defer analyticsLock.Unlock()
+
+	usage, err := a.entries()
+	if err != nil {
+		return err
+	}
+	if err := usage.CountUsage(kind, t, refs...); err != nil {
+		return err
+	}
+	return a.saveFile(usage, a.file)
+}
+
+// Usage implements the repo.Analytics interface
+func (a Analytics) Usage(since time.Time) ([]*repo.DailyUsage, error) {
+	usage, err := a.entries()
+	if err != nil {
+		return nil, err
+	}
+	return usage.Usage(since)
+}
+
+func (a Analytics) entries() (repo.MemAnalytics, error) {
+	usage := repo.MemAnalytics{}
+	data, err := ioutil.ReadFile(a.filepath(a.file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return usage, nil
+		}
+		log.Debug(err.Error())
+		return usage, fmt.Errorf("error loading analytics: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &usage); err != nil {
+		log.Debug(err.Error())
+		return usage, fmt.Errorf("error unmarshaling analytics: %s", err.Error())
+	}
+	return usage, nil
+}
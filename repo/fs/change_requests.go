@@ -0,0 +1,86 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// changeRequestsLock serializes read-modify-write cycles on change request
+// files
+var changeRequestsLock sync.Mutex
+
+// ChangeRequests is a file-based implementation of the
+// repo.ChangeRequestStore interface
+type ChangeRequests struct {
+	basepath
+	file File
+}
+
+// PutChangeRequest implements the repo.ChangeRequestStore interface
+func (c ChangeRequests) PutChangeRequest(cr *repo.ChangeRequest) error {
+	return c.update(func(crs *repo.MemChangeRequests) error {
+		return crs.PutChangeRequest(cr)
+	})
+}
+
+// GetChangeRequest implements the repo.ChangeRequestStore interface
+func (c ChangeRequests) GetChangeRequest(id string) (*repo.ChangeRequest, error) {
+	crs, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	return crs.GetChangeRequest(id)
+}
+
+// ListChangeRequests implements the repo.ChangeRequestStore interface
+func (c ChangeRequests) ListChangeRequests(limit, offset int) ([]*repo.ChangeRequest, error) {
+	crs, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	return crs.ListChangeRequests(limit, offset)
+}
+
+// DeleteChangeRequest implements the repo.ChangeRequestStore interface
+func (c ChangeRequests) DeleteChangeRequest(id string) error {
+	return c.update(func(crs *repo.MemChangeRequests) error {
+		return crs.DeleteChangeRequest(id)
+	})
+}
+
+func (c ChangeRequests) update(fn func(crs *repo.MemChangeRequests) error) error {
+	changeRequestsLock.Lock()
+	defer changeRequestsLock.Unlock()
+
+	crs, err := c.entries()
+	if err != nil {
+		return err
+	}
+	if err := fn(&crs); err != nil {
+		return err
+	}
+	return c.saveFile(crs, c.file)
+}
+
+func (c ChangeRequests) entries() (repo.MemChangeRequests, error) {
+	crs := repo.MemChangeRequests{}
+	data, err := ioutil.ReadFile(c.filepath(c.file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return crs, nil
+		}
+		log.Debug(err.Error())
+		return crs, fmt.Errorf("error loading change requests: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &crs); err != nil {
+		log.Debug(err.Error())
+		return crs, fmt.Errorf("error unmarshaling change requests: %s", err.Error())
+	}
+	return crs, nil
+}
@@ -1,32 +1,97 @@
 package fsrepo
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/ipfs/go-datastore"
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/qri/repo"
 )
 
-// EventLog is a file-based implementation of the repo.EventLog interface
+var (
+	// eventsLock serializes access to event log segments & their manifest
+	eventsLock sync.Mutex
+	// headCache holds the decoded events of each log's newest segment, keyed
+	// by log directory, so every read & write doesn't decode it again. guarded
+	// by eventsLock
+	headCache = map[string]*cachedSegment{}
+)
+
+// cachedSegment holds the decoded events of a segment file, valid while the
+// file's size & modification time are unchanged. other processes append to
+// segments, so the file is checked each time the cache is used
+type cachedSegment struct {
+	path   string
+	size   int64
+	mod    time.Time
+	events []*repo.Event
+}
+
+// SegmentSize is the number of events written to a segment before the log
+// rotates to a new one
+var SegmentSize = 500
+
+// EventRetention configures how much history file-based event logs keep
+var EventRetention Retention
+
+// Retention limits the history kept in the live event log. Whole segments
+// that fall outside the limits are archived when the log rotates. Zero values
+// mean no limit
+type Retention struct {
+	// MaxAge archives segments who's newest event is older than MaxAge
+	MaxAge time.Duration
+	// MaxCount archives the oldest segments while the log holds at least
+	// MaxCount newer events
+	MaxCount int
+}
+
+// EventLog is a file-based implementation of the repo.EventLog interface.
+// Events are appended to a directory of newline-delimited json segment files,
+// oldest first. A manifest records the time span & number of events in each
+// segment, so reads only open the segments they need
 type EventLog struct {
 	basepath
-	file  File
-	store cafs.Filestore
+	file      File
+	store     cafs.Filestore
+	retention Retention
+	// optional bus to publish logged events on
+	bus *repo.EventBus
+}
+
+// segment describes a single file of events
+type segment struct {
+	ID    int       `json:"id"`
+	Count int       `json:"count"`
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+	// Archived segments have been moved out of the live log
+	Archived bool `json:"archived,omitempty"`
+	// Path is the location of an archived segment in the store, if any
+	Path string `json:"path,omitempty"`
 }
 
-// NewEventLog allocates a new file-based EventLog instance
+// NewEventLog allocates a new file-based EventLog instance. file is the
+// directory segments are written to
 func NewEventLog(base string, file File, store cafs.Filestore) EventLog {
-	return EventLog{basepath: basepath(base), file: file, store: store}
+	return EventLog{basepath: basepath(base), file: file, store: store, retention: EventRetention}
 }
 
 // LogEvent adds a Event to the store
 func (ql EventLog) LogEvent(t repo.EventType, ref repo.DatasetRef) error {
-	log, err := ql.logs()
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	segs, err := ql.segments()
 	if err != nil {
 		return err
 	}
@@ -36,60 +101,542 @@ func (ql EventLog) LogEvent(t repo.EventType, ref repo.DatasetRef) error {
 		Type: t,
 		Ref:  ref,
 	}
-	log = append([]*repo.Event{e}, log...)
-	sort.Slice(log, func(i, j int) bool { return log[i].Time.After(log[j].Time) })
-	return ql.saveFile(log, ql.file)
+
+	if len(segs) == 0 || segs[len(segs)-1].Archived || segs[len(segs)-1].Count >= SegmentSize {
+		id := 1
+		if len(segs) > 0 {
+			id = segs[len(segs)-1].ID + 1
+		}
+		segs = append(segs, &segment{ID: id, First: e.Time})
+		if err := ql.compact(segs, e.Time); err != nil {
+			return err
+		}
+	}
+
+	head := segs[len(segs)-1]
+	if err := ql.appendEvents(head, []*repo.Event{e}); err != nil {
+		return err
+	}
+	if err := ql.saveSegments(segs); err != nil {
+		return err
+	}
+
+	if ql.bus != nil {
+		ql.bus.Publish(e)
+	}
+	return nil
 }
 
-// Events fetches a set of Events from the store
+// Events fetches a set of Events from the store, newest first
 func (ql EventLog) Events(limit, offset int) ([]*repo.Event, error) {
-	logs, err := ql.logs()
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	segs, err := ql.segments()
 	if err != nil {
 		return nil, err
 	}
 
-	if offset > len(logs) {
-		offset = len(logs)
-	}
-	stop := limit + offset
-	if stop > len(logs) {
-		stop = len(logs)
+	events := []*repo.Event{}
+	for i := len(segs) - 1; i >= 0 && len(events) < limit; i-- {
+		seg := segs[i]
+		if seg.Archived {
+			break
+		}
+		if offset >= seg.Count {
+			offset -= seg.Count
+			continue
+		}
+
+		es, err := ql.readSegment(seg)
+		if err != nil {
+			return nil, err
+		}
+		for j := len(es) - 1 - offset; j >= 0 && len(events) < limit; j-- {
+			events = append(events, es[j])
+		}
+		offset = 0
 	}
 
-	return logs[offset:stop], nil
+	return events, nil
 }
 
-// EventsSince fetches a set of Events from the store that occur after a given timestamp
+// EventsSince fetches a set of Events from the store that occur after a
+// given timestamp, oldest first
 func (ql EventLog) EventsSince(t time.Time) ([]*repo.Event, error) {
-	logs, err := ql.logs()
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	segs, err := ql.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*repo.Event{}
+	for _, seg := range segs {
+		if seg.Archived || !seg.Last.After(t) {
+			continue
+		}
+		es, err := ql.readSegment(seg)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range es {
+			if e.Time.After(t) {
+				events = append(events, e)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// ImportEvents adds events to the log, keeping their timestamps. Events are
+// merged into the live log in time order, and events the log already holds
+// are skipped. Imported events aren't published to subscribers
+func (ql EventLog) ImportEvents(events []*repo.Event) error {
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	segs, err := ql.segments()
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	merged := []*segment{}
+	live := []*segment{}
+	sorted := []*repo.Event{}
+	nextID := 1
+	for _, seg := range segs {
+		es, err := ql.loadSegment(seg)
+		if err != nil {
+			return err
+		}
+		for _, e := range es {
+			seen[eventKey(e)] = true
+		}
+		if seg.Archived {
+			merged = append(merged, seg)
+		} else {
+			live = append(live, seg)
+			sorted = append(sorted, es...)
+		}
+		nextID = seg.ID + 1
+	}
+
+	added := 0
+	for _, e := range events {
+		if key := eventKey(e); !seen[key] {
+			seen[key] = true
+			sorted = append(sorted, e)
+			added++
+		}
+	}
+	if added == 0 {
+		return nil
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	// write the merged log to new segments & swap the manifest over, so a
+	// failed import leaves the log as it was. imports are held to the same
+	// retention limits as logged events
+	written := []*segment{}
+	for i := 0; i < len(sorted); i += SegmentSize {
+		end := i + SegmentSize
+		if end > len(sorted) {
+			end = len(sorted)
+		}
+		seg := &segment{ID: nextID}
+		nextID++
+		written = append(written, seg)
+		if err = ql.appendEvents(seg, sorted[i:end]); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		segs = append(merged, written...)
+		if err = ql.compact(segs, time.Now()); err == nil {
+			err = ql.saveSegments(segs)
+		}
+	}
+	if err != nil {
+		for _, seg := range written {
+			os.Remove(ql.segmentPath(seg))
+		}
+		return err
+	}
+
+	for _, seg := range live {
+		os.Remove(ql.segmentPath(seg))
+	}
+	return nil
+}
+
+// eventKey identifies an event for de-duplication
+func eventKey(e *repo.Event) string {
+	return fmt.Sprintf("%d %s %s", e.Time.UnixNano(), e.Type, e.Ref.String())
+}
+
+// EventArchives lists the store paths of segments that have been archived
+// out of the live log, oldest first
+func (ql EventLog) EventArchives() ([]string, error) {
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	segs, err := ql.segments()
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]*repo.Event, 0, len(logs))
-	for _, e := range logs {
-		if e.Time.After(t) {
-			events = append([]*repo.Event{e}, events...)
+	paths := []string{}
+	for _, seg := range segs {
+		if seg.Path != "" {
+			paths = append(paths, seg.Path)
 		}
 	}
+	return paths, nil
+}
+
+// ArchivedEvents reads events archived out of the live log back from the
+// store, oldest first. Segments archived without a store have no events
+func (ql EventLog) ArchivedEvents() ([]*repo.Event, error) {
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	segs, err := ql.segments()
+	if err != nil {
+		return nil, err
+	}
 
+	events := []*repo.Event{}
+	for _, seg := range segs {
+		if !seg.Archived {
+			continue
+		}
+		es, err := ql.loadSegment(seg)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, es...)
+	}
 	return events, nil
 }
 
-func (ql *EventLog) logs() ([]*repo.Event, error) {
-	ds := []*repo.Event{}
-	data, err := ioutil.ReadFile(ql.filepath(ql.file))
+// compact archives segments that fall outside the log's retention limits.
+// the newest segment is never archived
+func (ql EventLog) compact(segs []*segment, now time.Time) error {
+	live := 0
+	for _, seg := range segs {
+		if !seg.Archived {
+			live += seg.Count
+		}
+	}
+
+	for _, seg := range segs[:len(segs)-1] {
+		if seg.Archived {
+			continue
+		}
+		expired := ql.retention.MaxAge > 0 && now.Sub(seg.Last) > ql.retention.MaxAge
+		surplus := ql.retention.MaxCount > 0 && live-seg.Count >= ql.retention.MaxCount
+		if !expired && !surplus {
+			break
+		}
+		if err := ql.archive(seg); err != nil {
+			return err
+		}
+		live -= seg.Count
+	}
+	return nil
+}
+
+// archive moves a segment out of the live log, writing it to the store if
+// the log has one
+func (ql EventLog) archive(seg *segment) error {
+	path := ql.segmentPath(seg)
+	if ql.store != nil {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("error reading event log segment: %s", err.Error())
+		}
+		key, err := ql.store.Put(cafs.NewMemfileBytes(filepath.Base(path), data), true)
+		if err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("error archiving event log segment: %s", err.Error())
+		}
+		seg.Path = key.String()
+	}
+
+	seg.Archived = true
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// appendEvents writes events to the end of a segment, creating the segment
+// file if it doesn't exist. The segment is cached as the log's newest
+func (ql EventLog) appendEvents(seg *segment, events []*repo.Event) error {
+	path := ql.segmentPath(seg)
+	var prior []*repo.Event
+	if c := ql.cached(path); c != nil {
+		prior = c.events
+	} else if _, err := os.Stat(path); os.IsNotExist(err) {
+		prior = []*repo.Event{}
+	}
+	// drop the cache until the write is done, so a failed write can't leave
+	// it describing a file it doesn't match
+	delete(headCache, ql.filepath(ql.file))
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.ModePerm)
+	if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error opening event log segment: %s", err.Error())
+	}
+
+	enc := json.NewEncoder(f)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			log.Debug(err.Error())
+			return fmt.Errorf("error writing event: %s", err.Error())
+		}
+		if seg.Count == 0 {
+			seg.First = e.Time
+		}
+		seg.Count++
+		seg.Last = e.Time
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if prior != nil {
+		ql.cache(path, append(prior[:len(prior):len(prior)], events...))
+	}
+	return nil
+}
+
+// cached gives the cached events of the segment file at path, if they're
+// cached & the file hasn't changed since
+func (ql EventLog) cached(path string) *cachedSegment {
+	dir := ql.filepath(ql.file)
+	c := headCache[dir]
+	if c == nil || c.path != path {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() != c.size || !fi.ModTime().Equal(c.mod) {
+		delete(headCache, dir)
+		return nil
+	}
+	return c
+}
+
+// cache records the decoded events of the segment file at path, replacing
+// any other segment cached for the log
+func (ql EventLog) cache(path string, events []*repo.Event) {
+	dir := ql.filepath(ql.file)
+	fi, err := os.Stat(path)
+	if err != nil {
+		delete(headCache, dir)
+		return
+	}
+	headCache[dir] = &cachedSegment{path: path, size: fi.Size(), mod: fi.ModTime(), events: events}
+}
+
+// readSegment loads the events in a segment, oldest first
+func (ql EventLog) readSegment(seg *segment) ([]*repo.Event, error) {
+	path := ql.segmentPath(seg)
+	if c := ql.cached(path); c != nil {
+		return append([]*repo.Event{}, c.events...), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*repo.Event{}, nil
+		}
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error loading logs: %s", err.Error())
+	}
+	defer f.Close()
+	return decodeEvents(f, seg.Count)
+}
+
+// loadSegment loads the events in a segment, reading archived segments back
+// from the store. Segments archived without a store have no events
+func (ql EventLog) loadSegment(seg *segment) ([]*repo.Event, error) {
+	if !seg.Archived {
+		return ql.readSegment(seg)
+	}
+	if seg.Path == "" || ql.store == nil {
+		return []*repo.Event{}, nil
+	}
+
+	f, err := ql.store.Get(datastore.NewKey(seg.Path))
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error loading archived logs: %s", err.Error())
+	}
+	defer f.Close()
+	return decodeEvents(f, seg.Count)
+}
+
+func decodeEvents(r io.Reader, count int) ([]*repo.Event, error) {
+	events := make([]*repo.Event, 0, count)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		e := &repo.Event{}
+		if err := json.Unmarshal(sc.Bytes(), e); err != nil {
+			log.Debug(err.Error())
+			return nil, fmt.Errorf("error unmarshaling logs: %s", err.Error())
+		}
+		events = append(events, e)
+	}
+	return events, sc.Err()
+}
+
+func (ql EventLog) segmentPath(seg *segment) string {
+	return filepath.Join(ql.filepath(ql.file), fmt.Sprintf("%08d.jsonl", seg.ID))
+}
+
+func (ql EventLog) manifestPath() string {
+	return filepath.Join(ql.filepath(ql.file), "segments.json")
+}
+
+// segments reads the segment manifest, oldest first. logs written by older
+// versions of qri are migrated into segments the first time they're read
+func (ql EventLog) segments() ([]*segment, error) {
+	segs := []*segment{}
+	data, err := ioutil.ReadFile(ql.manifestPath())
 	if err != nil {
 		if os.IsNotExist(err) {
-			return ds, nil
+			return ql.migrate()
+		}
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error loading logs: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &segs); err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error unmarshaling logs: %s", err.Error())
+	}
+	if err := ql.syncHead(segs); err != nil {
+		return nil, err
+	}
+	return segs, nil
+}
+
+// syncHead brings the manifest entry for the newest live segment in line
+// with it's file. LogEvent appends to a segment before saving the manifest,
+// so a crash between the two leaves the manifest behind. The segment file is
+// the source of truth, and a partially written final event is dropped
+func (ql EventLog) syncHead(segs []*segment) error {
+	if len(segs) == 0 || segs[len(segs)-1].Archived {
+		return nil
+	}
+	head := segs[len(segs)-1]
+
+	path := ql.segmentPath(head)
+	if c := ql.cached(path); c != nil {
+		setSpan(head, c.events)
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		head.Count = 0
+		return nil
+	} else if err != nil {
+		log.Debug(err.Error())
+		return fmt.Errorf("error loading logs: %s", err.Error())
+	}
+
+	end := bytes.LastIndexByte(data, '\n') + 1
+	if end < len(data) {
+		if err := os.Truncate(path, int64(end)); err != nil {
+			log.Debug(err.Error())
+			return fmt.Errorf("error repairing event log segment: %s", err.Error())
 		}
+	}
+
+	events, err := decodeEvents(bytes.NewReader(data[:end]), head.Count)
+	if err != nil {
+		return err
+	}
+	ql.cache(path, events)
+	setSpan(head, events)
+	return nil
+}
+
+// setSpan sets a segment's count & time span from it's events
+func setSpan(seg *segment, events []*repo.Event) {
+	seg.Count = len(events)
+	if len(events) > 0 {
+		seg.First = events[0].Time
+		seg.Last = events[len(events)-1].Time
+	}
+}
+
+func (ql EventLog) saveSegments(segs []*segment) error {
+	data, err := json.Marshal(segs)
+	if err != nil {
+		log.Debug(err.Error())
+		return err
+	}
+	// write to a temp file & rename so readers never see a partial manifest
+	path := ql.manifestPath()
+	if err := ioutil.WriteFile(path+".tmp", data, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// migrate converts a json event log file into segments, renaming the json
+// file when it's done
+func (ql EventLog) migrate() ([]*segment, error) {
+	segs := []*segment{}
+	if err := os.MkdirAll(ql.filepath(ql.file), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	path := ql.filepath(FileEventLogs)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return segs, nil
+	} else if err != nil {
 		log.Debug(err.Error())
-		return ds, fmt.Errorf("error loading logs: %s", err.Error())
+		return nil, fmt.Errorf("error loading logs: %s", err.Error())
 	}
 
-	if err := json.Unmarshal(data, &ds); err != nil {
+	events := []*repo.Event{}
+	if err := json.Unmarshal(data, &events); err != nil {
 		log.Debug(err.Error())
-		return ds, fmt.Errorf("error unmarshaling logs: %s", err.Error())
+		return nil, fmt.Errorf("error unmarshaling logs: %s", err.Error())
+	}
+
+	// json logs are stored newest first
+	for i := len(events) - 1; i >= 0; i -= SegmentSize {
+		start := i - SegmentSize + 1
+		if start < 0 {
+			start = 0
+		}
+		chunk := make([]*repo.Event, 0, i-start+1)
+		for j := i; j >= start; j-- {
+			chunk = append(chunk, events[j])
+		}
+
+		seg := &segment{ID: len(segs) + 1}
+		if err := ql.appendEvents(seg, chunk); err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+	}
+
+	if err := ql.saveSegments(segs); err != nil {
+		return nil, err
 	}
-	return ds, nil
+	log.Infof("migrated %d events to %s", len(events), ql.filepath(ql.file))
+	return segs, os.Rename(path, path+".migrated")
 }
@@ -0,0 +1,232 @@
+package fsrepo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestEventLog(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_event_log_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	prevSize := SegmentSize
+	SegmentSize = 3
+	defer func() { SegmentSize = prevSize }()
+
+	// write a json log the way earlier versions did, newest first
+	start := time.Now().Add(-time.Hour)
+	prev := []*repo.Event{}
+	for i, name := range []string{"a", "b", "c", "d"} {
+		e := &repo.Event{Time: start.Add(time.Duration(i) * time.Minute), Type: repo.ETDsCreated, Ref: repo.DatasetRef{Name: name}}
+		prev = append([]*repo.Event{e}, prev...)
+	}
+	if err := basepath(path).saveFile(prev, FileEventLogs); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	store := cafs.NewMapstore()
+	l := NewEventLog(path, FileEventSegments, store)
+	l.retention = Retention{MaxCount: 3}
+
+	for _, name := range []string{"e", "f", "g"} {
+		if err := l.LogEvent(repo.ETDsCreated, repo.DatasetRef{Name: name}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	if _, err := os.Stat(basepath(path).filepath(FileEventLogs)); !os.IsNotExist(err) {
+		t.Error("expected json event log to be moved aside after migrating")
+	}
+
+	// 7 events in segments of 3. keeping 3 archives the oldest segment
+	events, err := l.Events(10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if names := eventNames(events); names != "gfed" {
+		t.Errorf("expected live events newest first, got: %s", names)
+	}
+	if events, _ := l.Events(2, 1); eventNames(events) != "fe" {
+		t.Errorf("limit/offset mismatch across segments, got: %s", eventNames(events))
+	}
+
+	since, err := l.EventsSince(start.Add(150 * time.Second))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if names := eventNames(since); names != "defg" {
+		t.Errorf("expected events since oldest first, got: %s", names)
+	}
+
+	archives, err := l.EventArchives()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(archives) != 1 {
+		t.Fatalf("expected 1 archived segment, got: %d", len(archives))
+	}
+	f, err := store.Get(datastore.NewKey(archives[0]))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	e := &repo.Event{}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(e); err != nil || e.Ref.Name != "a" {
+		t.Errorf("expected archived segment to start with the oldest event, got: %v, err: %v", e.Ref.Name, err)
+	}
+
+	archived, err := l.ArchivedEvents()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if names := eventNames(archived); names != "abc" {
+		t.Errorf("expected archived events oldest first, got: %s", names)
+	}
+}
+
+func eventNames(events []*repo.Event) (names string) {
+	for _, e := range events {
+		names += e.Ref.Name
+	}
+	return
+}
+
+func TestImportEvents(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_import_events_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	prevSize := SegmentSize
+	SegmentSize = 2
+	defer func() { SegmentSize = prevSize }()
+
+	l := NewEventLog(path, FileEventSegments, cafs.NewMapstore())
+	for _, name := range []string{"c", "d", "e"} {
+		if err := l.LogEvent(repo.ETDsCreated, repo.DatasetRef{Name: name}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	logged, err := l.Events(10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	start := time.Now().Add(-time.Hour)
+	older := []*repo.Event{
+		{Time: start.Add(time.Minute), Type: repo.ETDsCreated, Ref: repo.DatasetRef{Name: "b"}},
+		{Time: start, Type: repo.ETDsCreated, Ref: repo.DatasetRef{Name: "a"}},
+	}
+	if err := l.ImportEvents(append(older, logged...)); err != nil {
+		t.Fatal(err.Error())
+	}
+	// importing the same events again must not duplicate them
+	if err := l.ImportEvents(older); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	events, err := l.Events(10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if names := eventNames(events); names != "edcba" {
+		t.Errorf("expected imported events merged in time order without duplicates, got: %s", names)
+	}
+}
+
+func TestImportEventsRetention(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_import_events_retention_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	prevSize := SegmentSize
+	SegmentSize = 2
+	defer func() { SegmentSize = prevSize }()
+
+	store := cafs.NewMapstore()
+	l := NewEventLog(path, FileEventSegments, store)
+	l.retention = Retention{MaxCount: 2}
+	if err := l.LogEvent(repo.ETDsCreated, repo.DatasetRef{Name: "e"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	start := time.Now().Add(-time.Hour)
+	imported := []*repo.Event{}
+	for i, name := range []string{"a", "b", "c", "d"} {
+		imported = append(imported, &repo.Event{Time: start.Add(time.Duration(i) * time.Minute), Type: repo.ETDsCreated, Ref: repo.DatasetRef{Name: name}})
+	}
+	if err := l.ImportEvents(imported); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// 5 events in segments of 2. keeping 2 archives the oldest segment
+	events, err := l.Events(10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if names := eventNames(events); names != "edc" {
+		t.Errorf("expected imports to be held to retention limits, got live events: %s", names)
+	}
+	archived, err := l.ArchivedEvents()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if names := eventNames(archived); names != "ab" {
+		t.Errorf("expected imported events past retention limits to be archived, got: %s", names)
+	}
+}
+
+func TestEventLogCrashRecovery(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_event_log_crash_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	l := NewEventLog(path, FileEventSegments, nil)
+	if err := l.LogEvent(repo.ETDsCreated, repo.DatasetRef{Name: "a"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// write an event to the segment without updating the manifest, followed
+	// by a partially written one, as if LogEvent crashed
+	segs, err := l.segments()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	f, err := os.OpenFile(l.segmentPath(segs[0]), os.O_APPEND|os.O_WRONLY, os.ModePerm)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	json.NewEncoder(f).Encode(&repo.Event{Time: time.Now(), Type: repo.ETDsCreated, Ref: repo.DatasetRef{Name: "b"}})
+	f.Write([]byte(`{"time":`))
+	f.Close()
+
+	if err := l.LogEvent(repo.ETDsCreated, repo.DatasetRef{Name: "c"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	events, err := l.Events(10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if names := eventNames(events); names != "cba" {
+		t.Errorf("expected events written before the crash to be kept, got: %s", names)
+	}
+}
@@ -36,13 +36,14 @@ const (
 	// FileLockfile is the on-disk mutex lock
 	FileLockfile
 	// FileInfo stores information about this repository
-	// like version number, size of repo, etc.
+	// like version number, size of repo, etc. see RepoInfo
 	FileInfo
 	// FileConfig holds configuration specific to this repo
 	FileConfig
 	// FileDatasets holds the list of datasets
 	FileDatasets
-	// FileEventLogs is a log of all queries in order they're run
+	// FileEventLogs is a log of all queries in order they're run. Event logs
+	// are now kept in FileEventSegments, this file is only read to migrate it
 	FileEventLogs
 	// FileRefstore is a file for the user's local namespace
 	FileRefstore
@@ -55,6 +56,15 @@ const (
 	FileSearchIndex
 	// FileChangeRequests is a file of change requests
 	FileChangeRequests
+	// FileLineage records which datasets were derived from which
+	FileLineage
+	// FileRefsDB is an embedded database of dataset references
+	FileRefsDB
+	// FileEventSegments is a directory of event log segments
+	FileEventSegments
+	// FileRelationships holds this peer's follows & trust levels for other
+	// profiles
+	FileRelationships
 )
 
 var paths = map[File]string{
@@ -69,6 +79,10 @@ var paths = map[File]string{
 	FileAnalytics:      "/analytics.json",
 	FileSearchIndex:    "/index.bleve",
 	FileChangeRequests: "/change_requests.json",
+	FileLineage:        "/lineage.json",
+	FileRefsDB:         "/refs.db",
+	FileEventSegments:  "/events",
+	FileRelationships:  "/relationships.json",
 }
 
 // Filepath gives the relative filepath to a repofile
@@ -3,6 +3,7 @@ package fsrepo
 import (
 	"fmt"
 	"os"
+	"sync"
 
 	golog "github.com/ipfs/go-log"
 	"github.com/libp2p/go-libp2p-crypto"
@@ -29,13 +30,21 @@ type Repo struct {
 	pk      crypto.PrivKey
 
 	store cafs.Filestore
-	graph map[string]*dsgraph.Node
+	// graph caches the result of Graph until references change
+	graph     map[string]*dsgraph.Node
+	graphLock sync.Mutex
 
-	Refstore
+	KVRefstore
 	EventLog
+	Lineage
+	*repo.EventBus
 
-	profiles ProfileStore
-	index    search.Index
+	profiles       ProfileStore
+	changeRequests ChangeRequests
+	analytics      Analytics
+	index          search.Index
+	// indexErr is the error loading the search index, if any
+	indexErr error
 }
 
 // NewRepo creates a new file-based repository
@@ -44,6 +53,9 @@ func NewRepo(store cafs.Filestore, cfg *config.Profile, base string) (repo.Repo,
 		return nil, err
 	}
 	bp := basepath(base)
+	if err := checkVersion(bp); err != nil {
+		return nil, err
+	}
 
 	p, err := cfg.DecodeProfile()
 	if err != nil {
@@ -54,6 +66,15 @@ func NewRepo(store cafs.Filestore, cfg *config.Profile, base string) (repo.Repo,
 		return nil, err
 	}
 
+	refs, err := NewKVRefstore(bp, FileRefsDB, store)
+	if err != nil {
+		return nil, err
+	}
+
+	bus := repo.NewEventBus()
+	events := NewEventLog(base, FileEventSegments, store)
+	events.bus = bus
+
 	r := &Repo{
 		profile: p,
 		pk:      pk,
@@ -61,15 +82,22 @@ func NewRepo(store cafs.Filestore, cfg *config.Profile, base string) (repo.Repo,
 		store:    store,
 		basepath: bp,
 
-		Refstore: Refstore{basepath: bp, store: store, file: FileRefstore},
-		EventLog: NewEventLog(base, FileEventLogs, store),
+		KVRefstore: refs,
+		EventLog:   events,
+		Lineage:    Lineage{basepath: bp, file: FileLineage},
+		EventBus:   bus,
 
-		profiles: ProfileStore{bp},
+		profiles:       ProfileStore{bp},
+		changeRequests: ChangeRequests{basepath: bp, file: FileChangeRequests},
+		analytics:      Analytics{basepath: bp, file: FileAnalytics},
 	}
 
 	if index, err := search.LoadIndex(bp.filepath(FileSearchIndex)); err == nil {
 		r.index = index
-		r.Refstore.index = index
+		r.KVRefstore.index = index
+	} else {
+		log.Debug(err.Error())
+		r.indexErr = err
 	}
 
 	// TODO - this is racey.
@@ -85,12 +113,15 @@ func NewRepo(store cafs.Filestore, cfg *config.Profile, base string) (repo.Repo,
 }
 
 // Store returns the underlying cafs.Filestore driving this repo
-func (r Repo) Store() cafs.Filestore {
+func (r *Repo) Store() cafs.Filestore {
 	return r.store
 }
 
 // Graph returns the graph of dataset objects for this repo
 func (r *Repo) Graph() (map[string]*dsgraph.Node, error) {
+	r.graphLock.Lock()
+	defer r.graphLock.Unlock()
+
 	if r.graph == nil {
 		nodes, err := repo.Graph(r)
 		if err != nil {
@@ -102,6 +133,30 @@ func (r *Repo) Graph() (map[string]*dsgraph.Node, error) {
 	return r.graph, nil
 }
 
+// PutRef adds a reference to the store, clearing the cached graph
+func (r *Repo) PutRef(ref repo.DatasetRef) error {
+	if err := r.KVRefstore.PutRef(ref); err != nil {
+		return err
+	}
+	r.clearGraph()
+	return nil
+}
+
+// DeleteRef removes a reference from the store, clearing the cached graph
+func (r *Repo) DeleteRef(ref repo.DatasetRef) error {
+	if err := r.KVRefstore.DeleteRef(ref); err != nil {
+		return err
+	}
+	r.clearGraph()
+	return nil
+}
+
+func (r *Repo) clearGraph() {
+	r.graphLock.Lock()
+	r.graph = nil
+	r.graphLock.Unlock()
+}
+
 // Profile gives this repo's peer profile
 func (r *Repo) Profile() (*profile.Profile, error) {
 	return r.profile, nil
@@ -155,12 +210,85 @@ func (r *Repo) UpdateSearchIndex(store cafs.Filestore) error {
 	return search.IndexRepo(r, r.index)
 }
 
+// IndexHas returns true if a dataset path is in this repo's search index
+func (r *Repo) IndexHas(path string) (bool, error) {
+	if r.index == nil {
+		return false, r.noIndex()
+	}
+	doc, err := r.index.Document(path)
+	if err != nil {
+		log.Debug(err.Error())
+		return false, err
+	}
+	return doc != nil, nil
+}
+
+// IndexCount gives the number of documents in this repo's search index
+func (r *Repo) IndexCount() (int, error) {
+	if r.index == nil {
+		return 0, r.noIndex()
+	}
+	count, err := r.index.DocCount()
+	return int(count), err
+}
+
+// Reindex rebuilds this repo's search index from the refstore. An index that
+// failed to load is replaced with a new one
+func (r *Repo) Reindex() error {
+	if r.index == nil {
+		path := r.filepath(FileSearchIndex)
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		index, err := search.LoadIndex(path)
+		if err != nil {
+			log.Debug(err.Error())
+			return err
+		}
+		r.index = index
+		r.KVRefstore.index = index
+		r.indexErr = nil
+	}
+	return r.UpdateSearchIndex(r.store)
+}
+
+func (r *Repo) noIndex() error {
+	if r.indexErr != nil {
+		return fmt.Errorf("search index failed to load: %s", r.indexErr.Error())
+	}
+	return fmt.Errorf("search not supported")
+}
+
+// WithExclusiveLock runs fn holding the on-disk repo lock exclusively, so no
+// other qri process can use the repo while fn runs
+func (r *Repo) WithExclusiveLock(fn func() error) error {
+	return WithExclusiveLock(string(r.basepath), fn)
+}
+
 // Profiles returns this repo's Peers implementation
 func (r *Repo) Profiles() profile.Store {
 	return r.profiles
 }
 
-// Destroy destroys this repository
+// ChangeRequests returns this repo's ChangeRequestStore implementation
+func (r *Repo) ChangeRequests() repo.ChangeRequestStore {
+	return r.changeRequests
+}
+
+// Analytics returns this repo's Analytics implementation
+func (r *Repo) Analytics() repo.Analytics {
+	return r.analytics
+}
+
+// Close closes the repo's refs database
+func (r *Repo) Close() error {
+	return r.KVRefstore.Close()
+}
+
+// Destroy closes & removes this repository
 func (r *Repo) Destroy() error {
+	if err := r.Close(); err != nil {
+		return err
+	}
 	return os.RemoveAll(string(r.basepath))
 }
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/test"
@@ -33,3 +35,67 @@ func TestRepo(t *testing.T) {
 		t.Errorf("error cleaning up after test: %s", err.Error())
 	}
 }
+
+func TestGraphCache(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_graph_cache_test")
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	store := cafs.NewMapstore()
+	r, err := NewRepo(store, config.DefaultProfile(), path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	put := func(name string) repo.DatasetRef {
+		ds := &dataset.Dataset{
+			Meta:   &dataset.Meta{Title: name},
+			Commit: &dataset.Commit{Message: name},
+			Structure: &dataset.Structure{
+				Format: dataset.JSONDataFormat,
+				Schema: dataset.BaseSchemaObject,
+			},
+		}
+		data := cafs.NewMemfileBytes("data.json", []byte(`["`+name+`"]`))
+		path, err := dsfs.WriteDataset(store, ds, data, true)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ref := repo.DatasetRef{Peername: pro.Peername, ProfileID: pro.ID, Name: name, Path: path.String()}
+		if err := r.PutRef(ref); err != nil {
+			t.Fatal(err.Error())
+		}
+		return ref
+	}
+	inGraph := func(ref repo.DatasetRef) bool {
+		nodes, err := r.Graph()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return nodes[ref.Path] != nil
+	}
+
+	a := put("a")
+	if !inGraph(a) {
+		t.Errorf("expected graph to include %s", a.AliasString())
+	}
+
+	// the graph is cached after the first call, adding a ref must clear it
+	b := put("b")
+	if !inGraph(b) {
+		t.Errorf("expected graph to include %s after adding it", b.AliasString())
+	}
+
+	if err := r.DeleteRef(b); err != nil {
+		t.Fatal(err.Error())
+	}
+	if inGraph(b) {
+		t.Errorf("expected graph not to include %s after deleting it", b.AliasString())
+	}
+}
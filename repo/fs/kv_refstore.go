@@ -0,0 +1,455 @@
+package fsrepo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/search"
+)
+
+var (
+	// bktRefs holds references, keyed by path
+	bktRefs = []byte("refs")
+	// bktPeernames indexes paths by peername/name
+	bktPeernames = []byte("peernames")
+	// bktProfileIDs indexes paths by profileID/name
+	bktProfileIDs = []byte("profile_ids")
+)
+
+// kvTimeout is how long to wait for another process to release the refs
+// database before giving up
+const kvTimeout = 5 * time.Second
+
+var (
+	kvDBsLock sync.Mutex
+	// kvDBs holds the refs database of every repo this process has opened, by
+	// path. bolt's file lock conflicts between file descriptors within a
+	// process too, so all refstores for a repo must share one database
+	kvDBs = map[string]*kvDB{}
+)
+
+// KVRefstore is an implementation of the repo.Refstore interface backed by an
+// embedded bolt database. References are keyed by path, with indexes by
+// peername & profileID. Every change happens in a single transaction.
+// The database is opened once & shared by every read, it must be closed with
+// Close when the refstore is no longer needed
+type KVRefstore struct {
+	basepath
+	file File
+	// optional search index to add/remove from
+	index search.Index
+	// filestore for checking dataset integrity
+	store cafs.Filestore
+	db    *kvDB
+}
+
+// NewKVRefstore creates a KVRefstore, creating the database if it doesn't
+// exist. References in a json refstore file are migrated into the database
+func NewKVRefstore(bp basepath, file File, store cafs.Filestore) (KVRefstore, error) {
+	rs := KVRefstore{basepath: bp, file: file, store: store, db: sharedKVDB(bp.filepath(file))}
+
+	// creating buckets opens the database for writing, only do so if they're
+	// missing so processes that just read from the repo can share it
+	missing := true
+	if _, err := os.Stat(rs.db.path); os.IsNotExist(err) {
+		// the repo was removed out from under a database we still hold open
+		if err := rs.db.close(); err != nil {
+			return rs, err
+		}
+	} else if err == nil {
+		err = rs.view(func(tx *bolt.Tx) error {
+			missing = tx.Bucket(bktRefs) == nil || tx.Bucket(bktPeernames) == nil || tx.Bucket(bktProfileIDs) == nil
+			return nil
+		})
+		if err != nil {
+			return rs, err
+		}
+	}
+	if missing {
+		err := rs.update(func(tx *bolt.Tx) error {
+			for _, name := range [][]byte{bktRefs, bktPeernames, bktProfileIDs} {
+				if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return rs, err
+		}
+	}
+
+	if err := rs.migrate(Refstore{basepath: bp, file: FileRefstore}); err != nil {
+		return rs, fmt.Errorf("error migrating references: %s", err.Error())
+	}
+	return rs, nil
+}
+
+// PutRef adds a reference to the store
+func (rs KVRefstore) PutRef(put repo.DatasetRef) (err error) {
+	var ds *dataset.Dataset
+
+	if put.ProfileID == "" {
+		return repo.ErrPeerIDRequired
+	} else if put.Name == "" {
+		return repo.ErrNameRequired
+	} else if put.Path == "" {
+		return repo.ErrPathRequired
+	} else if put.Peername == "" {
+		return repo.ErrPeernameRequired
+	}
+
+	p := repo.DatasetRef{Peername: put.Peername, ProfileID: put.ProfileID, Name: put.Name, Path: put.Path}
+
+	if rs.store != nil {
+		ds, err = dsfs.LoadDataset(rs.store, datastore.NewKey(p.Path))
+		if err != nil {
+			return err
+		}
+	}
+
+	exists := false
+	err = rs.update(func(tx *bolt.Tx) error {
+		if ref, err := findRef(tx, p); err == nil {
+			if ref.Equal(p) {
+				exists = true
+				return nil
+			}
+			return repo.ErrNameTaken
+		} else if err != repo.ErrNotFound {
+			return err
+		}
+		return putRef(tx, p)
+	})
+	if err != nil || exists {
+		return err
+	}
+
+	if rs.index != nil {
+		batch := rs.index.NewBatch()
+		if err = batch.Index(p.Path, ds); err != nil {
+			log.Debug(err.Error())
+			return err
+		}
+		if err = rs.index.Batch(batch); err != nil {
+			log.Debug(err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRef completes a partially-known reference
+func (rs KVRefstore) GetRef(get repo.DatasetRef) (ref repo.DatasetRef, err error) {
+	err = rs.view(func(tx *bolt.Tx) error {
+		ref, err = findRef(tx, get)
+		return err
+	})
+	return
+}
+
+// DeleteRef removes a reference from the store
+func (rs KVRefstore) DeleteRef(del repo.DatasetRef) error {
+	var ref repo.DatasetRef
+	err := rs.update(func(tx *bolt.Tx) (err error) {
+		if ref, err = findRef(tx, del); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bktRefs).Delete([]byte(ref.Path)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bktPeernames).Delete(peernameKey(ref)); err != nil {
+			return err
+		}
+		return tx.Bucket(bktProfileIDs).Delete(profileIDKey(ref))
+	})
+	if err == repo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if rs.index != nil {
+		if err := rs.index.Delete(ref.Path); err != nil {
+			log.Debug(err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// References gives a set of dataset references from the store, ordered by
+// peername & name
+func (rs KVRefstore) References(limit, offset int) (refs []repo.DatasetRef, err error) {
+	refs = []repo.DatasetRef{}
+	err = rs.view(func(tx *bolt.Tx) error {
+		paths := tx.Bucket(bktPeernames)
+		stored := tx.Bucket(bktRefs)
+		c := paths.Cursor()
+		i := 0
+		for k, path := c.First(); k != nil && len(refs) < limit; k, path = c.Next() {
+			if i < offset {
+				i++
+				continue
+			}
+			ref, err := decodeRef(stored.Get(path))
+			if err != nil {
+				return err
+			}
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	return
+}
+
+// RefCount returns the number of references in the store
+func (rs KVRefstore) RefCount() (count int, err error) {
+	err = rs.view(func(tx *bolt.Tx) error {
+		count = tx.Bucket(bktRefs).Stats().KeyN
+		return nil
+	})
+	return
+}
+
+// RefsVersion implements the repo.RefsVersioner interface with the ID of the
+// database's last write transaction, which every process's writes advance
+func (rs KVRefstore) RefsVersion() (version uint64, err error) {
+	err = rs.view(func(tx *bolt.Tx) error {
+		version = uint64(tx.ID())
+		return nil
+	})
+	return
+}
+
+// migrate moves references from a json refstore into the database, renaming
+// the json file when it's done. references that are already in the database
+// are skipped, so an interrupted migration can safely run again
+func (rs KVRefstore) migrate(prev Refstore) error {
+	path := prev.filepath(prev.file)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	refs, err := prev.names()
+	if err != nil {
+		return err
+	}
+
+	err = rs.update(func(tx *bolt.Tx) error {
+		for _, ref := range refs {
+			if ref.ProfileID == "" || ref.Name == "" || ref.Path == "" {
+				log.Infof("skipping incomplete reference while migrating: %s", ref)
+				continue
+			}
+			if _, err := findRef(tx, ref); err == nil {
+				continue
+			}
+			if err := putRef(tx, ref); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("migrated %d references to %s", len(refs), rs.filepath(rs.file))
+	return os.Rename(path, path+".migrated")
+}
+
+// Close closes the refs database
+func (rs KVRefstore) Close() error {
+	return rs.db.close()
+}
+
+func (rs KVRefstore) view(fn func(tx *bolt.Tx) error) error {
+	return rs.db.with(false, func(db *bolt.DB) error {
+		return db.View(fn)
+	})
+}
+
+func (rs KVRefstore) update(fn func(tx *bolt.Tx) error) error {
+	return rs.db.with(true, func(db *bolt.DB) error {
+		return db.Update(fn)
+	})
+}
+
+// sharedKVDB gives the database for path, shared by every refstore in this
+// process
+func sharedKVDB(path string) *kvDB {
+	path = filepath.Clean(path)
+	kvDBsLock.Lock()
+	defer kvDBsLock.Unlock()
+
+	if db, ok := kvDBs[path]; ok {
+		return db
+	}
+	db := &kvDB{path: path}
+	kvDBs[path] = db
+	return db
+}
+
+// kvDB holds a bolt database open for a refstore. bolt locks the database
+// file, which only matters to other processes: within this process every
+// read shares the one open database. Reads use a read-only database so
+// processes that only read from a repo don't block one another. Writes close
+// it, open the database for writing & close it again once the write is done,
+// so a process that has written doesn't lock other processes out.
+// A closed database is reopened on next use
+type kvDB struct {
+	path string
+	// lock is held for reading while the database is in use, and for
+	// writing while it's opened, closed or written to
+	lock sync.RWMutex
+	db   *bolt.DB
+}
+
+// with calls fn with the open database, opening it first if need be
+func (k *kvDB) with(writable bool, fn func(db *bolt.DB) error) error {
+	if writable {
+		return k.write(fn)
+	}
+	for {
+		k.lock.RLock()
+		if k.db != nil {
+			defer k.lock.RUnlock()
+			return fn(k.db)
+		}
+		k.lock.RUnlock()
+
+		if err := k.open(); err != nil {
+			return err
+		}
+	}
+}
+
+// write calls fn with the database opened for writing, closing it once fn
+// returns
+func (k *kvDB) write(fn func(db *bolt.DB) error) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	// bolt's file lock conflicts with our own read-only database
+	if k.db != nil {
+		if err := k.db.Close(); err != nil {
+			return err
+		}
+		k.db = nil
+	}
+
+	db, err := bolt.Open(k.path, os.ModePerm, &bolt.Options{Timeout: kvTimeout})
+	if err != nil {
+		return fmt.Errorf("error opening refs database: %s", err.Error())
+	}
+	if err := fn(db); err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
+
+// open opens the database read-only
+func (k *kvDB) open() error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if k.db != nil {
+		return nil
+	}
+
+	// a database that doesn't exist yet can only be created for writing
+	if _, err := os.Stat(k.path); os.IsNotExist(err) {
+		db, err := bolt.Open(k.path, os.ModePerm, &bolt.Options{Timeout: kvTimeout})
+		if err != nil {
+			return fmt.Errorf("error opening refs database: %s", err.Error())
+		}
+		if err := db.Close(); err != nil {
+			return err
+		}
+	}
+
+	db, err := bolt.Open(k.path, os.ModePerm, &bolt.Options{Timeout: kvTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("error opening refs database: %s", err.Error())
+	}
+	k.db = db
+	return nil
+}
+
+func (k *kvDB) close() error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if k.db == nil {
+		return nil
+	}
+	err := k.db.Close()
+	k.db = nil
+	return err
+}
+
+// findRef looks up the stored reference that matches ref, using the same
+// rules as DatasetRef.Match: equal paths, or equal names with an equal
+// profileID or peername
+func findRef(tx *bolt.Tx, ref repo.DatasetRef) (repo.DatasetRef, error) {
+	stored := tx.Bucket(bktRefs)
+
+	if ref.Path != "" {
+		if data := stored.Get([]byte(ref.Path)); data != nil {
+			return decodeRef(data)
+		}
+	}
+
+	if ref.Name != "" {
+		if ref.ProfileID != "" {
+			if path := tx.Bucket(bktProfileIDs).Get(profileIDKey(ref)); path != nil {
+				return decodeRef(stored.Get(path))
+			}
+		}
+		if ref.Peername != "" {
+			if path := tx.Bucket(bktPeernames).Get(peernameKey(ref)); path != nil {
+				return decodeRef(stored.Get(path))
+			}
+		}
+	}
+
+	return repo.DatasetRef{}, repo.ErrNotFound
+}
+
+// putRef writes a reference & it's index entries
+func putRef(tx *bolt.Tx, ref repo.DatasetRef) error {
+	path := []byte(ref.Path)
+	if err := tx.Bucket(bktRefs).Put(path, []byte(ref.String())); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bktPeernames).Put(peernameKey(ref), path); err != nil {
+		return err
+	}
+	return tx.Bucket(bktProfileIDs).Put(profileIDKey(ref), path)
+}
+
+func decodeRef(data []byte) (repo.DatasetRef, error) {
+	if data == nil {
+		return repo.DatasetRef{}, fmt.Errorf("refs database index points to a missing reference")
+	}
+	return repo.ParseDatasetRef(string(data))
+}
+
+func peernameKey(ref repo.DatasetRef) []byte {
+	return []byte(ref.Peername + "/" + ref.Name)
+}
+
+func profileIDKey(ref repo.DatasetRef) []byte {
+	return []byte(ref.ProfileID.String() + "/" + ref.Name)
+}
@@ -0,0 +1,175 @@
+package fsrepo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestKVRefstoreMigrate(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_kv_refstore_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	id := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	refs := []repo.DatasetRef{
+		{ProfileID: id, Peername: "peer", Name: "b", Path: "/map/QmB"},
+		{ProfileID: id, Peername: "peer", Name: "a", Path: "/map/QmA"},
+	}
+
+	bp := basepath(path)
+	prev := Refstore{basepath: bp, file: FileRefstore}
+	if err := prev.save(refs); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	rs, err := NewKVRefstore(bp, FileRefsDB, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer rs.Close()
+	if _, err := os.Stat(bp.filepath(FileRefstore)); !os.IsNotExist(err) {
+		t.Error("expected json refstore to be moved aside after migrating")
+	}
+
+	if count, err := rs.RefCount(); err != nil || count != 2 {
+		t.Errorf("expected 2 migrated references, got: %d. err: %v", count, err)
+	}
+	got, err := rs.References(10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(got) != 2 || !got[0].Equal(refs[1]) || !got[1].Equal(refs[0]) {
+		t.Errorf("expected references ordered by name, got: %v", got)
+	}
+	if got, _ := rs.References(10, 1); len(got) != 1 || got[0].Name != "b" {
+		t.Errorf("offset mismatch, got: %v", got)
+	}
+
+	// lookups by each index
+	for _, get := range []repo.DatasetRef{
+		{Path: "/map/QmA"},
+		{Peername: "peer", Name: "a"},
+		{ProfileID: id, Name: "a"},
+	} {
+		ref, err := rs.GetRef(get)
+		if err != nil || !ref.Equal(refs[1]) {
+			t.Errorf("GetRef(%s) mismatch. got: %s, err: %v", get, ref, err)
+		}
+	}
+
+	if err := rs.PutRef(repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "a", Path: "/map/QmC"}); err != repo.ErrNameTaken {
+		t.Errorf("expected putting a taken name to error with ErrNameTaken, got: %v", err)
+	}
+
+	// opening again must not re-migrate or lose references
+	rs, err = NewKVRefstore(bp, FileRefsDB, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := rs.DeleteRef(repo.DatasetRef{Peername: "peer", Name: "a"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := rs.GetRef(repo.DatasetRef{ProfileID: id, Name: "a"}); err != repo.ErrNotFound {
+		t.Errorf("expected deleted reference to be removed from every index, got: %v", err)
+	}
+	if count, _ := rs.RefCount(); count != 1 {
+		t.Errorf("expected 1 reference after delete, got: %d", count)
+	}
+}
+
+func TestKVRefstoreShared(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_kv_refstore_shared_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	bp := basepath(path)
+
+	a, err := NewKVRefstore(bp, FileRefsDB, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer a.Close()
+	b, err := NewKVRefstore(bp, FileRefsDB, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// refstores for the same repo share a database, writers within a process
+	// must not wait on each other's file lock
+	id := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		rs := a
+		if i%2 == 1 {
+			rs = b
+		}
+		wg.Add(1)
+		go func(i int, rs KVRefstore) {
+			defer wg.Done()
+			errs <- rs.PutRef(repo.DatasetRef{ProfileID: id, Peername: "peer", Name: fmt.Sprintf("ds_%d", i), Path: fmt.Sprintf("/map/Qm%d", i)})
+		}(i, rs)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err.Error())
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= kvTimeout {
+		t.Errorf("expected concurrent writes not to wait on the file lock, took: %s", elapsed)
+	}
+
+	// closing one refstore closes the shared database, the other reopens it
+	if err := b.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if count, err := a.RefCount(); err != nil || count != 20 {
+		t.Errorf("expected 20 references, got: %d. err: %v", count, err)
+	}
+}
+
+func TestKVRefstoreReleasesWrites(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_kv_refstore_release_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+	bp := basepath(path)
+
+	rs, err := NewKVRefstore(bp, FileRefsDB, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer rs.Close()
+
+	id := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	if err := rs.PutRef(repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "a", Path: "/map/QmA"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// a write must not leave the database locked for writing, as another
+	// process opening it would have to wait on the file lock
+	db, err := bolt.Open(bp.filepath(FileRefsDB), os.ModePerm, &bolt.Options{Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected database to be released after writing: %s", err.Error())
+	}
+	db.Close()
+
+	if _, err := rs.GetRef(repo.DatasetRef{Peername: "peer", Name: "a"}); err != nil {
+		t.Errorf("expected reading after a write to reopen the database: %s", err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// lineageLock serializes read-modify-write cycles on lineage files
+var lineageLock sync.Mutex
+
+// Lineage is a file-based implementation of the repo.Lineage interface
+type Lineage struct {
+	basepath
+	file File
+}
+
+// PutUpstreams implements the repo.Lineage interface
+func (l Lineage) PutUpstreams(ref repo.DatasetRef, upstreams []repo.DatasetRef) error {
+	return l.update(func(ml *repo.MemLineage) error {
+		return ml.PutUpstreams(ref, upstreams)
+	})
+}
+
+// Upstreams implements the repo.Lineage interface
+func (l Lineage) Upstreams(ref repo.DatasetRef) ([]repo.DatasetRef, error) {
+	ml, err := l.entries()
+	if err != nil {
+		return nil, err
+	}
+	return ml.Upstreams(ref)
+}
+
+// Downstreams implements the repo.Lineage interface
+func (l Lineage) Downstreams(ref repo.DatasetRef) ([]repo.DatasetRef, error) {
+	ml, err := l.entries()
+	if err != nil {
+		return nil, err
+	}
+	return ml.Downstreams(ref)
+}
+
+// SetStale implements the repo.Lineage interface
+func (l Lineage) SetStale(ref repo.DatasetRef, stale bool) error {
+	return l.update(func(ml *repo.MemLineage) error {
+		return ml.SetStale(ref, stale)
+	})
+}
+
+// IsStale implements the repo.Lineage interface
+func (l Lineage) IsStale(ref repo.DatasetRef) (bool, error) {
+	ml, err := l.entries()
+	if err != nil {
+		return false, err
+	}
+	return ml.IsStale(ref)
+}
+
+// DeleteLineage implements the repo.Lineage interface
+func (l Lineage) DeleteLineage(ref repo.DatasetRef) error {
+	return l.update(func(ml *repo.MemLineage) error {
+		return ml.DeleteLineage(ref)
+	})
+}
+
+func (l Lineage) update(fn func(ml *repo.MemLineage) error) error {
+	lineageLock.Lock()
+	defer lineageLock.Unlock()
+
+	ml, err := l.entries()
+	if err != nil {
+		return err
+	}
+	if err := fn(&ml); err != nil {
+		return err
+	}
+	return l.saveFile(ml, l.file)
+}
+
+func (l Lineage) entries() (repo.MemLineage, error) {
+	ml := repo.MemLineage{}
+	data, err := ioutil.ReadFile(l.filepath(l.file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ml, nil
+		}
+		log.Debug(err.Error())
+		return ml, fmt.Errorf("error loading lineage: %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &ml); err != nil {
+		log.Debug(err.Error())
+		return ml, fmt.Errorf("error unmarshaling lineage: %s", err.Error())
+	}
+	return ml, nil
+}
@@ -0,0 +1,48 @@
+package fsrepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qri-io/qri/repo"
+)
+
+func TestLineage(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_lineage_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	a := repo.DatasetRef{Peername: "peer", Name: "a"}
+	b := repo.DatasetRef{Peername: "peer", Name: "b"}
+
+	l := Lineage{basepath: basepath(path), file: FileLineage}
+	if err := l.PutUpstreams(b, []repo.DatasetRef{a}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := l.SetStale(b, true); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// a fresh instance must read what the first wrote
+	l = Lineage{basepath: basepath(path), file: FileLineage}
+	downs, err := l.Downstreams(a)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(downs) != 1 || downs[0].AliasString() != "peer/b" {
+		t.Errorf("downstreams mismatch, got: %v", downs)
+	}
+	if stale, err := l.IsStale(b); err != nil || !stale {
+		t.Errorf("expected b to be stale. err: %v", err)
+	}
+
+	if err := l.DeleteLineage(b); err != nil {
+		t.Fatal(err.Error())
+	}
+	if downs, _ := l.Downstreams(a); len(downs) != 0 {
+		t.Errorf("expected no downstreams after delete, got: %v", downs)
+	}
+}
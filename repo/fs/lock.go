@@ -0,0 +1,215 @@
+package fsrepo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LockMode sets whether a repo lock can be held by more than one process
+type LockMode int
+
+const (
+	// LockShared can be held by many processes at once. Processes that only
+	// read from the repo should take a shared lock
+	LockShared LockMode = iota
+	// LockExclusive can only be held by one process, and excludes shared locks
+	LockExclusive
+)
+
+// String implements the stringer interface for LockMode
+func (m LockMode) String() string {
+	if m == LockExclusive {
+		return "exclusive"
+	}
+	return "shared"
+}
+
+// errWouldBlock is returned by lockFile when another process holds the lock
+var errWouldBlock = errors.New("lock is held by another process")
+
+var (
+	heldLock sync.Mutex
+	// held tracks the locks this process holds by repo path. flock conflicts
+	// between file descriptors within a process too, so WithExclusiveLock
+	// must reuse or upgrade a lock this process already holds
+	held = map[string]*Lock{}
+)
+
+// LockedError is returned when a repo lock is held by another process
+type LockedError struct {
+	// PID of the process holding an exclusive lock. 0 if the lock is shared,
+	// or the holder is unknown
+	PID int
+}
+
+// Error implements the error interface for LockedError
+func (e *LockedError) Error() string {
+	if e.PID != 0 {
+		return fmt.Sprintf("qri repo is locked by another qri process (pid %d)", e.PID)
+	}
+	return "qri repo is locked by one or more qri processes reading from it"
+}
+
+// Lock is an advisory lock on a repo, coordinating access between processes
+// through the repo's lockfile. Locks are released by the OS when the process
+// holding them exits
+type Lock struct {
+	lk   sync.Mutex
+	base string
+	mode LockMode
+	file *os.File
+	// upgrades counts the WithExclusiveLock calls a shared lock is upgraded
+	// for
+	upgrades int
+}
+
+// AcquireLock takes a lock on the repo at base. If another process holds
+// a conflicting lock AcquireLock returns a *LockedError right away instead
+// of waiting
+func AcquireLock(base string, mode LockMode) (*Lock, error) {
+	path := basepath(base).filepath(FileLockfile)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening repo lockfile: %s", err.Error())
+	}
+
+	if err := lockFile(f, mode); err != nil {
+		f.Close()
+		if err == errWouldBlock {
+			return nil, &LockedError{PID: lockHolder(path)}
+		}
+		return nil, fmt.Errorf("error locking repo: %s", err.Error())
+	}
+
+	// holding any lock means no other process holds an exclusive one, so a
+	// PID left in the lockfile is from a holder that crashed
+	f.Truncate(0)
+	if mode == LockExclusive {
+		// record the holder so processes that find the lock taken can say who
+		// has it
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+
+	l := &Lock{base: base, mode: mode, file: f}
+	heldLock.Lock()
+	if held[filepath.Clean(base)] == nil {
+		held[filepath.Clean(base)] = l
+	}
+	heldLock.Unlock()
+	return l, nil
+}
+
+// WithExclusiveLock runs fn while holding an exclusive lock on the repo at
+// base. If this process already holds an exclusive lock fn runs under it, a
+// shared lock is upgraded for the duration of fn, otherwise the lock is taken
+// for the duration of fn
+func WithExclusiveLock(base string, fn func() error) error {
+	heldLock.Lock()
+	l := held[filepath.Clean(base)]
+	heldLock.Unlock()
+
+	if l == nil {
+		l, err := AcquireLock(base, LockExclusive)
+		if err != nil {
+			return err
+		}
+		defer l.Release()
+		return fn()
+	}
+
+	if err := l.upgrade(); err != nil {
+		return err
+	}
+	defer l.downgrade()
+	return fn()
+}
+
+// upgrade turns a shared lock exclusive. Other processes holding shared locks
+// make upgrading fail with a *LockedError, leaving the lock shared. Upgrades
+// nest, the lock is only downgraded once every upgrade is undone. Exclusive
+// locks are left as they are
+func (l *Lock) upgrade() error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	if l.mode == LockExclusive {
+		if l.upgrades > 0 {
+			l.upgrades++
+		}
+		return nil
+	}
+
+	if err := relockFile(l.file, LockShared, LockExclusive); err != nil {
+		if err == errWouldBlock {
+			return &LockedError{}
+		}
+		return fmt.Errorf("error locking repo: %s", err.Error())
+	}
+	l.mode = LockExclusive
+	l.upgrades = 1
+	l.file.Truncate(0)
+	l.file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	return nil
+}
+
+// downgrade undoes an upgrade, turning the lock back into a shared one once
+// every upgrade is undone
+func (l *Lock) downgrade() {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	if l.upgrades == 0 {
+		return
+	}
+	if l.upgrades--; l.upgrades > 0 {
+		return
+	}
+	l.file.Truncate(0)
+	if err := relockFile(l.file, LockExclusive, LockShared); err != nil {
+		log.Debugf("error downgrading repo lock: %s", err.Error())
+	}
+	l.mode = LockShared
+}
+
+// Base gives the path of the locked repo
+func (l *Lock) Base() string {
+	return l.base
+}
+
+// Mode gives the kind of lock held
+func (l *Lock) Mode() LockMode {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	return l.mode
+}
+
+// Release gives up the lock
+func (l *Lock) Release() error {
+	if l.mode == LockExclusive {
+		l.file.Truncate(0)
+	}
+	heldLock.Lock()
+	if held[filepath.Clean(l.base)] == l {
+		delete(held, filepath.Clean(l.base))
+	}
+	heldLock.Unlock()
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return fmt.Errorf("error unlocking repo: %s", err.Error())
+	}
+	return l.file.Close()
+}
+
+// lockHolder reads the PID of the process holding an exclusive lock
+func lockHolder(path string) int {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
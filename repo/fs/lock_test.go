@@ -0,0 +1,140 @@
+package fsrepo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_lock_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	ex, err := AcquireLock(path, LockExclusive)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, mode := range []LockMode{LockShared, LockExclusive} {
+		_, err := AcquireLock(path, mode)
+		locked, ok := err.(*LockedError)
+		if !ok {
+			t.Errorf("expected %s lock to fail with a LockedError, got: %v", mode, err)
+			continue
+		}
+		if locked.PID != os.Getpid() {
+			t.Errorf("expected locked error to name the holder's pid %d, got: %d", os.Getpid(), locked.PID)
+		}
+	}
+
+	if err := ex.Release(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	a, err := AcquireLock(path, LockShared)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	b, err := AcquireLock(path, LockShared)
+	if err != nil {
+		t.Errorf("expected shared locks to be held together: %s", err.Error())
+	}
+
+	_, err = AcquireLock(path, LockExclusive)
+	if locked, ok := err.(*LockedError); !ok || locked.PID != 0 {
+		t.Errorf("expected exclusive lock to fail without a pid while shared locks are held, got: %v", err)
+	}
+
+	a.Release()
+	if b != nil {
+		b.Release()
+	}
+	if ex, err = AcquireLock(path, LockExclusive); err != nil {
+		t.Errorf("expected exclusive lock after shared locks are released: %s", err.Error())
+	} else {
+		ex.Release()
+	}
+}
+
+func TestWithExclusiveLock(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_exclusive_lock_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	ran := false
+	err := WithExclusiveLock(path, func() error {
+		ran = true
+		if _, err := AcquireLock(path, LockShared); err == nil {
+			t.Error("expected the repo to be locked while fn runs")
+		}
+		return nil
+	})
+	if err != nil || !ran {
+		t.Fatalf("expected fn to run, ran: %t, err: %v", ran, err)
+	}
+
+	ex, err := AcquireLock(path, LockExclusive)
+	if err != nil {
+		t.Fatalf("expected the lock to be released after fn: %s", err.Error())
+	}
+	if err := WithExclusiveLock(path, func() error { return nil }); err != nil {
+		t.Errorf("expected to reuse the exclusive lock this process holds: %s", err.Error())
+	}
+	ex.Release()
+
+	sh, err := AcquireLock(path, LockShared)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer sh.Release()
+	err = WithExclusiveLock(path, func() error {
+		if sh.Mode() != LockExclusive {
+			t.Error("expected the shared lock this process holds to be upgraded")
+		}
+		if _, err := AcquireLock(path, LockShared); err == nil {
+			t.Error("expected the repo to be locked while fn runs")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected to upgrade the shared lock this process holds: %s", err.Error())
+	}
+	if sh.Mode() != LockShared {
+		t.Error("expected the lock to be shared again after fn runs")
+	}
+	other, err := AcquireLock(path, LockShared)
+	if err != nil {
+		t.Errorf("expected shared locks to be allowed after fn runs: %s", err.Error())
+	} else {
+		other.Release()
+	}
+}
+
+func TestLockStalePID(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_stale_lock_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	// a process that crashed while holding an exclusive lock leaves it's pid
+	lockfile := basepath(path).filepath(FileLockfile)
+	if err := ioutil.WriteFile(lockfile, []byte("99999999"), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	sh, err := AcquireLock(path, LockShared)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer sh.Release()
+	if pid := lockHolder(lockfile); pid != 0 {
+		t.Errorf("expected a stale pid to be cleared, got: %d", pid)
+	}
+}
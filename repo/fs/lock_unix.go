@@ -0,0 +1,38 @@
+//go:build !windows
+// +build !windows
+
+package fsrepo
+
+import (
+	"os"
+	"syscall"
+)
+
+func lockFile(f *os.File, mode LockMode) error {
+	how := syscall.LOCK_SH
+	if mode == LockExclusive {
+		how = syscall.LOCK_EX
+	}
+	err := syscall.Flock(int(f.Fd()), how|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return errWouldBlock
+	}
+	return err
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// relockFile converts a lock held on f from one mode to another. flock
+// converts in place, but not atomically: a conversion that would block can
+// drop the lock, so the original mode is taken again
+func relockFile(f *os.File, from, to LockMode) error {
+	err := lockFile(f, to)
+	if err != nil {
+		if e := lockFile(f, from); e != nil {
+			return e
+		}
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+//go:build windows
+// +build windows
+
+package fsrepo
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// windows locks are mandatory, so lock a byte well past the end of the
+// lockfile, leaving the holder's PID readable
+const lockOffsetHigh = 1
+
+func lockFile(f *os.File, mode LockMode) error {
+	flags := uint32(windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if mode == LockExclusive {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, 1, 0, ol)
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return errWouldBlock
+	}
+	return err
+}
+
+func unlockFile(f *os.File) error {
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
+
+// relockFile converts a lock held on f from one mode to another. windows
+// can't convert locks, so the lock is released & taken again, falling back
+// to the original mode if the new one can't be taken
+func relockFile(f *os.File, from, to LockMode) error {
+	if err := unlockFile(f); err != nil {
+		return err
+	}
+	err := lockFile(f, to)
+	if err != nil {
+		if e := lockFile(f, from); e != nil {
+			return e
+		}
+	}
+	return err
+}
@@ -0,0 +1,312 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// RepoVersion is the version of the on-disk repo layout this version of qri
+// reads & writes. Every change to the layout must bump RepoVersion and add a
+// Migration
+const RepoVersion = 2
+
+// RepoInfo is stored in FileInfo, describing the repo
+type RepoInfo struct {
+	// Version is the layout version of the repo
+	Version int `json:"version"`
+}
+
+// VersionError is returned by NewRepo when a repo's layout version doesn't
+// match RepoVersion
+type VersionError struct {
+	Have int
+	Want int
+}
+
+// Error implements the error interface
+func (e *VersionError) Error() string {
+	if e.Have > e.Want {
+		return fmt.Sprintf("qri repo is version %d, but this version of qri only supports up to version %d. please upgrade qri", e.Have, e.Want)
+	}
+	return fmt.Sprintf("qri repo is version %d and needs migrating to version %d. run `qri repo migrate` to upgrade it", e.Have, e.Want)
+}
+
+// Migration changes the repo layout from Version-1 to Version & back again
+type Migration struct {
+	// Version is the repo version after applying Up
+	Version     int
+	Description string
+	Up          func(base string) error
+	Down        func(base string) error
+}
+
+// Migrations lists every layout change, in order. Migrations[i] upgrades
+// version i to version i+1
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "move dataset references from ds_refs.json into refs.db",
+		Up:          refsDBUp,
+		Down:        refsDBDown,
+	},
+	{
+		Version:     2,
+		Description: "split events.json into an append-only segmented event log",
+		Up:          eventSegmentsUp,
+		Down:        eventSegmentsDown,
+	},
+}
+
+// MigrationStep is a migration applied in one direction
+type MigrationStep struct {
+	Migration
+	// Down is true if the step reverses the migration
+	Down bool
+}
+
+// String describes the step
+func (s MigrationStep) String() string {
+	if s.Down {
+		return fmt.Sprintf("%d -> %d: revert %s", s.Version, s.Version-1, s.Description)
+	}
+	return fmt.Sprintf("%d -> %d: %s", s.Version-1, s.Version, s.Description)
+}
+
+// Version reads the layout version of a repo. repos that don't have any files
+// yet are at RepoVersion
+func Version(base string) (int, error) {
+	v, fresh, err := readVersion(basepath(base))
+	if fresh {
+		return RepoVersion, err
+	}
+	return v, err
+}
+
+// MigrationPlan lists the steps needed to bring a repo to the target version
+func MigrationPlan(base string, target int) ([]MigrationStep, error) {
+	if target < 0 || target > RepoVersion {
+		return nil, fmt.Errorf("invalid repo version: %d. must be between 0 and %d", target, RepoVersion)
+	}
+	v, err := Version(base)
+	if err != nil {
+		return nil, err
+	}
+	if v > RepoVersion {
+		return nil, &VersionError{Have: v, Want: RepoVersion}
+	}
+
+	steps := []MigrationStep{}
+	for ; v < target; v++ {
+		steps = append(steps, MigrationStep{Migration: Migrations[v]})
+	}
+	for ; v > target; v-- {
+		steps = append(steps, MigrationStep{Migration: Migrations[v-1], Down: true})
+	}
+	return steps, nil
+}
+
+// Migrate applies migrations to bring a repo to the target version, recording
+// the version after each step so an interrupted migration can be resumed.
+// Repos should be backed up before migrating
+func Migrate(base string, target int) ([]MigrationStep, error) {
+	steps, err := MigrationPlan(base, target)
+	if err != nil {
+		return nil, err
+	}
+
+	bp := basepath(base)
+	for i, step := range steps {
+		version := step.Version
+		if step.Down {
+			err = step.Migration.Down(base)
+			version--
+		} else {
+			err = step.Migration.Up(base)
+		}
+		if err != nil {
+			log.Debug(err.Error())
+			return steps[:i], fmt.Errorf("error migrating repo (%s): %s", step.String(), err.Error())
+		}
+		if err = writeVersion(bp, version); err != nil {
+			return steps[:i], err
+		}
+	}
+	return steps, nil
+}
+
+// BackupRepo copies the files of a repo into a new directory next to it,
+// returning the path of the copy
+func BackupRepo(base string) (string, error) {
+	v, err := Version(base)
+	if err != nil {
+		return "", err
+	}
+	dst := fmt.Sprintf("%s.backup-v%d-%s", filepath.Clean(base), v, time.Now().Format("20060102150405"))
+	lockfile := basepath(base).filepath(FileLockfile)
+
+	err = filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		if path == lockfile {
+			return nil
+		}
+		return copyFile(path, target, info.Mode())
+	})
+	if err != nil {
+		return "", fmt.Errorf("error backing up repo: %s", err.Error())
+	}
+	return dst, nil
+}
+
+// checkVersion confirms a repo is at RepoVersion, recording the version of
+// new repos
+func checkVersion(bp basepath) error {
+	v, fresh, err := readVersion(bp)
+	if err != nil {
+		return err
+	}
+	if fresh {
+		return writeVersion(bp, RepoVersion)
+	}
+	if v != RepoVersion {
+		return &VersionError{Have: v, Want: RepoVersion}
+	}
+	return nil
+}
+
+// readVersion reads FileInfo. repos without FileInfo are version 0 if they
+// hold any data, fresh otherwise
+func readVersion(bp basepath) (version int, fresh bool, err error) {
+	data, err := bp.readBytes(FileInfo)
+	if os.IsNotExist(err) {
+		for _, f := range []File{FileRefstore, FileEventLogs, FileRefsDB, FileEventSegments, FilePeers, FileLineage} {
+			if _, err := os.Stat(bp.filepath(f)); err == nil {
+				return 0, false, nil
+			}
+		}
+		return 0, true, nil
+	} else if err != nil {
+		return 0, false, fmt.Errorf("error reading repo info: %s", err.Error())
+	}
+
+	info := &RepoInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return 0, false, fmt.Errorf("error reading repo info: %s", err.Error())
+	}
+	return info.Version, false, nil
+}
+
+func writeVersion(bp basepath, version int) error {
+	return bp.saveFile(&RepoInfo{Version: version}, FileInfo)
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func refsDBUp(base string) error {
+	rs, err := NewKVRefstore(basepath(base), FileRefsDB, nil)
+	if err != nil {
+		return err
+	}
+	return rs.Close()
+}
+
+func refsDBDown(base string) error {
+	bp := basepath(base)
+	path := bp.filepath(FileRefsDB)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	rs := KVRefstore{basepath: bp, file: FileRefsDB, db: sharedKVDB(path)}
+	count, err := rs.RefCount()
+	if err != nil {
+		rs.Close()
+		return err
+	}
+	refs, err := rs.References(count, 0)
+	rs.Close()
+	if err != nil {
+		return err
+	}
+
+	prev := Refstore{basepath: bp, file: FileRefstore}
+	if err := prev.save(refs); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func eventSegmentsUp(base string) error {
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	// reading segments migrates events.json if no segments exist
+	_, err := NewEventLog(base, FileEventSegments, nil).segments()
+	return err
+}
+
+// eventSegmentsDown writes events back to events.json. migrations don't have
+// access to the store, so logs with archived segments can't be downgraded
+// without losing history & are refused
+func eventSegmentsDown(base string) error {
+	eventsLock.Lock()
+	defer eventsLock.Unlock()
+
+	l := NewEventLog(base, FileEventSegments, nil)
+	segs, err := l.segments()
+	if err != nil {
+		return err
+	}
+
+	events := []*repo.Event{}
+	for _, seg := range segs {
+		if seg.Archived {
+			return fmt.Errorf("can't downgrade: the event log has archived events, which older versions of qri can't read")
+		}
+		es, err := l.readSegment(seg)
+		if err != nil {
+			return err
+		}
+		// events.json is ordered newest first
+		for _, e := range es {
+			events = append([]*repo.Event{e}, events...)
+		}
+	}
+
+	if err := l.saveFile(events, FileEventLogs); err != nil {
+		return err
+	}
+	return os.RemoveAll(l.filepath(l.file))
+}
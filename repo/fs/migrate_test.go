@@ -0,0 +1,134 @@
+package fsrepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_migrate_test")
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	// write a repo the way the first versions of qri did
+	bp := basepath(path)
+	id := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	ref := repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "movies", Path: "/map/QmA"}
+	if err := (&Refstore{basepath: bp, file: FileRefstore}).save([]repo.DatasetRef{ref}); err != nil {
+		t.Fatal(err.Error())
+	}
+	events := []*repo.Event{{Time: time.Now(), Type: repo.ETDsCreated, Ref: ref}}
+	if err := bp.saveFile(events, FileEventLogs); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err := NewRepo(cafs.NewMapstore(), config.DefaultProfile(), path)
+	if verr, ok := err.(*VersionError); !ok || verr.Have != 0 || verr.Want != RepoVersion {
+		t.Fatalf("expected opening an unmigrated repo to fail with a version error, got: %v", err)
+	}
+
+	backup, err := BackupRepo(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(backup)
+	if _, err := os.Stat(basepath(backup).filepath(FileRefstore)); err != nil {
+		t.Errorf("expected backup to contain refs: %s", err.Error())
+	}
+
+	steps, err := Migrate(path, RepoVersion)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(steps) != RepoVersion {
+		t.Errorf("expected %d migrations, got: %d", RepoVersion, len(steps))
+	}
+	if v, _ := Version(path); v != RepoVersion {
+		t.Errorf("expected repo to be at version %d, got: %d", RepoVersion, v)
+	}
+
+	r, err := NewRepo(cafs.NewMapstore(), config.DefaultProfile(), path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got, err := r.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"}); err != nil || !got.Equal(ref) {
+		t.Errorf("expected migrated reference, got: %s, err: %v", got, err)
+	}
+	if es, err := r.Events(10, 0); err != nil || len(es) != 1 {
+		t.Errorf("expected 1 migrated event, got: %d, err: %v", len(es), err)
+	}
+
+	// migrating back down restores json files
+	if steps, err := Migrate(path, 0); err != nil || len(steps) != RepoVersion || !steps[0].Down {
+		t.Fatalf("expected to revert every migration, got: %v, err: %v", steps, err)
+	}
+	if _, err := os.Stat(bp.filepath(FileRefsDB)); !os.IsNotExist(err) {
+		t.Error("expected reverting to remove refs.db")
+	}
+	refs, err := (&Refstore{basepath: bp, file: FileRefstore}).names()
+	if err != nil || len(refs) != 1 || !refs[0].Equal(ref) {
+		t.Errorf("expected reverted refs to match, got: %v, err: %v", refs, err)
+	}
+
+	if _, err := MigrationPlan(path, RepoVersion+1); err == nil {
+		t.Error("expected planning a migration to an unknown version to error")
+	}
+}
+
+func TestNewRepoVersion(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_repo_version_test")
+	defer os.RemoveAll(path)
+
+	if _, err := NewRepo(cafs.NewMapstore(), config.DefaultProfile(), path); err != nil {
+		t.Fatal(err.Error())
+	}
+	if v, err := Version(path); err != nil || v != RepoVersion {
+		t.Errorf("expected new repo to be recorded at version %d, got: %d, err: %v", RepoVersion, v, err)
+	}
+
+	if err := writeVersion(basepath(path), RepoVersion+1); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := NewRepo(cafs.NewMapstore(), config.DefaultProfile(), path); err == nil {
+		t.Error("expected opening a repo from a newer version of qri to error")
+	}
+}
+
+func TestEventSegmentsDownArchived(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_event_segments_down_test")
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(path)
+
+	prevSize := SegmentSize
+	SegmentSize = 1
+	defer func() { SegmentSize = prevSize }()
+
+	l := NewEventLog(path, FileEventSegments, cafs.NewMapstore())
+	l.retention = Retention{MaxCount: 1}
+	for _, name := range []string{"a", "b", "c"} {
+		if err := l.LogEvent(repo.ETDsCreated, repo.DatasetRef{Name: name}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	if err := eventSegmentsDown(path); err == nil {
+		t.Error("expected downgrading an event log with archived segments to error")
+	}
+	if _, err := os.Stat(basepath(path).filepath(FileEventLogs)); !os.IsNotExist(err) {
+		t.Error("expected a refused downgrade not to write events.json")
+	}
+}
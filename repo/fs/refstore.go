@@ -17,7 +17,8 @@ import (
 )
 
 // Refstore is a file-based implementation of the repo.Refstore
-// interface. It stores names in a json file
+// interface. It stores names in a json file. New repos use KVRefstore,
+// Refstore is kept to migrate existing json files
 type Refstore struct {
 	basepath
 	file File
@@ -0,0 +1,86 @@
+package fsrepo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// relationshipsLock serializes read-modify-write cycles on the relationships
+// file
+var relationshipsLock sync.Mutex
+
+// Relationship gives this peer's relationship with a profile
+func (r ProfileStore) Relationship(id profile.ID) (profile.Relationship, error) {
+	rels, err := r.relationships()
+	if err != nil {
+		return profile.Relationship{}, err
+	}
+	return rels.Relationship(id)
+}
+
+// PutRelationship sets this peer's relationship with a profile
+func (r ProfileStore) PutRelationship(id profile.ID, rel profile.Relationship) error {
+	relationshipsLock.Lock()
+	defer relationshipsLock.Unlock()
+
+	rels, err := r.relationships()
+	if err != nil {
+		return err
+	}
+	if err := rels.PutRelationship(id, rel); err != nil {
+		return err
+	}
+
+	all, err := rels.Relationships()
+	if err != nil {
+		return err
+	}
+	relss := map[string]profile.Relationship{}
+	for id, rel := range all {
+		relss[id.String()] = rel
+	}
+	return r.basepath.saveFile(relss, FileRelationships)
+}
+
+// Relationships lists every relationship in the store
+func (r ProfileStore) Relationships() (map[profile.ID]profile.Relationship, error) {
+	rels, err := r.relationships()
+	if err != nil {
+		return nil, err
+	}
+	return rels.Relationships()
+}
+
+// relationships reads the relationships file into a MemRelationships
+func (r ProfileStore) relationships() (profile.MemRelationships, error) {
+	rels := profile.MemRelationships{}
+	data, err := ioutil.ReadFile(r.filepath(FileRelationships))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rels, nil
+		}
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error loading relationships: %s", err.Error())
+	}
+
+	relss := map[string]profile.Relationship{}
+	if err := json.Unmarshal(data, &relss); err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error decoding relationships: %s", err.Error())
+	}
+	for idstr, rel := range relss {
+		id, err := profile.IDB58Decode(idstr)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding relationships: %s", err.Error())
+		}
+		if err := rels.PutRelationship(id, rel); err != nil {
+			return nil, err
+		}
+	}
+	return rels, nil
+}
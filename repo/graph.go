@@ -1,7 +1,10 @@
 package repo
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
 
 	"github.com/ipfs/go-datastore"
@@ -50,22 +53,189 @@ func Graph(r Repo) (map[string]*dsgraph.Node, error) {
 	nodes := NodeList{Nodes: map[string]*dsgraph.Node{}}
 	root := nodes.node(dsgraph.NtNamespace, "root")
 	mu := sync.Mutex{}
-	err := WalkRepoDatasets(r, func(prev *dsgraph.Node) func(int, *DatasetRef, error) (bool, error) {
-		return func(depth int, ref *DatasetRef, e error) (kontinue bool, err error) {
-			if e != nil {
-				return false, e
-			}
-			mu.Lock()
-			ds := nodes.nodesFromDatasetRef(r, ref)
-			prev.AddLinks(dsgraph.Link{From: prev, To: ds})
-			prev = ds
-			mu.Unlock()
-			return true, nil
+	err := WalkRepoDatasets(r, func(depth int, ref *DatasetRef, e error) (kontinue bool, err error) {
+		if e != nil {
+			return false, e
 		}
-	}(root))
+		mu.Lock()
+		ds := nodes.nodesFromDatasetRef(r, ref)
+		// the namespace links to the head of each dataset. previous versions are
+		// linked by their successors, which keeps lineage traversable
+		if depth == 0 {
+			root.AddLinks(dsgraph.Link{From: root, To: ds})
+		}
+		mu.Unlock()
+		return true, nil
+	})
 	return nodes.Nodes, err
 }
 
+// FilterGraph returns the subgraph of nodes connected to the node at path.
+// ancestry includes everything path was built from: previous versions,
+// components, and the datasets it's transform draws on. derived includes
+// everything built from path: later versions & datasets that use it as a
+// transform resource. Setting neither includes both
+func FilterGraph(nodes map[string]*dsgraph.Node, path string, ancestry, derived bool) (map[string]*dsgraph.Node, error) {
+	if nodes[path] == nil {
+		return nil, ErrNotFound
+	}
+	if !ancestry && !derived {
+		ancestry, derived = true, true
+	}
+
+	keep := map[string]bool{path: true}
+
+	if ancestry {
+		visit([]*dsgraph.Node{nodes[path]}, keep, func(n *dsgraph.Node) (next []*dsgraph.Node) {
+			for _, l := range n.Links {
+				next = append(next, l.To)
+			}
+			return
+		})
+	}
+
+	if derived {
+		parents := map[string][]*dsgraph.Node{}
+		for _, n := range nodes {
+			for _, l := range n.Links {
+				parents[l.To.Path] = append(parents[l.To.Path], n)
+			}
+		}
+		visit([]*dsgraph.Node{nodes[path]}, keep, func(n *dsgraph.Node) (next []*dsgraph.Node) {
+			for _, p := range parents[n.Path] {
+				if p.Type != dsgraph.NtNamespace {
+					next = append(next, p)
+				}
+			}
+			return
+		})
+	}
+
+	sub := map[string]*dsgraph.Node{}
+	for p := range keep {
+		if n := nodes[p]; n != nil {
+			sub[p] = &dsgraph.Node{Type: n.Type, Path: n.Path}
+		}
+	}
+	for p, n := range sub {
+		for _, l := range nodes[p].Links {
+			if to := sub[l.To.Path]; to != nil {
+				n.AddLinks(dsgraph.Link{From: n, To: to})
+			} else if l.To.Type == dsgraph.NtCommit && keep[l.To.Path] {
+				// commit nodes aren't listed in the node map, carry them along
+				n.AddLinks(dsgraph.Link{From: n, To: &dsgraph.Node{Type: l.To.Type, Path: l.To.Path}})
+			}
+		}
+	}
+
+	return sub, nil
+}
+
+// visit performs a breadth-first traversal, adding the path of every node
+// reached to seen
+func visit(queue []*dsgraph.Node, seen map[string]bool, next func(n *dsgraph.Node) []*dsgraph.Node) {
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, nn := range next(n) {
+			if !seen[nn.Path] {
+				seen[nn.Path] = true
+				queue = append(queue, nn)
+			}
+		}
+	}
+}
+
+// GraphNode is a single node in a GraphData
+type GraphNode struct {
+	Type dsgraph.NodeType `json:"type"`
+	Path string           `json:"path"`
+}
+
+// GraphLink is a directed edge between two nodes in a GraphData, referenced
+// by path
+type GraphLink struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GraphData is a flat representation of a graph of dsgraph.Nodes. dsgraph
+// nodes are cyclic pointer structures that don't encode, GraphData does
+type GraphData struct {
+	// Root is the path the graph was filtered to, if any
+	Root  string      `json:"root,omitempty"`
+	Nodes []GraphNode `json:"nodes"`
+	Links []GraphLink `json:"links"`
+}
+
+// NewGraphData flattens a map of nodes into GraphData, sorted by path
+func NewGraphData(nodes map[string]*dsgraph.Node) *GraphData {
+	g := &GraphData{Nodes: []GraphNode{}, Links: []GraphLink{}}
+	added := map[string]bool{}
+	add := func(n *dsgraph.Node) {
+		if !added[n.Path] {
+			added[n.Path] = true
+			g.Nodes = append(g.Nodes, GraphNode{Type: n.Type, Path: n.Path})
+		}
+	}
+
+	for _, n := range nodes {
+		add(n)
+		for _, l := range n.Links {
+			add(l.To)
+			g.Links = append(g.Links, GraphLink{From: n.Path, To: l.To.Path})
+		}
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].Path < g.Nodes[j].Path })
+	sort.Slice(g.Links, func(i, j int) bool {
+		if g.Links[i].From == g.Links[j].From {
+			return g.Links[i].To < g.Links[j].To
+		}
+		return g.Links[i].From < g.Links[j].From
+	})
+	return g
+}
+
+// Children lists the paths a node links to
+func (g *GraphData) Children(path string) (paths []string) {
+	for _, l := range g.Links {
+		if l.From == path {
+			paths = append(paths, l.To)
+		}
+	}
+	return
+}
+
+// Roots lists the paths of nodes nothing links to
+func (g *GraphData) Roots() (paths []string) {
+	linked := map[string]bool{}
+	for _, l := range g.Links {
+		linked[l.To] = true
+	}
+	for _, n := range g.Nodes {
+		if !linked[n.Path] {
+			paths = append(paths, n.Path)
+		}
+	}
+	return
+}
+
+// WriteDOT writes the graph in graphviz DOT format
+func (g *GraphData) WriteDOT(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString("digraph qri {\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(buf, "  %q [label=%q];\n", n.Path, fmt.Sprintf("%s\n%s", n.Type, n.Path))
+	}
+	for _, l := range g.Links {
+		fmt.Fprintf(buf, "  %q -> %q;\n", l.From, l.To)
+	}
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
 // QueriesMap returns a mapped subset of a list of nodes in the form:
 // 		QueryHash : DatasetHash
 func QueriesMap(nodes map[string]*dsgraph.Node) (qs map[string]datastore.Key) {
@@ -232,7 +402,10 @@ func WalkRepoDatasets(r Repo, visit func(depth int, ref *DatasetRef, err error)
 		return nil
 	}
 
-	pageSize := count / pll
+	// round page size up so every reference is in a section, dropping
+	// sections that would start past the last reference
+	pageSize := (count + pll - 1) / pll
+	pll = (count + pageSize - 1) / pageSize
 	done := make(chan error, pll)
 	for i := 0; i < pll; i++ {
 		go doSection(i, pageSize, done)
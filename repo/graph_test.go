@@ -1,9 +1,12 @@
 package repo
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"github.com/qri-io/dataset/dsfs"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/ipfs/go-datastore"
@@ -104,6 +107,143 @@ func TestDataNodes(t *testing.T) {
 	}
 }
 
+func TestFilterGraph(t *testing.T) {
+	r, err := makeTestRepo()
+	if err != nil {
+		t.Errorf("error making test repo: %s", err.Error())
+		return
+	}
+	nodes, err := Graph(r)
+	if err != nil {
+		t.Errorf("error generating repo graph: %s", err.Error())
+		return
+	}
+
+	ds2, err := r.GetRef(DatasetRef{Peername: "peer", Name: "ds2"})
+	if err != nil {
+		t.Errorf("error getting ref: %s", err.Error())
+		return
+	}
+
+	if _, err := FilterGraph(nodes, "/not/a/path", true, false); err != ErrNotFound {
+		t.Errorf("expected missing path to return ErrNotFound, got: %v", err)
+	}
+
+	cases := []struct {
+		path              string
+		ancestry, derived bool
+		contains          []string
+		excludes          []string
+	}{
+		{ds2.Path, true, false, []string{ds2.Path, "/path/to/a", "/path/to/b"}, []string{"root"}},
+		{"/path/to/a", false, true, []string{"/path/to/a", ds2.Path}, []string{"/path/to/b", "root"}},
+		{"/path/to/a", true, false, []string{"/path/to/a"}, []string{ds2.Path}},
+		{"/path/to/a", false, false, []string{"/path/to/a", ds2.Path}, []string{"/path/to/b"}},
+	}
+
+	for i, c := range cases {
+		sub, err := FilterGraph(nodes, c.path, c.ancestry, c.derived)
+		if err != nil {
+			t.Errorf("case %d unexpected error: %s", i, err.Error())
+			continue
+		}
+		for _, p := range c.contains {
+			if sub[p] == nil {
+				t.Errorf("case %d expected subgraph to contain %s", i, p)
+			}
+		}
+		for _, p := range c.excludes {
+			if sub[p] != nil {
+				t.Errorf("case %d expected subgraph not to contain %s", i, p)
+			}
+		}
+	}
+}
+
+func TestGraphData(t *testing.T) {
+	r, err := makeTestRepo()
+	if err != nil {
+		t.Errorf("error making test repo: %s", err.Error())
+		return
+	}
+	nodes, err := Graph(r)
+	if err != nil {
+		t.Errorf("error generating repo graph: %s", err.Error())
+		return
+	}
+
+	g := NewGraphData(nodes)
+	if len(g.Nodes) < len(nodes) {
+		t.Errorf("expected at least %d nodes, got: %d", len(nodes), len(g.Nodes))
+	}
+
+	roots := g.Roots()
+	if len(roots) != 1 || roots[0] != "root" {
+		t.Errorf("expected namespace to be the only root, got: %v", roots)
+	}
+	if len(g.Children("root")) != 2 {
+		t.Errorf("expected namespace to link to 2 datasets, got: %d", len(g.Children("root")))
+	}
+
+	buf := &bytes.Buffer{}
+	if err := g.WriteDOT(buf); err != nil {
+		t.Errorf("error writing dot: %s", err.Error())
+		return
+	}
+	if !strings.HasPrefix(buf.String(), "digraph qri {") {
+		t.Errorf("unexpected dot output: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"root" ->`) {
+		t.Errorf("expected dot output to contain namespace links")
+	}
+}
+
+func TestWalkRepoDatasets(t *testing.T) {
+	r, err := makeTestRepo()
+	if err != nil {
+		t.Fatalf("error making test repo: %s", err.Error())
+	}
+	ds3 := &dataset.Dataset{
+		Meta:      &dataset.Meta{Title: "dataset 3"},
+		Commit:    &dataset.Commit{Message: "baz"},
+		Structure: &dataset.Structure{Format: dataset.JSONDataFormat, Schema: dataset.BaseSchemaObject},
+	}
+	ds3p, err := dsfs.WriteDataset(r.Store(), ds3, cafs.NewMemfileBytes("data3", []byte("dataset_3")), true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.PutRef(DatasetRef{ProfileID: profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"), Peername: "peer", Name: "ds3", Path: ds3p.String()}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	prev := walkParallelism
+	defer func() { walkParallelism = prev }()
+	// 3 references don't divide evenly into 2 sections
+	walkParallelism = 2
+
+	lk := sync.Mutex{}
+	visited := map[string]bool{}
+	err = WalkRepoDatasets(r, func(depth int, ref *DatasetRef, err error) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		lk.Lock()
+		defer lk.Unlock()
+		if depth == 0 {
+			visited[ref.Name] = true
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, name := range []string{"ds1", "ds2", "ds3"} {
+		if !visited[name] {
+			t.Errorf("expected %s to be visited", name)
+		}
+	}
+}
+
 func makeTestRepo() (Repo, error) {
 	ds1 := &dataset.Dataset{
 		Meta: &dataset.Meta{
@@ -165,14 +305,14 @@ func makeTestRepo() (Repo, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error putting dataset: %s", err.Error())
 	}
-	r.PutRef(DatasetRef{Peername: "peer", Name: "ds1", Path: ds1p.String()})
+	r.PutRef(DatasetRef{ProfileID: profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"), Peername: "peer", Name: "ds1", Path: ds1p.String()})
 
 	data2f := cafs.NewMemfileBytes("data2", []byte("dataset_2"))
 	ds2p, err := dsfs.WriteDataset(store, ds2, data2f, true)
 	if err != nil {
 		return nil, fmt.Errorf("error putting dataset: %s", err.Error())
 	}
-	r.PutRef(DatasetRef{Peername: "peer", Name: "ds2", Path: ds2p.String()})
+	r.PutRef(DatasetRef{ProfileID: profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"), Peername: "peer", Name: "ds2", Path: ds2p.String()})
 
 	return r, nil
 }
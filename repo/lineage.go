@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"sort"
+)
+
+// Lineage records which datasets were derived from which, and tracks when a
+// derived dataset has fallen behind the datasets it was derived from.
+// Datasets are identified by alias (peername/name), so lineage holds across
+// versions of a dataset
+type Lineage interface {
+	// PutUpstreams records the datasets ref was derived from, replacing
+	// any previously recorded upstreams
+	PutUpstreams(ref DatasetRef, upstreams []DatasetRef) error
+	// Upstreams lists the datasets ref was derived from
+	Upstreams(ref DatasetRef) ([]DatasetRef, error)
+	// Downstreams lists the datasets derived from ref
+	Downstreams(ref DatasetRef) ([]DatasetRef, error)
+	// SetStale marks weather a derived dataset is out of date with it's upstreams
+	SetStale(ref DatasetRef, stale bool) error
+	// IsStale reports weather a derived dataset is out of date
+	IsStale(ref DatasetRef) (bool, error)
+	// DeleteLineage drops the lineage record for ref
+	DeleteLineage(ref DatasetRef) error
+}
+
+// LineageEntry is the lineage record for a single dataset
+type LineageEntry struct {
+	// Upstreams lists aliases of datasets this dataset was derived from
+	Upstreams []string `json:"upstreams"`
+	// Stale is true when an upstream has changed since this dataset was created
+	Stale bool `json:"stale,omitempty"`
+}
+
+// MemLineage is an in-memory implementation of the Lineage interface,
+// mapping dataset aliases to lineage entries
+type MemLineage map[string]*LineageEntry
+
+// PutUpstreams implements the Lineage interface
+func (l *MemLineage) PutUpstreams(ref DatasetRef, upstreams []DatasetRef) error {
+	if ref.Peername == "" {
+		return ErrPeernameRequired
+	} else if ref.Name == "" {
+		return ErrNameRequired
+	}
+	if *l == nil {
+		*l = MemLineage{}
+	}
+
+	aliases := make([]string, 0, len(upstreams))
+	for _, up := range upstreams {
+		aliases = append(aliases, up.AliasString())
+	}
+	sort.Strings(aliases)
+
+	if e, ok := (*l)[ref.AliasString()]; ok {
+		e.Upstreams = aliases
+		return nil
+	}
+	(*l)[ref.AliasString()] = &LineageEntry{Upstreams: aliases}
+	return nil
+}
+
+// Upstreams implements the Lineage interface
+func (l MemLineage) Upstreams(ref DatasetRef) ([]DatasetRef, error) {
+	e, ok := l[ref.AliasString()]
+	if !ok {
+		return nil, nil
+	}
+	return aliasRefs(e.Upstreams), nil
+}
+
+// Downstreams implements the Lineage interface
+func (l MemLineage) Downstreams(ref DatasetRef) ([]DatasetRef, error) {
+	alias := ref.AliasString()
+	downs := []string{}
+	for a, e := range l {
+		for _, up := range e.Upstreams {
+			if up == alias {
+				downs = append(downs, a)
+				break
+			}
+		}
+	}
+	sort.Strings(downs)
+	return aliasRefs(downs), nil
+}
+
+// SetStale implements the Lineage interface
+func (l MemLineage) SetStale(ref DatasetRef, stale bool) error {
+	e, ok := l[ref.AliasString()]
+	if !ok {
+		return ErrNotFound
+	}
+	e.Stale = stale
+	return nil
+}
+
+// IsStale implements the Lineage interface
+func (l MemLineage) IsStale(ref DatasetRef) (bool, error) {
+	if e, ok := l[ref.AliasString()]; ok {
+		return e.Stale, nil
+	}
+	return false, nil
+}
+
+// DeleteLineage implements the Lineage interface
+func (l MemLineage) DeleteLineage(ref DatasetRef) error {
+	delete(l, ref.AliasString())
+	return nil
+}
+
+func aliasRefs(aliases []string) []DatasetRef {
+	refs := make([]DatasetRef, 0, len(aliases))
+	for _, a := range aliases {
+		if ref, err := ParseDatasetRef(a); err == nil {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"testing"
+)
+
+func TestMemLineage(t *testing.T) {
+	l := &MemLineage{}
+	a := DatasetRef{Peername: "peer", Name: "a"}
+	b := DatasetRef{Peername: "peer", Name: "b"}
+	c := DatasetRef{Peername: "peer", Name: "c"}
+
+	if err := l.PutUpstreams(DatasetRef{Peername: "peer"}, []DatasetRef{a}); err != ErrNameRequired {
+		t.Errorf("expected missing name to error with ErrNameRequired, got: %v", err)
+	}
+	if err := l.PutUpstreams(b, []DatasetRef{a}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := l.PutUpstreams(c, []DatasetRef{b, a}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ups, err := l.Upstreams(c)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(ups) != 2 || ups[0].AliasString() != "peer/a" || ups[1].AliasString() != "peer/b" {
+		t.Errorf("upstreams mismatch, got: %v", ups)
+	}
+
+	downs, err := l.Downstreams(a)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(downs) != 2 || downs[0].AliasString() != "peer/b" || downs[1].AliasString() != "peer/c" {
+		t.Errorf("downstreams mismatch, got: %v", downs)
+	}
+
+	if err := l.SetStale(a, true); err != ErrNotFound {
+		t.Errorf("expected marking a dataset without lineage stale to return ErrNotFound, got: %v", err)
+	}
+	if err := l.SetStale(b, true); err != nil {
+		t.Fatal(err.Error())
+	}
+	// replacing upstreams must keep the stale flag
+	if err := l.PutUpstreams(b, []DatasetRef{a}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if stale, _ := l.IsStale(b); !stale {
+		t.Error("expected b to be stale")
+	}
+	if stale, _ := l.IsStale(c); stale {
+		t.Error("expected c not to be stale")
+	}
+
+	if err := l.DeleteLineage(b); err != nil {
+		t.Fatal(err.Error())
+	}
+	if ups, _ := l.Upstreams(b); len(ups) != 0 {
+		t.Errorf("expected deleted lineage to have no upstreams, got: %v", ups)
+	}
+}
@@ -9,17 +9,22 @@ type MemRefstore []DatasetRef
 
 // PutRef adds a reference to the namestore. Only complete references may be added
 func (r *MemRefstore) PutRef(put DatasetRef) error {
-	if put.Peername == "" {
-		return ErrPeernameRequired
+	if put.ProfileID == "" {
+		return ErrPeerIDRequired
 	} else if put.Name == "" {
 		return ErrNameRequired
 	} else if put.Path == "" {
 		return ErrPathRequired
+	} else if put.Peername == "" {
+		return ErrPeernameRequired
 	}
 
 	for _, ref := range *r {
 		if ref.Match(put) {
-			return nil
+			if ref.Equal(put) {
+				return nil
+			}
+			return ErrNameTaken
 		}
 	}
 	*r = append(*r, put)
@@ -55,17 +60,18 @@ func (r *MemRefstore) DeleteRef(del DatasetRef) error {
 
 // References grabs a set of names from the Store's namespace
 func (r MemRefstore) References(limit, offset int) ([]DatasetRef, error) {
-	res := make([]DatasetRef, limit)
-	for i, ref := range r {
-		if i < offset {
-			continue
-		}
-		if i-offset == limit {
-			return res, nil
-		}
-		res[i-offset] = ref
+	if offset > len(r) {
+		offset = len(r)
+	}
+	stop := offset + limit
+	if stop > len(r) {
+		stop = len(r)
+	} else if stop < offset {
+		stop = offset
 	}
-	return res[:len(r)-offset], nil
+	res := make([]DatasetRef, stop-offset)
+	copy(res, r[offset:stop])
+	return res, nil
 }
 
 // RefCount returns the total number of names in the store
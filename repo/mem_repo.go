@@ -1,33 +1,51 @@
 package repo
 
 import (
+	"sync"
+	"time"
+
 	"github.com/libp2p/go-libp2p-crypto"
 	"github.com/qri-io/cafs"
 	"github.com/qri-io/dataset/dsgraph"
 	"github.com/qri-io/qri/repo/profile"
 )
 
-// MemRepo is an in-memory implementation of the Repo interface
+// MemRepo is an in-memory implementation of the Repo interface. References
+// & the event log are safe for concurrent use
 type MemRepo struct {
+	lock     *sync.RWMutex
 	pk       crypto.PrivKey
 	store    cafs.Filestore
 	graph    map[string]*dsgraph.Node
 	refCache *MemRefstore
 	*MemRefstore
 	*MemEventLog
+	*MemLineage
+	*EventBus
 	profile  *profile.Profile
 	profiles profile.Store
+	crs      *memChangeRequestStore
+	usage    *memAnalytics
 }
 
 // NewMemRepo creates a new in-memory repository
 func NewMemRepo(p *profile.Profile, store cafs.Filestore, ps profile.Store) (Repo, error) {
+	lock := &sync.RWMutex{}
+	if ms, ok := ps.(profile.MemStore); ok {
+		ps = memProfileStore{MemStore: ms, rels: profile.MemRelationships{}}
+	}
 	return &MemRepo{
+		lock:        lock,
 		store:       store,
 		MemRefstore: &MemRefstore{},
 		MemEventLog: &MemEventLog{},
+		MemLineage:  &MemLineage{},
+		EventBus:    NewEventBus(),
 		refCache:    &MemRefstore{},
 		profile:     p,
 		profiles:    ps,
+		crs:         &memChangeRequestStore{lock: lock, crs: MemChangeRequests{}},
+		usage:       &memAnalytics{lock: lock, usage: MemAnalytics{}},
 	}, nil
 }
 
@@ -36,6 +54,71 @@ func (r *MemRepo) Store() cafs.Filestore {
 	return r.store
 }
 
+// PutRef implements the Refstore interface
+func (r *MemRepo) PutRef(ref DatasetRef) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.MemRefstore.PutRef(ref)
+}
+
+// GetRef implements the Refstore interface
+func (r *MemRepo) GetRef(ref DatasetRef) (DatasetRef, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.MemRefstore.GetRef(ref)
+}
+
+// DeleteRef implements the Refstore interface
+func (r *MemRepo) DeleteRef(ref DatasetRef) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.MemRefstore.DeleteRef(ref)
+}
+
+// References implements the Refstore interface
+func (r *MemRepo) References(limit, offset int) ([]DatasetRef, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.MemRefstore.References(limit, offset)
+}
+
+// RefCount implements the Refstore interface
+func (r *MemRepo) RefCount() (int, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.MemRefstore.RefCount()
+}
+
+// LogEvent adds an event to the log & publishes it to subscribers
+func (r *MemRepo) LogEvent(t EventType, ref DatasetRef) error {
+	r.lock.Lock()
+	if err := r.MemEventLog.LogEvent(t, ref); err != nil {
+		r.lock.Unlock()
+		return err
+	}
+	e := (*r.MemEventLog)[0]
+	r.lock.Unlock()
+
+	r.Publish(e)
+	return nil
+}
+
+// Events implements the EventLog interface
+func (r *MemRepo) Events(limit, offset int) ([]*Event, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	events, err := r.MemEventLog.Events(limit, offset)
+	// copy so callers don't share the log's backing array
+	return append([]*Event{}, events...), err
+}
+
+// EventsSince implements the EventLog interface
+func (r *MemRepo) EventsSince(t time.Time) ([]*Event, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.MemEventLog.EventsSince(t)
+}
+
 // SetPrivateKey sets this repos's internal private key reference
 func (r *MemRepo) SetPrivateKey(pk crypto.PrivKey) error {
 	r.pk = pk
@@ -72,3 +155,89 @@ func (r *MemRepo) SetProfile(p *profile.Profile) error {
 func (r *MemRepo) Profiles() profile.Store {
 	return r.profiles
 }
+
+// memProfileStore keeps relationships alongside a profile.MemStore, which
+// only keeps profiles
+type memProfileStore struct {
+	profile.MemStore
+	rels profile.MemRelationships
+}
+
+// Relationship implements the profile.Store interface
+func (s memProfileStore) Relationship(id profile.ID) (profile.Relationship, error) {
+	return s.rels.Relationship(id)
+}
+
+// PutRelationship implements the profile.Store interface
+func (s memProfileStore) PutRelationship(id profile.ID, rel profile.Relationship) error {
+	return s.rels.PutRelationship(id, rel)
+}
+
+// Relationships implements the profile.Store interface
+func (s memProfileStore) Relationships() (map[profile.ID]profile.Relationship, error) {
+	return s.rels.Relationships()
+}
+
+// ChangeRequests gives this repo's ChangeRequestStore implementation
+func (r *MemRepo) ChangeRequests() ChangeRequestStore {
+	return r.crs
+}
+
+// memChangeRequestStore guards a MemChangeRequests with the repo's lock
+type memChangeRequestStore struct {
+	lock *sync.RWMutex
+	crs  MemChangeRequests
+}
+
+// PutChangeRequest implements the ChangeRequestStore interface
+func (s *memChangeRequestStore) PutChangeRequest(cr *ChangeRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.crs.PutChangeRequest(cr)
+}
+
+// GetChangeRequest implements the ChangeRequestStore interface
+func (s *memChangeRequestStore) GetChangeRequest(id string) (*ChangeRequest, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.crs.GetChangeRequest(id)
+}
+
+// ListChangeRequests implements the ChangeRequestStore interface
+func (s *memChangeRequestStore) ListChangeRequests(limit, offset int) ([]*ChangeRequest, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.crs.ListChangeRequests(limit, offset)
+}
+
+// DeleteChangeRequest implements the ChangeRequestStore interface
+func (s *memChangeRequestStore) DeleteChangeRequest(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.crs.DeleteChangeRequest(id)
+}
+
+// Analytics gives this repo's Analytics implementation
+func (r *MemRepo) Analytics() Analytics {
+	return r.usage
+}
+
+// memAnalytics guards a MemAnalytics with the repo's lock
+type memAnalytics struct {
+	lock  *sync.RWMutex
+	usage MemAnalytics
+}
+
+// CountUsage implements the Analytics interface
+func (a *memAnalytics) CountUsage(kind UsageKind, t time.Time, refs ...DatasetRef) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.usage.CountUsage(kind, t, refs...)
+}
+
+// Usage implements the Analytics interface
+func (a *memAnalytics) Usage(since time.Time) ([]*DailyUsage, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.usage.Usage(since)
+}
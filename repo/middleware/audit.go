@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"github.com/qri-io/qri/repo"
+)
+
+// AuditRepo logs every change made to a repo's references & lineage
+type AuditRepo struct {
+	repo.Repo
+}
+
+// Audit is a Middleware that wraps a repo in an AuditRepo
+func Audit(r repo.Repo) (repo.Repo, error) {
+	return AuditRepo{r}, nil
+}
+
+// Unwrap implements the repo.Wrapper interface
+func (r AuditRepo) Unwrap() repo.Repo {
+	return r.Repo
+}
+
+// PutRef implements the repo.Refstore interface
+func (r AuditRepo) PutRef(ref repo.DatasetRef) error {
+	err := r.Repo.PutRef(ref)
+	audit("put ref", ref.String(), err)
+	return err
+}
+
+// DeleteRef implements the repo.Refstore interface
+func (r AuditRepo) DeleteRef(ref repo.DatasetRef) error {
+	err := r.Repo.DeleteRef(ref)
+	audit("delete ref", ref.String(), err)
+	return err
+}
+
+// PutUpstreams implements the repo.Lineage interface
+func (r AuditRepo) PutUpstreams(ref repo.DatasetRef, upstreams []repo.DatasetRef) error {
+	err := r.Repo.PutUpstreams(ref, upstreams)
+	audit("put upstreams", ref.AliasString(), err)
+	return err
+}
+
+// DeleteLineage implements the repo.Lineage interface
+func (r AuditRepo) DeleteLineage(ref repo.DatasetRef) error {
+	err := r.Repo.DeleteLineage(ref)
+	audit("delete lineage", ref.AliasString(), err)
+	return err
+}
+
+func audit(action, ref string, err error) {
+	if err != nil {
+		log.Infof("audit: %s %s failed: %s", action, ref, err.Error())
+		return
+	}
+	log.Infof("audit: %s %s", action, ref)
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"sync"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// CacheRepo keeps references in memory, writing changes through to the
+// wrapped repo. Only lookups by peername & name are cached. If the wrapped
+// repo is a repo.RefsVersioner the cache is cleared whenever references
+// change, otherwise changes made by other processes aren't seen, and the
+// cache is only safe to use with a single process
+type CacheRepo struct {
+	repo.Repo
+	lock *sync.RWMutex
+	refs map[string]repo.DatasetRef
+	// versioner reports changes to the wrapped repo's references, nil if it
+	// can't
+	versioner repo.RefsVersioner
+	// version of the references cached in refs
+	version *uint64
+}
+
+// Cache is a Middleware that wraps a repo in a CacheRepo
+func Cache(r repo.Repo) (repo.Repo, error) {
+	c := CacheRepo{Repo: r, lock: &sync.RWMutex{}, refs: map[string]repo.DatasetRef{}, version: new(uint64)}
+	if v, ok := repo.Base(r).(repo.RefsVersioner); ok {
+		c.versioner = v
+	}
+	return c, nil
+}
+
+// Unwrap implements the repo.Wrapper interface
+func (r CacheRepo) Unwrap() repo.Repo {
+	return r.Repo
+}
+
+// PutRef implements the repo.Refstore interface
+func (r CacheRepo) PutRef(ref repo.DatasetRef) error {
+	if err := r.Repo.PutRef(ref); err != nil {
+		return err
+	}
+	r.lock.Lock()
+	r.refs[ref.AliasString()] = ref
+	r.lock.Unlock()
+	return nil
+}
+
+// GetRef implements the repo.Refstore interface
+func (r CacheRepo) GetRef(ref repo.DatasetRef) (repo.DatasetRef, error) {
+	if !cacheable(ref) {
+		return r.Repo.GetRef(ref)
+	}
+
+	if err := r.sync(); err != nil {
+		return repo.DatasetRef{}, err
+	}
+
+	key := ref.AliasString()
+	r.lock.RLock()
+	got, ok := r.refs[key]
+	r.lock.RUnlock()
+	if ok {
+		return got, nil
+	}
+
+	got, err := r.Repo.GetRef(ref)
+	if err != nil {
+		return got, err
+	}
+	r.lock.Lock()
+	r.refs[key] = got
+	r.lock.Unlock()
+	return got, nil
+}
+
+// DeleteRef implements the repo.Refstore interface
+func (r CacheRepo) DeleteRef(ref repo.DatasetRef) error {
+	err := r.Repo.DeleteRef(ref)
+	r.lock.Lock()
+	if ref.Peername != "" && ref.Name != "" {
+		delete(r.refs, ref.AliasString())
+	} else {
+		// without a name any cached reference could be the one removed
+		for key := range r.refs {
+			delete(r.refs, key)
+		}
+	}
+	r.lock.Unlock()
+	return err
+}
+
+// sync clears the cache if the wrapped repo's references changed since they
+// were cached
+func (r CacheRepo) sync() error {
+	if r.versioner == nil {
+		return nil
+	}
+	version, err := r.versioner.RefsVersion()
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if version != *r.version {
+		for key := range r.refs {
+			delete(r.refs, key)
+		}
+		*r.version = version
+	}
+	return nil
+}
+
+// cacheable returns true for references looked up by peername & name
+func cacheable(ref repo.DatasetRef) bool {
+	return ref.Peername != "" && ref.Name != "" && ref.Path == ""
+}
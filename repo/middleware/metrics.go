@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// MetricsRepo counts calls to a repo's references, lineage & event log
+type MetricsRepo struct {
+	repo.Repo
+	lock   *sync.Mutex
+	counts map[string]int
+}
+
+// Metrics is a Middleware that wraps a repo in a MetricsRepo
+func Metrics(r repo.Repo) (repo.Repo, error) {
+	return MetricsRepo{Repo: r, lock: &sync.Mutex{}, counts: map[string]int{}}, nil
+}
+
+// Unwrap implements the repo.Wrapper interface
+func (r MetricsRepo) Unwrap() repo.Repo {
+	return r.Repo
+}
+
+// Counts returns the number of calls made to each method
+func (r MetricsRepo) Counts() map[string]int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	counts := make(map[string]int, len(r.counts))
+	for method, n := range r.counts {
+		counts[method] = n
+	}
+	return counts
+}
+
+func (r MetricsRepo) count(method string) {
+	r.lock.Lock()
+	r.counts[method]++
+	r.lock.Unlock()
+}
+
+// PutRef implements the repo.Refstore interface
+func (r MetricsRepo) PutRef(ref repo.DatasetRef) error {
+	r.count("PutRef")
+	return r.Repo.PutRef(ref)
+}
+
+// GetRef implements the repo.Refstore interface
+func (r MetricsRepo) GetRef(ref repo.DatasetRef) (repo.DatasetRef, error) {
+	r.count("GetRef")
+	return r.Repo.GetRef(ref)
+}
+
+// DeleteRef implements the repo.Refstore interface
+func (r MetricsRepo) DeleteRef(ref repo.DatasetRef) error {
+	r.count("DeleteRef")
+	return r.Repo.DeleteRef(ref)
+}
+
+// References implements the repo.Refstore interface
+func (r MetricsRepo) References(limit, offset int) ([]repo.DatasetRef, error) {
+	r.count("References")
+	return r.Repo.References(limit, offset)
+}
+
+// RefCount implements the repo.Refstore interface
+func (r MetricsRepo) RefCount() (int, error) {
+	r.count("RefCount")
+	return r.Repo.RefCount()
+}
+
+// PutUpstreams implements the repo.Lineage interface
+func (r MetricsRepo) PutUpstreams(ref repo.DatasetRef, upstreams []repo.DatasetRef) error {
+	r.count("PutUpstreams")
+	return r.Repo.PutUpstreams(ref, upstreams)
+}
+
+// Upstreams implements the repo.Lineage interface
+func (r MetricsRepo) Upstreams(ref repo.DatasetRef) ([]repo.DatasetRef, error) {
+	r.count("Upstreams")
+	return r.Repo.Upstreams(ref)
+}
+
+// Downstreams implements the repo.Lineage interface
+func (r MetricsRepo) Downstreams(ref repo.DatasetRef) ([]repo.DatasetRef, error) {
+	r.count("Downstreams")
+	return r.Repo.Downstreams(ref)
+}
+
+// LogEvent implements the repo.EventLog interface
+func (r MetricsRepo) LogEvent(t repo.EventType, ref repo.DatasetRef) error {
+	r.count("LogEvent")
+	return r.Repo.LogEvent(t, ref)
+}
+
+// Events implements the repo.EventLog interface
+func (r MetricsRepo) Events(limit, offset int) ([]*repo.Event, error) {
+	r.count("Events")
+	return r.Repo.Events(limit, offset)
+}
+
+// EventsSince implements the repo.EventLog interface
+func (r MetricsRepo) EventsSince(t time.Time) ([]*repo.Event, error) {
+	r.count("EventsSince")
+	return r.Repo.EventsSince(t)
+}
@@ -0,0 +1,76 @@
+// Package middleware wraps repos with extra behaviour. Middleware is
+// registered by name & applied in the order listed in the repo.middleware
+// config setting, so policy can be added to a repo without changing the
+// repo implementation
+package middleware
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	golog "github.com/ipfs/go-log"
+	"github.com/qri-io/qri/repo"
+)
+
+var log = golog.Logger("repo.middleware")
+
+// Middleware wraps a repo, returning a repo that adds behaviour. Wrapping
+// repos should implement repo.Wrapper
+type Middleware func(r repo.Repo) (repo.Repo, error)
+
+var (
+	registryLock sync.Mutex
+	registry     = map[string]Middleware{}
+)
+
+func init() {
+	Register("audit", Audit)
+	Register("readonly", ReadOnly)
+	Register("metrics", Metrics)
+	Register("cache", Cache)
+}
+
+// Register adds a middleware to the registry, replacing any middleware
+// already registered with the same name
+func Register(name string, m Middleware) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registry[name] = m
+}
+
+// Names lists registered middleware, sorted alphabetically
+func Names() []string {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wrap applies a chain of named middleware to a repo. The first name is the
+// outermost wrapper, seeing calls before the rest of the chain
+func Wrap(r repo.Repo, names []string) (repo.Repo, error) {
+	registryLock.Lock()
+	chain := make([]Middleware, len(names))
+	for i, name := range names {
+		m, ok := registry[name]
+		if !ok {
+			registryLock.Unlock()
+			return nil, fmt.Errorf("unknown repo middleware: '%s'", name)
+		}
+		chain[i] = m
+	}
+	registryLock.Unlock()
+
+	var err error
+	for i := len(chain) - 1; i >= 0; i-- {
+		if r, err = chain[i](r); err != nil {
+			return nil, fmt.Errorf("error applying repo middleware '%s': %s", names[i], err.Error())
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	testrepo "github.com/qri-io/qri/repo/test"
+)
+
+func TestWrap(t *testing.T) {
+	base, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, err := Wrap(base, []string{"audit", "nonexistent"}); err == nil {
+		t.Error("expected unknown middleware to error")
+	}
+
+	r, err := Wrap(base, []string{"metrics", "readonly", "cache", "audit"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, ok := r.(MetricsRepo); !ok {
+		t.Errorf("expected first middleware to be outermost, got: %T", r)
+	}
+	if repo.Base(r) != base {
+		t.Error("expected Base to unwrap the middleware chain")
+	}
+
+	ref, err := r.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.DeleteRef(ref); err != ErrReadOnly {
+		t.Errorf("expected read-only error, got: %v", err)
+	}
+	if err := r.LogEvent(repo.ETDsCreated, ref); err != ErrReadOnly {
+		t.Errorf("expected read-only error, got: %v", err)
+	}
+
+	counts := r.(MetricsRepo).Counts()
+	if counts["GetRef"] != 1 || counts["DeleteRef"] != 1 || counts["LogEvent"] != 1 {
+		t.Errorf("unexpected call counts: %v", counts)
+	}
+}
+
+func TestCache(t *testing.T) {
+	base, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	r, err := Cache(base)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	alias := repo.DatasetRef{Peername: "peer", Name: "cities"}
+	ref, err := r.GetRef(alias)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.DeleteRef(ref); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := r.GetRef(alias); err == nil {
+		t.Error("expected deleted ref to be evicted from the cache")
+	}
+
+	if err := r.PutRef(ref); err != nil {
+		t.Fatal(err.Error())
+	}
+	if got, err := base.GetRef(alias); err != nil || got.Path != ref.Path {
+		t.Errorf("expected put to write through to the wrapped repo. got: %s, err: %v", got, err)
+	}
+}
+
+// versionedRepo reports a version for references that tests bump by hand,
+// as if another process changed them
+type versionedRepo struct {
+	repo.Repo
+	version uint64
+}
+
+func (r *versionedRepo) RefsVersion() (uint64, error) {
+	return r.version, nil
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	tr, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	base := &versionedRepo{Repo: tr}
+	r, err := Cache(base)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	alias := repo.DatasetRef{Peername: "peer", Name: "cities"}
+	ref, err := r.GetRef(alias)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// remove the reference from under the cache
+	if err := base.Repo.DeleteRef(ref); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := r.GetRef(alias); err != nil {
+		t.Error("expected reference to be cached while the version is unchanged")
+	}
+	base.version++
+	if _, err := r.GetRef(alias); err == nil {
+		t.Error("expected a new version to clear the cache")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	base, err := testrepo.NewTestRepo()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	r, err := Wrap(base, []string{"audit", "readonly"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if repo.Writable(r) || !repo.Writable(base) {
+		t.Error("expected only the wrapped repo to be read-only")
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.Profiles().PutProfile(pro); err != ErrReadOnly {
+		t.Errorf("expected read-only error putting a profile, got: %v", err)
+	}
+	if err := r.Profiles().PutRelationship(pro.ID, profile.Relationship{Trust: profile.TrustBlocked}); err != ErrReadOnly {
+		t.Errorf("expected read-only error putting a relationship, got: %v", err)
+	}
+	if err := r.ChangeRequests().PutChangeRequest(&repo.ChangeRequest{ID: "cr"}); err != ErrReadOnly {
+		t.Errorf("expected read-only error putting a change request, got: %v", err)
+	}
+
+	ref, err := r.GetRef(repo.DatasetRef{Peername: "peer", Name: "movies"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.Store().Delete(datastore.NewKey(ref.Path)); err != ErrReadOnly {
+		t.Errorf("expected read-only error deleting a block, got: %v", err)
+	}
+	if repo.BaseStore(r.Store()) != base.Store() {
+		t.Error("expected BaseStore to unwrap the read-only store")
+	}
+}
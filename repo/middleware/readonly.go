@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// ErrReadOnly is returned when changing a read-only repo
+var ErrReadOnly = repo.ErrReadOnly
+
+// ReadOnlyRepo rejects changes to a repo's references, lineage, event log,
+// profiles & change requests. Blocks can still be added to the store, but
+// not deleted. ReadOnlyRepo is a repo.ReadOnlyWrapper, so opt-in writers
+// found through repo.Base refuse to run against it
+type ReadOnlyRepo struct {
+	repo.Repo
+}
+
+// ReadOnly is a Middleware that wraps a repo in a ReadOnlyRepo
+func ReadOnly(r repo.Repo) (repo.Repo, error) {
+	return ReadOnlyRepo{r}, nil
+}
+
+// Unwrap implements the repo.Wrapper interface
+func (r ReadOnlyRepo) Unwrap() repo.Repo {
+	return r.Repo
+}
+
+// ReadOnly implements the repo.ReadOnlyWrapper interface
+func (r ReadOnlyRepo) ReadOnly() bool {
+	return true
+}
+
+// Store implements the repo.Repo interface
+func (r ReadOnlyRepo) Store() cafs.Filestore {
+	return readOnlyStore{r.Repo.Store()}
+}
+
+// Profiles implements the repo.Repo interface
+func (r ReadOnlyRepo) Profiles() profile.Store {
+	return readOnlyProfiles{r.Repo.Profiles()}
+}
+
+// ChangeRequests implements the repo.Repo interface
+func (r ReadOnlyRepo) ChangeRequests() repo.ChangeRequestStore {
+	return readOnlyChangeRequests{r.Repo.ChangeRequests()}
+}
+
+// PutRef implements the repo.Refstore interface
+func (r ReadOnlyRepo) PutRef(ref repo.DatasetRef) error {
+	return ErrReadOnly
+}
+
+// DeleteRef implements the repo.Refstore interface
+func (r ReadOnlyRepo) DeleteRef(ref repo.DatasetRef) error {
+	return ErrReadOnly
+}
+
+// PutUpstreams implements the repo.Lineage interface
+func (r ReadOnlyRepo) PutUpstreams(ref repo.DatasetRef, upstreams []repo.DatasetRef) error {
+	return ErrReadOnly
+}
+
+// SetStale implements the repo.Lineage interface
+func (r ReadOnlyRepo) SetStale(ref repo.DatasetRef, stale bool) error {
+	return ErrReadOnly
+}
+
+// DeleteLineage implements the repo.Lineage interface
+func (r ReadOnlyRepo) DeleteLineage(ref repo.DatasetRef) error {
+	return ErrReadOnly
+}
+
+// LogEvent implements the repo.EventLog interface
+func (r ReadOnlyRepo) LogEvent(t repo.EventType, ref repo.DatasetRef) error {
+	return ErrReadOnly
+}
+
+// SetProfile implements the repo.Repo interface
+func (r ReadOnlyRepo) SetProfile(p *profile.Profile) error {
+	return ErrReadOnly
+}
+
+// readOnlyStore rejects deleting blocks
+type readOnlyStore struct {
+	cafs.Filestore
+}
+
+// UnwrapStore implements the repo.StoreWrapper interface
+func (s readOnlyStore) UnwrapStore() cafs.Filestore {
+	return s.Filestore
+}
+
+// Delete implements the cafs.Filestore interface
+func (s readOnlyStore) Delete(key datastore.Key) error {
+	return ErrReadOnly
+}
+
+// readOnlyProfiles rejects changes to profiles & relationships
+type readOnlyProfiles struct {
+	profile.Store
+}
+
+// PutProfile implements the profile.Store interface
+func (s readOnlyProfiles) PutProfile(p *profile.Profile) error {
+	return ErrReadOnly
+}
+
+// DeleteProfile implements the profile.Store interface
+func (s readOnlyProfiles) DeleteProfile(id profile.ID) error {
+	return ErrReadOnly
+}
+
+// PutRelationship implements the profile.Store interface
+func (s readOnlyProfiles) PutRelationship(id profile.ID, rel profile.Relationship) error {
+	return ErrReadOnly
+}
+
+// readOnlyChangeRequests rejects changes to change requests
+type readOnlyChangeRequests struct {
+	repo.ChangeRequestStore
+}
+
+// PutChangeRequest implements the repo.ChangeRequestStore interface
+func (s readOnlyChangeRequests) PutChangeRequest(cr *repo.ChangeRequest) error {
+	return ErrReadOnly
+}
+
+// DeleteChangeRequest implements the repo.ChangeRequestStore interface
+func (s readOnlyChangeRequests) DeleteChangeRequest(id string) error {
+	return ErrReadOnly
+}
@@ -1,11 +1,14 @@
 package profile
 
 import (
+	"encoding/base64"
+	"fmt"
 	"time"
 
 	"github.com/ipfs/go-datastore"
 	// ma "gx/ipfs/QmXY77cVe7rVRQXZZQRioukUM7aRW3BTcAgJe12MCtb3Ji/go-multiaddr"
 	peer "gx/ipfs/QmZoWKhxUmZ2seW4BzX6fJkNR8hh9PsGModr7q171yq2SS/go-libp2p-peer"
+	crypto "gx/ipfs/QmaPbCnUMBohSGo3KnxEa2bHqyJVVeEEcwtqJAYxerieBo/go-libp2p-crypto"
 )
 
 // Profile defines peer profile details
@@ -37,6 +40,8 @@ type Profile struct {
 	Poster datastore.Key `json:"poster"`
 	// Twitter is a  peer's twitter handle
 	Twitter string `json:"twitter"`
+	// PubKey is the base64-encoded public key this profile signs datasets with
+	PubKey string `json:"pubkey,omitempty"`
 	// Addresses lists any network addresses associated with this profile
 	// in the form of peer.ID.Pretty() : []multiaddr strings
 	// both peer.IDs and multiaddresses are converted to strings for
@@ -44,6 +49,34 @@ type Profile struct {
 	Addresses map[string][]string `json:"addresses"`
 }
 
+// PublicKey decodes this profile's public key, confirming the key hashes to
+// the profile's ID
+func (p *Profile) PublicKey() (crypto.PubKey, error) {
+	if p.PubKey == "" {
+		return nil, fmt.Errorf("profile %s has no public key", p.ID)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(p.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding public key: %s", err.Error())
+	}
+
+	pub, err := crypto.UnmarshalPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding public key: %s", err.Error())
+	}
+
+	pid, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if ID(pid) != p.ID {
+		return nil, fmt.Errorf("public key doesn't match profile %s", p.ID)
+	}
+
+	return pub, nil
+}
+
 // PeerIDs sifts through listed multaddrs looking for an IPFS peer ID
 func (p *Profile) PeerIDs() (ids []peer.ID) {
 	for idstr := range p.Addresses {
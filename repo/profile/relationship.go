@@ -0,0 +1,49 @@
+package profile
+
+import (
+	"fmt"
+)
+
+// Trust is how far this peer trusts a profile
+type Trust string
+
+const (
+	// TrustNeutral is the default level for profiles this peer has seen
+	TrustNeutral = Trust("neutral")
+	// TrustTrusted profiles are preferred when fetching data
+	TrustTrusted = Trust("trusted")
+	// TrustBlocked profiles are hidden from lists & search results, aren't
+	// fetched from, and have their messages dropped
+	TrustBlocked = Trust("blocked")
+)
+
+// ParseTrust decodes a trust level from a string. an empty string is neutral
+func ParseTrust(s string) (Trust, error) {
+	got, ok := map[string]Trust{"": TrustNeutral, "neutral": TrustNeutral, "trusted": TrustTrusted, "blocked": TrustBlocked}[s]
+	if !ok {
+		return TrustNeutral, fmt.Errorf("invalid trust level %q. must be one of trusted, neutral or blocked", s)
+	}
+	return got, nil
+}
+
+// Relationship is this peer's relationship with another profile. It's kept
+// separate from profile data, which comes from the other peer
+type Relationship struct {
+	// Following is true when this peer follows the profile
+	Following bool `json:"following"`
+	// Trust is how far this peer trusts the profile
+	Trust Trust `json:"trust"`
+}
+
+// IsDefault is true for relationships that are unfollowed & neutral, which
+// stores don't need to keep
+func (rel Relationship) IsDefault() bool {
+	return !rel.Following && (rel.Trust == "" || rel.Trust == TrustNeutral)
+}
+
+// Blocked is a convenience for checking if a store blocks a profile. Any
+// error reading the relationship is treated as not blocked
+func Blocked(s Store, id ID) bool {
+	rel, err := s.Relationship(id)
+	return err == nil && rel.Trust == TrustBlocked
+}
@@ -18,9 +18,24 @@ type Store interface {
 	GetProfile(id ID) (*Profile, error)
 	PeerProfile(id peer.ID) (*Profile, error)
 	DeleteProfile(id ID) error
+
+	// Relationship gives this peer's relationship with a profile. Profiles
+	// without one are unfollowed & neutral
+	Relationship(id ID) (Relationship, error)
+	// PutRelationship sets this peer's relationship with a profile. The
+	// profile doesn't need to be in the store, and deleting a profile keeps
+	// it's relationship
+	PutRelationship(id ID, rel Relationship) error
+	// Relationships lists every profile this peer follows, trusts or blocks
+	Relationships() (map[ID]Relationship, error)
 }
 
-// MemStore is an in-memory implementation of the profile Store interface
+// ErrNoRelationships is returned by stores that don't keep relationships
+var ErrNoRelationships = fmt.Errorf("profile: store doesn't keep relationships")
+
+// MemStore is an in-memory implementation of the profile Store interface.
+// MemStore only keeps profiles: every profile has the default relationship,
+// and PutRelationship errors. Pair it with MemRelationships to keep both
 type MemStore map[ID]*Profile
 
 // PutProfile adds a peer to this store
@@ -89,3 +104,58 @@ func (m MemStore) DeleteProfile(id ID) error {
 	delete(m, id)
 	return nil
 }
+
+// Relationship gives the default relationship, MemStore doesn't keep any
+func (m MemStore) Relationship(id ID) (Relationship, error) {
+	return Relationship{Trust: TrustNeutral}, nil
+}
+
+// PutRelationship returns ErrNoRelationships
+func (m MemStore) PutRelationship(id ID, rel Relationship) error {
+	return ErrNoRelationships
+}
+
+// Relationships gives an empty list, MemStore doesn't keep any
+func (m MemStore) Relationships() (map[ID]Relationship, error) {
+	return map[ID]Relationship{}, nil
+}
+
+// MemRelationships is an in-memory store of this peer's relationships with
+// other profiles
+type MemRelationships map[ID]Relationship
+
+// Relationship gives this peer's relationship with a profile
+func (m MemRelationships) Relationship(id ID) (Relationship, error) {
+	if rel, ok := m[id]; ok {
+		return rel, nil
+	}
+	return Relationship{Trust: TrustNeutral}, nil
+}
+
+// PutRelationship sets this peer's relationship with a profile
+func (m MemRelationships) PutRelationship(id ID, rel Relationship) error {
+	if id.String() == "" {
+		return fmt.Errorf("profile.ID is required")
+	}
+	trust, err := ParseTrust(string(rel.Trust))
+	if err != nil {
+		return err
+	}
+	rel.Trust = trust
+
+	if rel.IsDefault() {
+		delete(m, id)
+		return nil
+	}
+	m[id] = rel
+	return nil
+}
+
+// Relationships lists every relationship in the store
+func (m MemRelationships) Relationships() (map[ID]Relationship, error) {
+	res := map[ID]Relationship{}
+	for id, rel := range m {
+		res[id] = rel
+	}
+	return res, nil
+}
@@ -57,8 +57,31 @@ type DatasetRef struct {
 	Path string `json:"path,omitempty"`
 	// Dataset is a pointer to the dataset being referenced
 	Dataset *dataset.Dataset `json:"dataset,omitempty"`
+	// Verification is the result of checking the dataset's commit signature
+	// against it's author's public key. It's computed on read & never stored
+	Verification SignatureStatus `json:"verification,omitempty"`
+	// Stale is true when a dataset this dataset was derived from has changed
+	// since it was created. Like Verification it's computed on read
+	Stale bool `json:"stale,omitempty"`
 }
 
+// SignatureStatus enumerates the possible outcomes of verifying a commit
+// signature
+type SignatureStatus string
+
+const (
+	// SigUnchecked is the zero value: no verification has been attempted
+	SigUnchecked = SignatureStatus("")
+	// SigValid means the commit signature matches the author's public key
+	SigValid = SignatureStatus("valid")
+	// SigUnsigned means the commit carries no signature
+	SigUnsigned = SignatureStatus("unsigned")
+	// SigInvalid means the signature doesn't match the author's public key
+	SigInvalid = SignatureStatus("invalid")
+	// SigUnknownKey means the author's public key isn't known to this repo
+	SigUnknownKey = SignatureStatus("unknown key")
+)
+
 // String implements the Stringer interface for DatasetRef
 func (r DatasetRef) String() (s string) {
 	s = r.AliasString()
@@ -31,6 +31,8 @@ var (
 	ErrRepoEmpty = fmt.Errorf("repo: this repo contains no datasets")
 	// ErrNotPinner is for when the repo doesn't have the concept of pinning as a feature
 	ErrNotPinner = fmt.Errorf("repo: backing store doesn't support pinning")
+	// ErrReadOnly is for when changing a read-only repo
+	ErrReadOnly = fmt.Errorf("repo: repo is read-only")
 )
 
 // Repo is the interface for working with a qri repository qri repos are stored
@@ -54,6 +56,10 @@ type Repo interface {
 	Refstore
 	// EventLog keeps a log of Profile activity for this repo
 	EventLog
+	// Lineage records which datasets were derived from which
+	Lineage
+	// EventSubscriber delivers events as they're logged
+	EventSubscriber
 
 	// A repository must maintain profile information about the owner of this dataset.
 	// The value returned by Profile() should represent the peer.
@@ -73,6 +79,10 @@ type Repo interface {
 	// TODO - should rename this to "profiles" to separate from the networking
 	// concept of a peer
 	Profiles() profile.Store
+	// ChangeRequests keeps change requests proposed to & from this repo
+	ChangeRequests() ChangeRequestStore
+	// Analytics keeps local counts of how this repo's datasets are used
+	Analytics() Analytics
 }
 
 // SearchParams encapsulates parameters provided to Searchable.Search
@@ -86,6 +96,24 @@ type Searchable interface {
 	Search(p SearchParams) ([]DatasetRef, error)
 }
 
+// Indexer is an opt-in interface for repos that keep a search index of
+// their refstore
+type Indexer interface {
+	// IndexHas returns true if a dataset path is in the search index
+	IndexHas(path string) (bool, error)
+	// IndexCount gives the number of documents in the search index
+	IndexCount() (int, error)
+	// Reindex rebuilds the search index from the refstore
+	Reindex() error
+}
+
+// RefsVersioner is an opt-in interface for repos that can tell when their
+// references change, including changes made by other processes
+type RefsVersioner interface {
+	// RefsVersion gives a value that changes every time references change
+	RefsVersion() (uint64, error)
+}
+
 // MustProfile loads a repo's profile data, panicing if any error is encountered
 func MustProfile(r Repo) *profile.Profile {
 	p, err := r.Profile()
@@ -94,3 +122,65 @@ func MustProfile(r Repo) *profile.Profile {
 	}
 	return p
 }
+
+// Wrapper is implemented by repos that wrap another repo, like repo
+// middleware
+type Wrapper interface {
+	// Unwrap gives the repo being wrapped
+	Unwrap() Repo
+}
+
+// Base returns the innermost repo of a chain of Wrappers. opt-in interfaces
+// like Searchable & Indexer should be checked against Base, as wrappers
+// won't implement them
+func Base(r Repo) Repo {
+	for {
+		w, ok := r.(Wrapper)
+		if !ok {
+			return r
+		}
+		r = w.Unwrap()
+	}
+}
+
+// ReadOnlyWrapper is implemented by wrappers that reject changes to the repo
+// they wrap
+type ReadOnlyWrapper interface {
+	ReadOnly() bool
+}
+
+// Writable is false if any wrapper in a chain of Wrappers is read-only.
+// opt-in interfaces that change a repo are found with Base, skipping
+// wrappers, so callers must check Writable before using them
+func Writable(r Repo) bool {
+	for {
+		if ro, ok := r.(ReadOnlyWrapper); ok && ro.ReadOnly() {
+			return false
+		}
+		w, ok := r.(Wrapper)
+		if !ok {
+			return true
+		}
+		r = w.Unwrap()
+	}
+}
+
+// StoreWrapper is implemented by stores that wrap another store, like the
+// stores of repo middleware
+type StoreWrapper interface {
+	// UnwrapStore gives the store being wrapped
+	UnwrapStore() cafs.Filestore
+}
+
+// BaseStore returns the innermost store of a chain of StoreWrappers. checks
+// for concrete store types like *ipfs.Filestore should be made against
+// BaseStore
+func BaseStore(s cafs.Filestore) cafs.Filestore {
+	for {
+		w, ok := s.(StoreWrapper)
+		if !ok {
+			return s
+		}
+		s = w.UnwrapStore()
+	}
+}
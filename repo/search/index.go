@@ -103,15 +103,48 @@ func buildIndexMapping() (mapping.IndexMapping, error) {
 	return indexMapping, nil
 }
 
-// IndexRepo calculates an index for a given repository
+// IndexRepo calculates an index for a given repository, removing documents
+// for paths that are no longer in the repo's refstore
 func IndexRepo(r repo.Repo, i bleve.Index) error {
 	refs, err := r.References(-1, 0)
 	if err != nil {
 		return err
 	}
+	if err := removeStale(i, refs); err != nil {
+		return err
+	}
 	return indexDatasetRefs(r.Store(), i, refs)
 }
 
+// removeStale deletes every document from the index that isn't the path of
+// one of refs
+func removeStale(i bleve.Index, refs []repo.DatasetRef) error {
+	count, err := i.DocCount()
+	if err != nil || count == 0 {
+		return err
+	}
+
+	keep := map[string]bool{}
+	for _, ref := range refs {
+		keep[ref.Path] = true
+	}
+
+	res, err := i.Search(bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), int(count), 0, false))
+	if err != nil {
+		return err
+	}
+	batch := i.NewBatch()
+	for _, hit := range res.Hits {
+		if !keep[hit.ID] {
+			batch.Delete(hit.ID)
+		}
+	}
+	if batch.Size() == 0 {
+		return nil
+	}
+	return i.Batch(batch)
+}
+
 func indexDatasetRefs(store cafs.Filestore, i bleve.Index, refs []repo.DatasetRef) error {
 	log.Printf("Indexing...")
 	count := 0
@@ -0,0 +1,70 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Analytics is a SQLite implementation of the repo.Analytics interface, with
+// a row of counts per day per dataset
+type Analytics struct {
+	db *sql.DB
+}
+
+// usageColumns maps usage kinds to the columns that count them
+var usageColumns = map[repo.UsageKind]string{
+	repo.UsageServed:   "served",
+	repo.UsageListed:   "listed",
+	repo.UsageRead:     "read",
+	repo.UsageExported: "exported",
+}
+
+// CountUsage adds one to the count of kind for each ref, on the day t falls on
+func (a Analytics) CountUsage(kind repo.UsageKind, t time.Time, refs ...repo.DatasetRef) error {
+	for _, ref := range refs {
+		if ref.Peername == "" {
+			return repo.ErrPeernameRequired
+		} else if ref.Name == "" {
+			return repo.ErrNameRequired
+		}
+	}
+	col, ok := usageColumns[kind]
+	if !ok {
+		return nil
+	}
+
+	day := t.UTC().Format(repo.UsageDayFormat)
+	return tx(a.db, func(tx *sql.Tx) error {
+		for _, ref := range refs {
+			alias := ref.AliasString()
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO analytics (day, ref) VALUES (?, ?)`, day, alias); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`UPDATE analytics SET `+col+` = `+col+` + 1 WHERE day = ? AND ref = ?`, day, alias); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Usage lists daily usage from the day since falls on, oldest first
+func (a Analytics) Usage(since time.Time) ([]*repo.DailyUsage, error) {
+	rows, err := a.db.Query(`SELECT day, ref, served, listed, read, exported FROM analytics WHERE day >= ? ORDER BY day, ref`, since.UTC().Format(repo.UsageDayFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []*repo.DailyUsage{}
+	for rows.Next() {
+		u := &repo.DailyUsage{}
+		if err := rows.Scan(&u.Day, &u.Ref, &u.Served, &u.Listed, &u.Read, &u.Exported); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
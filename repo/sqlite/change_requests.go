@@ -0,0 +1,78 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// ChangeRequests is a SQLite implementation of the repo.ChangeRequestStore
+// interface. Change requests are stored as json, indexed by creation time
+type ChangeRequests struct {
+	db *sql.DB
+}
+
+// PutChangeRequest adds a change request, replacing any with the same ID
+func (c ChangeRequests) PutChangeRequest(cr *repo.ChangeRequest) error {
+	if cr.ID == "" {
+		return repo.ErrPathRequired
+	}
+	data, err := json.Marshal(cr)
+	if err != nil {
+		return err
+	}
+	_, err = c.db.Exec(`INSERT OR REPLACE INTO change_requests (id, created, data) VALUES (?, ?, ?)`, cr.ID, cr.Created.UnixNano(), string(data))
+	return err
+}
+
+// GetChangeRequest gets a change request by ID
+func (c ChangeRequests) GetChangeRequest(id string) (*repo.ChangeRequest, error) {
+	var data string
+	err := c.db.QueryRow(`SELECT data FROM change_requests WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, repo.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	cr := &repo.ChangeRequest{}
+	err = json.Unmarshal([]byte(data), cr)
+	return cr, err
+}
+
+// ListChangeRequests lists change requests, newest first
+func (c ChangeRequests) ListChangeRequests(limit, offset int) ([]*repo.ChangeRequest, error) {
+	rows, err := c.db.Query(`SELECT data FROM change_requests ORDER BY created DESC, id LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	crs := []*repo.ChangeRequest{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		cr := &repo.ChangeRequest{}
+		if err := json.Unmarshal([]byte(data), cr); err != nil {
+			return nil, err
+		}
+		crs = append(crs, cr)
+	}
+	return crs, rows.Err()
+}
+
+// DeleteChangeRequest removes a change request
+func (c ChangeRequests) DeleteChangeRequest(id string) error {
+	res, err := c.db.Exec(`DELETE FROM change_requests WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
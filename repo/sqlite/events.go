@@ -0,0 +1,82 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// EventLog is a SQLite implementation of the repo.EventLog interface
+type EventLog struct {
+	db *sql.DB
+	// optional bus to publish logged events to
+	bus *repo.EventBus
+}
+
+// LogEvent adds an event to the log
+func (l EventLog) LogEvent(t repo.EventType, ref repo.DatasetRef) error {
+	e := &repo.Event{Time: time.Now(), Type: t, Ref: ref}
+	if _, err := l.db.Exec(`INSERT INTO events (time, type, ref) VALUES (?, ?, ?)`, e.Time.UnixNano(), string(e.Type), e.Ref.String()); err != nil {
+		return err
+	}
+	if l.bus != nil {
+		l.bus.Publish(e)
+	}
+	return nil
+}
+
+// ImportEvents adds events to the log with their original timestamps,
+// without publishing them to subscribers. Events already in the log are
+// skipped
+func (l EventLog) ImportEvents(events []*repo.Event) error {
+	return tx(l.db, func(tx *sql.Tx) error {
+		for _, e := range events {
+			nsec, typ, ref := e.Time.UnixNano(), string(e.Type), e.Ref.String()
+			if _, err := tx.Exec(`INSERT INTO events (time, type, ref)
+				SELECT ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM events WHERE time = ? AND type = ? AND ref = ?)`,
+				nsec, typ, ref, nsec, typ, ref); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Events gives events from the log, newest first
+func (l EventLog) Events(limit, offset int) ([]*repo.Event, error) {
+	rows, err := l.db.Query(`SELECT time, type, ref FROM events ORDER BY time DESC, id DESC LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+// EventsSince gives events logged after t, oldest first
+func (l EventLog) EventsSince(t time.Time) ([]*repo.Event, error) {
+	rows, err := l.db.Query(`SELECT time, type, ref FROM events WHERE time > ? ORDER BY time, id`, t.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+func scanEvents(rows *sql.Rows) ([]*repo.Event, error) {
+	defer rows.Close()
+	events := []*repo.Event{}
+	for rows.Next() {
+		var (
+			nsec     int64
+			typ, ref string
+		)
+		if err := rows.Scan(&nsec, &typ, &ref); err != nil {
+			return nil, err
+		}
+		e := &repo.Event{Time: time.Unix(0, nsec), Type: repo.EventType(typ)}
+		if r, err := repo.ParseDatasetRef(ref); err == nil {
+			e.Ref = r
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,104 @@
+package sqliterepo
+
+import (
+	"database/sql"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Lineage is a SQLite implementation of the repo.Lineage interface
+type Lineage struct {
+	db *sql.DB
+}
+
+// PutUpstreams records the datasets ref was derived from, replacing any
+// previously recorded upstreams. Staleness is kept
+func (l Lineage) PutUpstreams(ref repo.DatasetRef, upstreams []repo.DatasetRef) error {
+	if ref.Peername == "" {
+		return repo.ErrPeernameRequired
+	} else if ref.Name == "" {
+		return repo.ErrNameRequired
+	}
+
+	alias := ref.AliasString()
+	return tx(l.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO lineage (ref) VALUES (?)`, alias); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM upstreams WHERE ref = ?`, alias); err != nil {
+			return err
+		}
+		for _, up := range upstreams {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO upstreams (ref, upstream) VALUES (?, ?)`, alias, up.AliasString()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Upstreams lists the datasets ref was derived from
+func (l Lineage) Upstreams(ref repo.DatasetRef) ([]repo.DatasetRef, error) {
+	rows, err := l.db.Query(`SELECT upstream FROM upstreams WHERE ref = ? ORDER BY upstream`, ref.AliasString())
+	if err != nil {
+		return nil, err
+	}
+	refs, err := scanAliases(rows)
+	if len(refs) == 0 {
+		return nil, err
+	}
+	return refs, err
+}
+
+// Downstreams lists the datasets derived from ref
+func (l Lineage) Downstreams(ref repo.DatasetRef) ([]repo.DatasetRef, error) {
+	rows, err := l.db.Query(`SELECT ref FROM upstreams WHERE upstream = ? ORDER BY ref`, ref.AliasString())
+	if err != nil {
+		return nil, err
+	}
+	return scanAliases(rows)
+}
+
+// SetStale marks weather a derived dataset is out of date with it's upstreams
+func (l Lineage) SetStale(ref repo.DatasetRef, stale bool) error {
+	res, err := l.db.Exec(`UPDATE lineage SET stale = ? WHERE ref = ?`, stale, ref.AliasString())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+// IsStale reports weather a derived dataset is out of date
+func (l Lineage) IsStale(ref repo.DatasetRef) (stale bool, err error) {
+	err = l.db.QueryRow(`SELECT stale FROM lineage WHERE ref = ?`, ref.AliasString()).Scan(&stale)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return
+}
+
+// DeleteLineage drops the lineage record for ref
+func (l Lineage) DeleteLineage(ref repo.DatasetRef) error {
+	_, err := l.db.Exec(`DELETE FROM lineage WHERE ref = ?`, ref.AliasString())
+	return err
+}
+
+func scanAliases(rows *sql.Rows) ([]repo.DatasetRef, error) {
+	defer rows.Close()
+	refs := []repo.DatasetRef{}
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		if ref, err := repo.ParseDatasetRef(alias); err == nil {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, rows.Err()
+}
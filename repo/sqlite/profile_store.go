@@ -0,0 +1,127 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/qri-io/doggos"
+	"github.com/qri-io/qri/repo/profile"
+
+	"gx/ipfs/QmZoWKhxUmZ2seW4BzX6fJkNR8hh9PsGModr7q171yq2SS/go-libp2p-peer"
+)
+
+// ProfileStore is a SQLite implementation of the profile.Store interface.
+// Profiles are stored as json, with indexes by peername & peer ID
+type ProfileStore struct {
+	db *sql.DB
+}
+
+// PutProfile adds a profile to the store, replacing any profile with the
+// same ID
+func (ps ProfileStore) PutProfile(p *profile.Profile) error {
+	if p.ID.String() == "" {
+		return fmt.Errorf("profile ID is required")
+	}
+	if p.Peername == "" {
+		p.Peername = doggos.DoggoNick(p.ID.String())
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	id := p.ID.String()
+	return tx(ps.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO profiles (id, peername, data) VALUES (?, ?, ?)`, id, p.Peername, string(data)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM profile_peers WHERE profile_id = ?`, id); err != nil {
+			return err
+		}
+		for peerID := range p.Addresses {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO profile_peers (peer_id, profile_id) VALUES (?, ?)`, peerID, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PeerIDs gives the peer.IDs list for a given profile
+func (ps ProfileStore) PeerIDs(id profile.ID) ([]peer.ID, error) {
+	p, err := ps.GetProfile(id)
+	if err != nil {
+		return nil, err
+	}
+	return p.PeerIDs(), nil
+}
+
+// List hands back every profile in the store
+func (ps ProfileStore) List() (map[profile.ID]*profile.Profile, error) {
+	rows, err := ps.db.Query(`SELECT data FROM profiles`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pros := map[profile.ID]*profile.Profile{}
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		pros[p.ID] = p
+	}
+	return pros, rows.Err()
+}
+
+// PeernameID gives the profile.ID for a given peername
+func (ps ProfileStore) PeernameID(peername string) (profile.ID, error) {
+	var id string
+	err := ps.db.QueryRow(`SELECT id FROM profiles WHERE peername = ? LIMIT 1`, peername).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", profile.ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return profile.IDB58Decode(id)
+}
+
+// GetProfile fetches a profile from the store
+func (ps ProfileStore) GetProfile(id profile.ID) (*profile.Profile, error) {
+	return ps.queryProfile(`SELECT data FROM profiles WHERE id = ?`, id.String())
+}
+
+// PeerProfile gives the profile that corresponds with a given peer.ID
+func (ps ProfileStore) PeerProfile(id peer.ID) (*profile.Profile, error) {
+	return ps.queryProfile(`SELECT profiles.data FROM profiles
+		JOIN profile_peers ON profile_peers.profile_id = profiles.id
+		WHERE profile_peers.peer_id = ? LIMIT 1`, id.Pretty())
+}
+
+// DeleteProfile removes a profile from the store
+func (ps ProfileStore) DeleteProfile(id profile.ID) error {
+	_, err := ps.db.Exec(`DELETE FROM profiles WHERE id = ?`, id.String())
+	return err
+}
+
+func (ps ProfileStore) queryProfile(query string, args ...interface{}) (*profile.Profile, error) {
+	p, err := scanProfile(ps.db.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, profile.ErrNotFound
+	}
+	return p, err
+}
+
+func scanProfile(row scanner) (*profile.Profile, error) {
+	var data string
+	if err := row.Scan(&data); err != nil {
+		return nil, err
+	}
+	p := &profile.Profile{}
+	if err := json.Unmarshal([]byte(data), p); err != nil {
+		return nil, fmt.Errorf("error decoding profile: %s", err.Error())
+	}
+	return p, nil
+}
@@ -0,0 +1,183 @@
+package sqliterepo
+
+import (
+	"database/sql"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// Refstore is a SQLite implementation of the repo.Refstore interface
+type Refstore struct {
+	db *sql.DB
+	// filestore for checking dataset integrity & reading dataset metadata
+	store cafs.Filestore
+}
+
+// PutRef adds a reference to the store
+func (rs Refstore) PutRef(put repo.DatasetRef) error {
+	return rs.PutRefs([]repo.DatasetRef{put})
+}
+
+// PutRefs adds a set of references to the store in a single transaction.
+// If any reference can't be added, none are
+func (rs Refstore) PutRefs(puts []repo.DatasetRef) error {
+	rows := make([]refRow, len(puts))
+	for i, put := range puts {
+		if put.ProfileID == "" {
+			return repo.ErrPeerIDRequired
+		} else if put.Name == "" {
+			return repo.ErrNameRequired
+		} else if put.Path == "" {
+			return repo.ErrPathRequired
+		} else if put.Peername == "" {
+			return repo.ErrPeernameRequired
+		}
+
+		rows[i].ref = repo.DatasetRef{Peername: put.Peername, ProfileID: put.ProfileID, Name: put.Name, Path: put.Path}
+		if rs.store != nil {
+			ds, err := dsfs.LoadDataset(rs.store, datastore.NewKey(put.Path))
+			if err != nil {
+				return err
+			}
+			if ds.Meta != nil {
+				rows[i].title = ds.Meta.Title
+				rows[i].description = ds.Meta.Description
+			}
+		}
+	}
+
+	return tx(rs.db, func(tx *sql.Tx) error {
+		for _, row := range rows {
+			ref, err := findRef(tx, row.ref)
+			if err == nil {
+				if ref.Equal(row.ref) {
+					continue
+				}
+				return repo.ErrNameTaken
+			} else if err != repo.ErrNotFound {
+				return err
+			}
+
+			_, err = tx.Exec(`INSERT INTO refs (path, peername, profile_id, name, title, description) VALUES (?, ?, ?, ?, ?, ?)`,
+				row.ref.Path, row.ref.Peername, row.ref.ProfileID.String(), row.ref.Name, row.title, row.description)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetRef completes a partially-known reference
+func (rs Refstore) GetRef(get repo.DatasetRef) (ref repo.DatasetRef, err error) {
+	err = tx(rs.db, func(tx *sql.Tx) error {
+		ref, err = findRef(tx, get)
+		return err
+	})
+	return
+}
+
+// DeleteRef removes a reference from the store
+func (rs Refstore) DeleteRef(del repo.DatasetRef) error {
+	return tx(rs.db, func(tx *sql.Tx) error {
+		ref, err := findRef(tx, del)
+		if err == repo.ErrNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`DELETE FROM refs WHERE path = ?`, ref.Path)
+		return err
+	})
+}
+
+// References gives a set of dataset references from the store, ordered by
+// peername & name
+func (rs Refstore) References(limit, offset int) ([]repo.DatasetRef, error) {
+	rows, err := rs.db.Query(`SELECT path, peername, profile_id, name FROM refs ORDER BY peername, name LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanRefs(rows)
+}
+
+// PeerReferences lists references belonging to a single peer, ordered by
+// name
+func (rs Refstore) PeerReferences(peername string, limit, offset int) ([]repo.DatasetRef, error) {
+	rows, err := rs.db.Query(`SELECT path, peername, profile_id, name FROM refs WHERE peername = ? ORDER BY name LIMIT ? OFFSET ?`, peername, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanRefs(rows)
+}
+
+// RefCount returns the number of references in the store
+func (rs Refstore) RefCount() (count int, err error) {
+	err = rs.db.QueryRow(`SELECT COUNT(*) FROM refs`).Scan(&count)
+	return
+}
+
+// refRow is a reference with the dataset metadata stored alongside it
+type refRow struct {
+	ref         repo.DatasetRef
+	title       string
+	description string
+}
+
+// findRef looks up the stored reference that matches ref, using the same
+// rules as DatasetRef.Match: equal paths, or equal names with an equal
+// profileID or peername
+func findRef(tx *sql.Tx, ref repo.DatasetRef) (repo.DatasetRef, error) {
+	const cols = `SELECT path, peername, profile_id, name FROM refs `
+	queries := []struct {
+		ok    bool
+		query string
+		args  []interface{}
+	}{
+		{ref.Path != "", cols + `WHERE path = ?`, []interface{}{ref.Path}},
+		{ref.Name != "" && ref.ProfileID != "", cols + `WHERE profile_id = ? AND name = ?`, []interface{}{ref.ProfileID.String(), ref.Name}},
+		{ref.Name != "" && ref.Peername != "", cols + `WHERE peername = ? AND name = ?`, []interface{}{ref.Peername, ref.Name}},
+	}
+
+	for _, q := range queries {
+		if !q.ok {
+			continue
+		}
+		got, err := scanRef(tx.QueryRow(q.query, q.args...))
+		if err == sql.ErrNoRows {
+			continue
+		}
+		return got, err
+	}
+	return repo.DatasetRef{}, repo.ErrNotFound
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRef(row scanner) (ref repo.DatasetRef, err error) {
+	var id string
+	if err = row.Scan(&ref.Path, &ref.Peername, &id, &ref.Name); err != nil {
+		return
+	}
+	ref.ProfileID, err = profile.IDB58Decode(id)
+	return
+}
+
+func scanRefs(rows *sql.Rows) ([]repo.DatasetRef, error) {
+	defer rows.Close()
+	refs := []repo.DatasetRef{}
+	for rows.Next() {
+		ref, err := scanRef(rows)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
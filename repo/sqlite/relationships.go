@@ -0,0 +1,68 @@
+package sqliterepo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// Relationship gives this peer's relationship with a profile
+func (ps ProfileStore) Relationship(id profile.ID) (profile.Relationship, error) {
+	rel := profile.Relationship{}
+	var trust string
+	err := ps.db.QueryRow(`SELECT following, trust FROM relationships WHERE profile_id = ?`, id.String()).Scan(&rel.Following, &trust)
+	if err == sql.ErrNoRows {
+		return profile.Relationship{Trust: profile.TrustNeutral}, nil
+	} else if err != nil {
+		return rel, err
+	}
+	rel.Trust = profile.Trust(trust)
+	return rel, nil
+}
+
+// PutRelationship sets this peer's relationship with a profile. Default
+// relationships aren't stored
+func (ps ProfileStore) PutRelationship(id profile.ID, rel profile.Relationship) error {
+	if id.String() == "" {
+		return fmt.Errorf("profile ID is required")
+	}
+	trust, err := profile.ParseTrust(string(rel.Trust))
+	if err != nil {
+		return err
+	}
+
+	if rel.IsDefault() {
+		_, err = ps.db.Exec(`DELETE FROM relationships WHERE profile_id = ?`, id.String())
+		return err
+	}
+	_, err = ps.db.Exec(`INSERT OR REPLACE INTO relationships (profile_id, following, trust) VALUES (?, ?, ?)`, id.String(), rel.Following, string(trust))
+	return err
+}
+
+// Relationships lists every relationship in the store
+func (ps ProfileStore) Relationships() (map[profile.ID]profile.Relationship, error) {
+	rows, err := ps.db.Query(`SELECT profile_id, following, trust FROM relationships`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rels := map[profile.ID]profile.Relationship{}
+	for rows.Next() {
+		var (
+			idstr, trust string
+			rel          profile.Relationship
+		)
+		if err := rows.Scan(&idstr, &rel.Following, &trust); err != nil {
+			return nil, err
+		}
+		id, err := profile.IDB58Decode(idstr)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding relationship profile ID: %s", err.Error())
+		}
+		rel.Trust = profile.Trust(trust)
+		rels[id] = rel
+	}
+	return rels, rows.Err()
+}
@@ -0,0 +1,335 @@
+// Package sqliterepo is an implementation of the repo.Repo interface that
+// keeps references, the event log, lineage, profiles, relationships, change
+// requests & analytics in a single SQLite database file. Changes that touch
+// more than one row happen in a transaction
+package sqliterepo
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	golog "github.com/ipfs/go-log"
+	"github.com/libp2p/go-libp2p-crypto"
+	// register the sqlite3 database/sql driver
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset/dsgraph"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/actions"
+	fsrepo "github.com/qri-io/qri/repo/fs"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+var log = golog.Logger("sqliterepo")
+
+// schema creates the repo's tables. every statement must be safe to run
+// against an existing database
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS refs (
+		path        TEXT PRIMARY KEY,
+		peername    TEXT NOT NULL,
+		profile_id  TEXT NOT NULL,
+		name        TEXT NOT NULL,
+		title       TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		UNIQUE (peername, name),
+		UNIQUE (profile_id, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS events (
+		id   INTEGER PRIMARY KEY AUTOINCREMENT,
+		time INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		ref  TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS events_time ON events (time)`,
+	`CREATE TABLE IF NOT EXISTS lineage (
+		ref   TEXT PRIMARY KEY,
+		stale INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE IF NOT EXISTS upstreams (
+		ref      TEXT NOT NULL REFERENCES lineage (ref) ON DELETE CASCADE,
+		upstream TEXT NOT NULL,
+		PRIMARY KEY (ref, upstream)
+	)`,
+	`CREATE INDEX IF NOT EXISTS upstreams_upstream ON upstreams (upstream)`,
+	`CREATE TABLE IF NOT EXISTS profiles (
+		id       TEXT PRIMARY KEY,
+		peername TEXT NOT NULL,
+		data     TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS profile_peers (
+		peer_id    TEXT NOT NULL,
+		profile_id TEXT NOT NULL REFERENCES profiles (id) ON DELETE CASCADE,
+		PRIMARY KEY (peer_id, profile_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS relationships (
+		profile_id TEXT PRIMARY KEY,
+		following  INTEGER NOT NULL DEFAULT 0,
+		trust      TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS change_requests (
+		id      TEXT PRIMARY KEY,
+		created INTEGER NOT NULL,
+		data    TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS analytics (
+		day      TEXT NOT NULL,
+		ref      TEXT NOT NULL,
+		served   INTEGER NOT NULL DEFAULT 0,
+		listed   INTEGER NOT NULL DEFAULT 0,
+		read     INTEGER NOT NULL DEFAULT 0,
+		exported INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (day, ref)
+	)`,
+}
+
+// Repo is a SQLite-backed implementation of the Repo interface
+type Repo struct {
+	db   *sql.DB
+	path string
+
+	profile *profile.Profile
+	pk      crypto.PrivKey
+
+	store cafs.Filestore
+	// graph caches the result of Graph until references change
+	graph     map[string]*dsgraph.Node
+	graphLock sync.Mutex
+
+	Refstore
+	EventLog
+	Lineage
+	*repo.EventBus
+
+	profiles       ProfileStore
+	changeRequests ChangeRequests
+	analytics      Analytics
+}
+
+// NewRepo opens the repo database at path, creating it if it doesn't exist
+func NewRepo(store cafs.Filestore, cfg *config.Profile, path string) (repo.Repo, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	db, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := cfg.DecodeProfile()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	pk, err := cfg.DecodePrivateKey()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	bus := repo.NewEventBus()
+	r := &Repo{
+		db:   db,
+		path: path,
+
+		profile: p,
+		pk:      pk,
+		store:   store,
+
+		Refstore: Refstore{db: db, store: store},
+		EventLog: EventLog{db: db, bus: bus},
+		Lineage:  Lineage{db: db},
+		EventBus: bus,
+
+		profiles:       ProfileStore{db: db},
+		changeRequests: ChangeRequests{db: db},
+		analytics:      Analytics{db: db},
+	}
+
+	if err = r.Profiles().PutProfile(p); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Open opens a repo database, creating any missing tables
+func Open(path string) (*sql.DB, error) {
+	// sqlite only enforces foreign keys when asked to, per-connection
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=1&_busy_timeout=5000", path))
+	if err != nil {
+		return nil, fmt.Errorf("error opening repo database: %s", err.Error())
+	}
+	// a single connection serializes writers, avoiding busy errors when
+	// transactions upgrade from read to write locks
+	db.SetMaxOpenConns(1)
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("error creating repo database: %s", err.Error())
+		}
+	}
+	return db, nil
+}
+
+// Store returns the underlying cafs.Filestore driving this repo
+func (r *Repo) Store() cafs.Filestore {
+	return r.store
+}
+
+// Graph returns the graph of dataset objects for this repo
+func (r *Repo) Graph() (map[string]*dsgraph.Node, error) {
+	r.graphLock.Lock()
+	defer r.graphLock.Unlock()
+
+	if r.graph == nil {
+		nodes, err := repo.Graph(r)
+		if err != nil {
+			log.Debug(err.Error())
+			return nil, err
+		}
+		r.graph = nodes
+	}
+	return r.graph, nil
+}
+
+// PutRef adds a reference to the store, clearing the cached graph
+func (r *Repo) PutRef(ref repo.DatasetRef) error {
+	if err := r.Refstore.PutRef(ref); err != nil {
+		return err
+	}
+	r.clearGraph()
+	return nil
+}
+
+// PutRefs adds a set of references to the store in a single transaction,
+// clearing the cached graph
+func (r *Repo) PutRefs(refs []repo.DatasetRef) error {
+	if err := r.Refstore.PutRefs(refs); err != nil {
+		return err
+	}
+	r.clearGraph()
+	return nil
+}
+
+// DeleteRef removes a reference from the store, clearing the cached graph
+func (r *Repo) DeleteRef(ref repo.DatasetRef) error {
+	if err := r.Refstore.DeleteRef(ref); err != nil {
+		return err
+	}
+	r.clearGraph()
+	return nil
+}
+
+func (r *Repo) clearGraph() {
+	r.graphLock.Lock()
+	r.graph = nil
+	r.graphLock.Unlock()
+}
+
+// Profile gives this repo's peer profile
+func (r *Repo) Profile() (*profile.Profile, error) {
+	return r.profile, nil
+}
+
+// SetProfile updates this repo's peer profile info
+func (r *Repo) SetProfile(p *profile.Profile) error {
+	r.profile = p
+	return r.Profiles().PutProfile(p)
+}
+
+// SetPrivateKey sets an internal reference to the private key for this profile
+func (r *Repo) SetPrivateKey(pk crypto.PrivKey) error {
+	r.pk = pk
+	return nil
+}
+
+// PrivateKey returns this repo's private key
+func (r *Repo) PrivateKey() crypto.PrivKey {
+	return r.pk
+}
+
+// WithExclusiveLock runs fn holding the on-disk repo lock exclusively, so no
+// other qri process can use the repo while fn runs
+func (r *Repo) WithExclusiveLock(fn func() error) error {
+	return fsrepo.WithExclusiveLock(filepath.Dir(r.path), fn)
+}
+
+// Profiles returns this repo's profile store
+func (r *Repo) Profiles() profile.Store {
+	return r.profiles
+}
+
+// ChangeRequests returns this repo's change request store
+func (r *Repo) ChangeRequests() repo.ChangeRequestStore {
+	return r.changeRequests
+}
+
+// Analytics returns this repo's usage counts
+func (r *Repo) Analytics() repo.Analytics {
+	return r.analytics
+}
+
+// Search matches a query against the peername, name, title & description of
+// datasets in this repo, ordered by peername & name
+func (r *Repo) Search(p repo.SearchParams) ([]repo.DatasetRef, error) {
+	q := "%" + escapeLike(p.Q) + "%"
+	rows, err := r.db.Query(`SELECT path, peername, profile_id, name FROM refs
+		WHERE peername LIKE ?1 ESCAPE '\' OR name LIKE ?1 ESCAPE '\'
+			OR title LIKE ?1 ESCAPE '\' OR description LIKE ?1 ESCAPE '\'
+		ORDER BY peername, name LIMIT ?2 OFFSET ?3`, q, p.Limit, p.Offset)
+	if err != nil {
+		log.Debug(err.Error())
+		return nil, fmt.Errorf("error searching: %s", err.Error())
+	}
+	refs, err := scanRefs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	act := actions.Dataset{r}
+	for i := range refs {
+		if err := act.ReadDataset(&refs[i]); err != nil {
+			log.Debug(err.Error())
+		}
+	}
+	return refs, nil
+}
+
+// Close closes the repo database
+func (r *Repo) Close() error {
+	return r.db.Close()
+}
+
+// Destroy closes & removes the repo database
+func (r *Repo) Destroy() error {
+	if err := r.db.Close(); err != nil {
+		return err
+	}
+	return os.Remove(r.path)
+}
+
+// escapeLike escapes the wildcard characters of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// tx runs fn in a transaction, committing if fn returns nil
+func tx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	t, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(t); err != nil {
+		t.Rollback()
+		return err
+	}
+	return t.Commit()
+}
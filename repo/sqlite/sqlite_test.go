@@ -0,0 +1,75 @@
+package sqliterepo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	"github.com/qri-io/qri/repo/test"
+)
+
+func TestRepo(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_sqlite_repo_test", "repo.sqlite")
+	t.Log(path)
+
+	rmf := func(t *testing.T) repo.Repo {
+		if err := os.RemoveAll(filepath.Dir(path)); err != nil {
+			t.Errorf("error removing files: %s", err.Error())
+		}
+
+		r, err := NewRepo(cafs.NewMapstore(), config.DefaultProfile(), path)
+		if err != nil {
+			t.Errorf("error creating repo: %s", err.Error())
+		}
+		return r
+	}
+
+	test.RunRepoTests(t, rmf)
+
+	if err := os.RemoveAll(filepath.Dir(path)); err != nil {
+		t.Errorf("error cleaning up after test: %s", err.Error())
+	}
+}
+
+func TestPutRefs(t *testing.T) {
+	path := filepath.Join(os.TempDir(), "qri_sqlite_refs_test.sqlite")
+	os.Remove(path)
+	defer os.Remove(path)
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer db.Close()
+	rs := Refstore{db: db}
+
+	id := profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt")
+	a := repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "a", Path: "/map/QmA"}
+	b := repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "b", Path: "/map/QmB"}
+	taken := repo.DatasetRef{ProfileID: id, Peername: "peer", Name: "a", Path: "/map/QmC"}
+
+	if err := rs.PutRefs([]repo.DatasetRef{b, a, taken}); err != repo.ErrNameTaken {
+		t.Errorf("expected name taken error, got: %v", err)
+	}
+	if count, _ := rs.RefCount(); count != 0 {
+		t.Errorf("expected a failed put to add no references, got: %d", count)
+	}
+
+	if err := rs.PutRefs([]repo.DatasetRef{b, a}); err != nil {
+		t.Fatal(err.Error())
+	}
+	refs, err := rs.PeerReferences("peer", 10, 0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(refs) != 2 || !refs[0].Equal(a) || !refs[1].Equal(b) {
+		t.Errorf("expected references ordered by name, got: %v", refs)
+	}
+	if got, err := rs.GetRef(repo.DatasetRef{ProfileID: id, Name: "b"}); err != nil || !got.Equal(b) {
+		t.Errorf("expected to get ref by profileID & name, got: %s, err: %v", got, err)
+	}
+}
@@ -19,12 +19,18 @@ func testdataPath(path string) string {
 }
 
 // RunRepoTests tests that this repo conforms to
-// expected behaviors
+// expected behaviors. rmf must return a new, empty repo each time it's called
 func RunRepoTests(t *testing.T, rmf RepoMakerFunc) {
 	tests := []repoTestFunc{
 		testProfile,
-		// testRefstore,
-		// DatasetActions,
+		RunRefstoreTests,
+		RunEventLogTests,
+		RunProfileStoreTests,
+		RunChangeRequestTests,
+		RunAnalyticsTests,
+		RunSearchableTests,
+		RunConcurrencyTests,
+		DatasetActions,
 	}
 
 	for _, test := range tests {
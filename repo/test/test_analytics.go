@@ -0,0 +1,73 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// RunAnalyticsTests checks a repo's Analytics implementation
+func RunAnalyticsTests(t *testing.T, rmf RepoMakerFunc) {
+	for _, test := range []repoTestFunc{
+		testAnalytics,
+	} {
+		test(t, rmf)
+	}
+}
+
+func testAnalytics(t *testing.T, rmf RepoMakerFunc) {
+	a := rmf(t).Analytics()
+
+	if err := a.CountUsage(repo.UsageServed, time.Now(), repo.DatasetRef{Name: "cities"}); err == nil {
+		t.Error("counting usage without a peername should error")
+	}
+
+	cities := repo.DatasetRef{Peername: "peer", Name: "cities", Path: "/map/QmCities"}
+	movies := repo.DatasetRef{Peername: "peer", Name: "movies"}
+	day1 := time.Date(2018, 6, 1, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+
+	counts := []struct {
+		kind repo.UsageKind
+		t    time.Time
+		refs []repo.DatasetRef
+	}{
+		{repo.UsageServed, day1, []repo.DatasetRef{cities}},
+		{repo.UsageServed, day1, []repo.DatasetRef{cities}},
+		{repo.UsageListed, day1, []repo.DatasetRef{cities, movies}},
+		{repo.UsageRead, day2, []repo.DatasetRef{cities}},
+		// a new version of a dataset counts toward the same alias
+		{repo.UsageExported, day2, []repo.DatasetRef{{Peername: "peer", Name: "cities", Path: "/map/QmNewCities"}}},
+	}
+	for i, c := range counts {
+		if err := a.CountUsage(c.kind, c.t, c.refs...); err != nil {
+			t.Errorf("count %d error: %s", i, err.Error())
+			return
+		}
+	}
+
+	usage, err := a.Usage(day1.Add(-48 * time.Hour))
+	if err != nil {
+		t.Errorf("Usage error: %s", err.Error())
+		return
+	}
+	expect := []repo.DailyUsage{
+		{Day: "2018-06-01", Ref: "peer/cities", UsageCounts: repo.UsageCounts{Served: 2, Listed: 1}},
+		{Day: "2018-06-01", Ref: "peer/movies", UsageCounts: repo.UsageCounts{Listed: 1}},
+		{Day: "2018-06-02", Ref: "peer/cities", UsageCounts: repo.UsageCounts{Read: 1, Exported: 1}},
+	}
+	if len(usage) != len(expect) {
+		t.Errorf("usage length mismatch. expected: %d, got: %d", len(expect), len(usage))
+		return
+	}
+	for i, e := range expect {
+		if *usage[i] != e {
+			t.Errorf("usage %d mismatch. expected: %v, got: %v", i, e, *usage[i])
+		}
+	}
+
+	if usage, err := a.Usage(day2); err != nil || len(usage) != 1 {
+		t.Errorf("expected usage since the second day to have 1 entry. got: %d, err: %v", len(usage), err)
+	}
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// RunChangeRequestTests checks a repo's ChangeRequestStore implementation
+func RunChangeRequestTests(t *testing.T, rmf RepoMakerFunc) {
+	for _, test := range []repoTestFunc{
+		testChangeRequests,
+	} {
+		test(t, rmf)
+	}
+}
+
+func testChangeRequests(t *testing.T, rmf RepoMakerFunc) {
+	crs := rmf(t).ChangeRequests()
+
+	if err := crs.PutChangeRequest(&repo.ChangeRequest{Title: "no id"}); err == nil {
+		t.Error("putting a change request without an ID should error")
+	}
+	if _, err := crs.GetChangeRequest("/map/missing"); err != repo.ErrNotFound {
+		t.Errorf("getting a missing change request should return ErrNotFound, got: %v", err)
+	}
+
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	a := &repo.ChangeRequest{
+		ID:           "/map/a",
+		Target:       repo.DatasetRef{Peername: "peer", Name: "cities", Path: "/map/head"},
+		FromPeername: "other_peer",
+		Title:        "fix a typo",
+		Incoming:     true,
+		Status:       repo.CRStatusOpen,
+		Created:      now,
+		Updated:      now,
+	}
+	b := &repo.ChangeRequest{
+		ID:      "/map/b",
+		Target:  repo.DatasetRef{Peername: "other_peer", Name: "movies", Path: "/map/head"},
+		Title:   "add a row",
+		Status:  repo.CRStatusOpen,
+		Created: now.Add(time.Hour),
+		Updated: now.Add(time.Hour),
+	}
+	for _, cr := range []*repo.ChangeRequest{a, b} {
+		if err := crs.PutChangeRequest(cr); err != nil {
+			t.Errorf("PutChangeRequest: %s", err.Error())
+			return
+		}
+	}
+
+	got, err := crs.GetChangeRequest(a.ID)
+	if err != nil {
+		t.Errorf("GetChangeRequest: %s", err.Error())
+		return
+	}
+	if got.Title != a.Title || !got.Incoming || got.Target.AliasString() != "peer/cities" || !got.Created.Equal(a.Created) {
+		t.Errorf("GetChangeRequest mismatch. expected: %#v, got: %#v", a, got)
+	}
+
+	list, err := crs.ListChangeRequests(-1, 0)
+	if err != nil {
+		t.Errorf("ListChangeRequests: %s", err.Error())
+		return
+	}
+	if len(list) != 2 || list[0].ID != b.ID || list[1].ID != a.ID {
+		t.Errorf("ListChangeRequests should list newest first. got: %v", list)
+	}
+	if list, err := crs.ListChangeRequests(1, 1); err != nil || len(list) != 1 || list[0].ID != a.ID {
+		t.Errorf("ListChangeRequests should page. got: %v, err: %v", list, err)
+	}
+
+	a.Status = repo.CRStatusRejected
+	a.Comment = "no thanks"
+	if err := crs.PutChangeRequest(a); err != nil {
+		t.Errorf("updating change request: %s", err.Error())
+		return
+	}
+	if got, err := crs.GetChangeRequest(a.ID); err != nil || got.Status != repo.CRStatusRejected || got.Comment != a.Comment {
+		t.Errorf("PutChangeRequest should replace existing change requests. got: %v, err: %v", got, err)
+	}
+
+	if err := crs.DeleteChangeRequest(b.ID); err != nil {
+		t.Errorf("DeleteChangeRequest: %s", err.Error())
+	}
+	if err := crs.DeleteChangeRequest(b.ID); err != repo.ErrNotFound {
+		t.Errorf("deleting a missing change request should return ErrNotFound, got: %v", err)
+	}
+	if list, err := crs.ListChangeRequests(-1, 0); err != nil || len(list) != 1 {
+		t.Errorf("expected 1 change request after delete. got: %d, err: %v", len(list), err)
+	}
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// ConcurrentWriters is the number of goroutines concurrency tests run at once
+var ConcurrentWriters = 10
+
+// RunConcurrencyTests checks a repo's references & event log can be used from
+// many goroutines at once. Run with the race detector for best results
+func RunConcurrencyTests(t *testing.T, rmf RepoMakerFunc) {
+	for _, test := range []repoTestFunc{
+		testConcurrentRefs,
+		testConcurrentNameTaken,
+	} {
+		test(t, rmf)
+	}
+}
+
+// putTestFiles adds n distinct files to a repo's store. Stores aren't
+// required to be safe for concurrent use, so files are added up front
+func putTestFiles(r repo.Repo, prefix string, n int) ([]string, error) {
+	paths := make([]string, n)
+	for i := range paths {
+		path, err := r.Store().Put(cafs.NewMemfileBytes("test", []byte(fmt.Sprintf(`{ "title": "%s_%d" }`, prefix, i))), true)
+		if err != nil {
+			return nil, fmt.Errorf("error putting test file in datastore: %s", err.Error())
+		}
+		paths[i] = path.String()
+	}
+	return paths, nil
+}
+
+func testConcurrentRefs(t *testing.T, rmf RepoMakerFunc) {
+	r := rmf(t)
+	paths, err := putTestFiles(r, "concurrent", ConcurrentWriters)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, len(paths)*3)
+	for i, path := range paths {
+		wg.Add(1)
+		go func(ref repo.DatasetRef) {
+			defer wg.Done()
+			if err := r.PutRef(ref); err != nil {
+				errs <- fmt.Errorf("repo.PutRef %s: %s", ref, err.Error())
+				return
+			}
+			if got, err := r.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name}); err != nil || got.Path != ref.Path {
+				errs <- fmt.Errorf("repo.GetRef %s: got: %s, err: %v", ref, got, err)
+			}
+			if err := r.LogEvent(repo.ETDsCreated, ref); err != nil {
+				errs <- fmt.Errorf("repo.LogEvent %s: %s", ref, err.Error())
+			}
+		}(repo.DatasetRef{
+			ProfileID: profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"),
+			Peername:  "peer",
+			Name:      fmt.Sprintf("concurrent_%d", i),
+			Path:      path,
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err.Error())
+	}
+
+	if count, err := r.RefCount(); err != nil || count != len(paths) {
+		t.Errorf("expected %d references after concurrent puts, got: %d, err: %v", len(paths), count, err)
+	}
+	if events, err := r.Events(len(paths)*2, 0); err != nil || len(events) != len(paths) {
+		t.Errorf("expected %d events after concurrent logging, got: %d, err: %v", len(paths), len(events), err)
+	}
+}
+
+func testConcurrentNameTaken(t *testing.T, rmf RepoMakerFunc) {
+	r := rmf(t)
+	paths, err := putTestFiles(r, "contested", ConcurrentWriters)
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	results := make(chan error, len(paths))
+	for _, path := range paths {
+		wg.Add(1)
+		go func(ref repo.DatasetRef) {
+			defer wg.Done()
+			results <- r.PutRef(ref)
+		}(repo.DatasetRef{
+			ProfileID: profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"),
+			Peername:  "peer",
+			Name:      "contested",
+			Path:      path,
+		})
+	}
+	wg.Wait()
+	close(results)
+
+	won := 0
+	for err := range results {
+		if err == nil {
+			won++
+		} else if err != repo.ErrNameTaken {
+			t.Errorf("expected losing puts to return repo.ErrNameTaken, got: %s", err.Error())
+		}
+	}
+	if won != 1 {
+		t.Errorf("expected exactly one put of a contested name to succeed, %d did", won)
+	}
+}
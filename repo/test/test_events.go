@@ -0,0 +1,102 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// RunEventLogTests checks a repo's EventLog implementation: Events lists
+// events newest first with pagination, EventsSince lists events after a time
+// oldest first
+func RunEventLogTests(t *testing.T, rmf RepoMakerFunc) {
+	for _, test := range []repoTestFunc{
+		testEventLogOrder,
+	} {
+		test(t, rmf)
+	}
+}
+
+func testEventLogOrder(t *testing.T, rmf RepoMakerFunc) {
+	r := rmf(t)
+	ref := repo.DatasetRef{ProfileID: profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"), Peername: "peer", Name: "events", Path: "/map/QmEvents"}
+
+	start := time.Now().Add(-time.Millisecond)
+	if err := r.LogEvent(repo.ETDsCreated, ref); err != nil {
+		t.Errorf("repo.LogEvent: %s", err.Error())
+		return
+	}
+	mid := time.Now()
+	time.Sleep(time.Millisecond)
+	for _, et := range []repo.EventType{repo.ETDsRenamed, repo.ETDsDeleted} {
+		if err := r.LogEvent(et, ref); err != nil {
+			t.Errorf("repo.LogEvent: %s", err.Error())
+			return
+		}
+	}
+
+	cases := []struct {
+		limit, offset int
+		expect        []repo.EventType
+	}{
+		{10, 0, []repo.EventType{repo.ETDsDeleted, repo.ETDsRenamed, repo.ETDsCreated}},
+		{2, 0, []repo.EventType{repo.ETDsDeleted, repo.ETDsRenamed}},
+		{10, 1, []repo.EventType{repo.ETDsRenamed, repo.ETDsCreated}},
+		{10, 3, []repo.EventType{}},
+	}
+	for i, c := range cases {
+		events, err := r.Events(c.limit, c.offset)
+		if err != nil {
+			t.Errorf("case %d repo.Events(%d,%d): %s", i, c.limit, c.offset, err.Error())
+			continue
+		}
+		if !eventTypesEqual(events, c.expect) {
+			t.Errorf("case %d repo.Events(%d,%d) mismatch. expected: %v, got: %v", i, c.limit, c.offset, c.expect, eventTypes(events))
+		}
+	}
+
+	events, err := r.EventsSince(start)
+	if err != nil {
+		t.Errorf("repo.EventsSince: %s", err.Error())
+		return
+	}
+	expect := []repo.EventType{repo.ETDsCreated, repo.ETDsRenamed, repo.ETDsDeleted}
+	if !eventTypesEqual(events, expect) {
+		t.Errorf("repo.EventsSince should list events oldest first. expected: %v, got: %v", expect, eventTypes(events))
+	}
+	if len(events) > 0 && !events[0].Ref.Equal(ref) {
+		t.Errorf("event reference mismatch. expected: %s, got: %s", ref, events[0].Ref)
+	}
+
+	events, err = r.EventsSince(mid)
+	if err != nil {
+		t.Errorf("repo.EventsSince: %s", err.Error())
+		return
+	}
+	expect = []repo.EventType{repo.ETDsRenamed, repo.ETDsDeleted}
+	if !eventTypesEqual(events, expect) {
+		t.Errorf("repo.EventsSince should only list events after the given time. expected: %v, got: %v", expect, eventTypes(events))
+	}
+}
+
+func eventTypes(events []*repo.Event) []repo.EventType {
+	types := make([]repo.EventType, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func eventTypesEqual(events []*repo.Event, expect []repo.EventType) bool {
+	if len(events) != len(expect) {
+		return false
+	}
+	for i, e := range events {
+		if e.Type != expect[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/repo/profile"
+
+	"gx/ipfs/QmZoWKhxUmZ2seW4BzX6fJkNR8hh9PsGModr7q171yq2SS/go-libp2p-peer"
+)
+
+// RunProfileStoreTests checks a repo's profile.Store implementation
+func RunProfileStoreTests(t *testing.T, rmf RepoMakerFunc) {
+	for _, test := range []repoTestFunc{
+		testProfileStore,
+		testProfileRelationships,
+	} {
+		test(t, rmf)
+	}
+}
+
+func testProfileStore(t *testing.T, rmf RepoMakerFunc) {
+	ps := rmf(t).Profiles()
+
+	if err := ps.PutProfile(&profile.Profile{Peername: "no_id"}); err == nil {
+		t.Error("putting a profile without an ID should error")
+	}
+
+	peerID := "QmYCvbfNbCwFR45HiNP45rwJgvatpiW38D961L5qAhUM5Y"
+	p := &profile.Profile{
+		ID:        profile.IDB58MustDecode("QmRdexT18WuAKVX3vPusqmJTWLeNSeJgjmMbaF5QLGHna1"),
+		Peername:  "other_peer",
+		Addresses: map[string][]string{peerID: {}},
+	}
+	if err := ps.PutProfile(p); err != nil {
+		t.Errorf("PutProfile: %s", err.Error())
+		return
+	}
+
+	got, err := ps.GetProfile(p.ID)
+	if err != nil {
+		t.Errorf("GetProfile: %s", err.Error())
+		return
+	}
+	if got.ID != p.ID || got.Peername != p.Peername {
+		t.Errorf("GetProfile mismatch. expected: %s/%s, got: %s/%s", p.ID, p.Peername, got.ID, got.Peername)
+	}
+
+	if id, err := ps.PeernameID(p.Peername); err != nil || id != p.ID {
+		t.Errorf("PeernameID mismatch. expected: %s, got: %s, err: %v", p.ID, id, err)
+	}
+
+	pid, err := peer.IDB58Decode(peerID)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got, err := ps.PeerProfile(pid); err != nil || got.ID != p.ID {
+		t.Errorf("PeerProfile should find profiles by address. err: %v", err)
+	}
+	if ids, err := ps.PeerIDs(p.ID); err != nil || len(ids) != 1 || ids[0] != pid {
+		t.Errorf("PeerIDs mismatch. expected: [%s], got: %v, err: %v", pid.Pretty(), ids, err)
+	}
+
+	list, err := ps.List()
+	if err != nil {
+		t.Errorf("List: %s", err.Error())
+		return
+	}
+	if list[p.ID] == nil {
+		t.Errorf("List should include every profile in the store")
+	}
+
+	if err := ps.DeleteProfile(p.ID); err != nil {
+		t.Errorf("DeleteProfile: %s", err.Error())
+		return
+	}
+	if _, err := ps.GetProfile(p.ID); err == nil {
+		t.Error("GetProfile of a deleted profile should error")
+	}
+	if _, err := ps.PeernameID(p.Peername); err == nil {
+		t.Error("PeernameID of a deleted profile should error")
+	}
+}
+
+func testProfileRelationships(t *testing.T, rmf RepoMakerFunc) {
+	ps := rmf(t).Profiles()
+	id := profile.IDB58MustDecode("QmRdexT18WuAKVX3vPusqmJTWLeNSeJgjmMbaF5QLGHna1")
+
+	rel, err := ps.Relationship(id)
+	if err != nil {
+		t.Errorf("Relationship: %s", err.Error())
+		return
+	}
+	if rel.Following || rel.Trust != profile.TrustNeutral {
+		t.Errorf("expected unknown profiles to be unfollowed & neutral, got: %#v", rel)
+	}
+
+	if err := ps.PutRelationship(id, profile.Relationship{Trust: profile.Trust("suspicious")}); err == nil {
+		t.Error("putting an invalid trust level should error")
+	}
+
+	if err := ps.PutRelationship(id, profile.Relationship{Following: true, Trust: profile.TrustBlocked}); err != nil {
+		t.Errorf("PutRelationship: %s", err.Error())
+		return
+	}
+	if rel, err := ps.Relationship(id); err != nil || !rel.Following || rel.Trust != profile.TrustBlocked {
+		t.Errorf("Relationship mismatch. expected following & blocked, got: %#v, err: %v", rel, err)
+	}
+	if !profile.Blocked(ps, id) {
+		t.Error("expected Blocked to be true")
+	}
+
+	rels, err := ps.Relationships()
+	if err != nil {
+		t.Errorf("Relationships: %s", err.Error())
+		return
+	}
+	if len(rels) != 1 || rels[id].Trust != profile.TrustBlocked {
+		t.Errorf("expected 1 blocked relationship, got: %v", rels)
+	}
+
+	if err := ps.PutRelationship(id, profile.Relationship{Trust: profile.TrustNeutral}); err != nil {
+		t.Errorf("PutRelationship: %s", err.Error())
+		return
+	}
+	if rels, err := ps.Relationships(); err != nil || len(rels) != 0 {
+		t.Errorf("expected default relationships to be dropped, got: %v, err: %v", rels, err)
+	}
+}
@@ -10,10 +10,14 @@ import (
 	"github.com/qri-io/qri/repo/profile"
 )
 
-func testRefstore(t *testing.T, rmf RepoMakerFunc) {
+// RunRefstoreTests checks a repo's Refstore implementation: incomplete
+// references are rejected, references can be looked up by profileID & name or
+// by path, names can't be claimed twice & listings are paginated in order
+func RunRefstoreTests(t *testing.T, rmf RepoMakerFunc) {
 	for _, test := range []repoTestFunc{
 		testInvalidRefs,
 		testRefs,
+		testRefNameTaken,
 		testRefstoreMain,
 	} {
 		test(t, rmf)
@@ -97,6 +101,50 @@ func testRefs(t *testing.T, rmf RepoMakerFunc) {
 	return
 }
 
+func testRefNameTaken(t *testing.T, rmf RepoMakerFunc) {
+	r := rmf(t)
+	a, err := r.Store().Put(cafs.NewMemfileBytes("test", []byte(`{ "title": "test data a" }`)), true)
+	if err != nil {
+		t.Errorf("error putting test file in datastore: %s", err.Error())
+		return
+	}
+	b, err := r.Store().Put(cafs.NewMemfileBytes("test", []byte(`{ "title": "test data b" }`)), true)
+	if err != nil {
+		t.Errorf("error putting test file in datastore: %s", err.Error())
+		return
+	}
+
+	ref := repo.DatasetRef{ProfileID: profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"), Peername: "peer", Name: "taken", Path: a.String()}
+	if err := r.PutRef(ref); err != nil {
+		t.Errorf("repo.PutRef: %s", err.Error())
+		return
+	}
+	if err := r.PutRef(ref); err != nil {
+		t.Errorf("putting an identical reference twice should not error, got: %s", err.Error())
+		return
+	}
+
+	taken := ref
+	taken.Path = b.String()
+	if err := r.PutRef(taken); err != repo.ErrNameTaken {
+		t.Errorf("putting a reference with a name already in use should return repo.ErrNameTaken, got: %v", err)
+		return
+	}
+	if got, err := r.GetRef(repo.DatasetRef{Peername: ref.Peername, Name: ref.Name}); err != nil || got.Path != ref.Path {
+		t.Errorf("a rejected reference should not replace the existing one. got: %s, err: %v", got, err)
+		return
+	}
+
+	if _, err := r.GetRef(repo.DatasetRef{Peername: "peer", Name: "not_a_dataset"}); err != repo.ErrNotFound {
+		t.Errorf("repo.GetRef of a missing reference should return repo.ErrNotFound, got: %v", err)
+		return
+	}
+
+	if err := r.DeleteRef(ref); err != nil {
+		t.Errorf("repo.DeleteRef: %s", err.Error())
+	}
+}
+
 func testRefstoreMain(t *testing.T, rmf RepoMakerFunc) {
 	r := rmf(t)
 	aname := "test_namespace_a"
@@ -108,14 +156,14 @@ func testRefstoreMain(t *testing.T, rmf RepoMakerFunc) {
 		{ProfileID: profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"), Peername: "peer", Name: "test_namespace_d"},
 		{ProfileID: profile.IDB58MustDecode("QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt"), Peername: "peer", Name: "test_namespace_e"},
 	}
-	for _, ref := range refs {
+	for i, ref := range refs {
 		path, err := r.Store().Put(cafs.NewMemfileBytes("test", []byte(fmt.Sprintf(`{ "title": "test_dataset_%s" }`, ref.Name))), true)
 		if err != nil {
 			t.Errorf("error putting test file in datastore: %s", err.Error())
 			return
 		}
-		ref.Path = path.String()
-		if err := r.PutRef(ref); err != nil {
+		refs[i].Path = path.String()
+		if err := r.PutRef(refs[i]); err != nil {
 			t.Errorf("error putting name in repo for namespace test: %s", err.Error())
 			return
 		}
@@ -2,8 +2,24 @@ package test
 
 import (
 	"testing"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
 )
 
+func TestMemRepo(t *testing.T) {
+	rmf := func(t *testing.T) repo.Repo {
+		r, err := repo.NewMemRepo(testPeerProfile, cafs.NewMapstore(), profile.MemStore{})
+		if err != nil {
+			t.Errorf("error creating repo: %s", err.Error())
+		}
+		return r
+	}
+
+	RunRepoTests(t, rmf)
+}
+
 func TestNewMemRepoFromDir(t *testing.T) {
 	repo, _, err := NewMemRepoFromDir("testdata")
 	if err != nil {
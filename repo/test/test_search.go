@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// RunSearchableTests checks repos that implement the opt-in
+// repo.Searchable interface can find a dataset by it's title. Repos that
+// aren't searchable pass
+func RunSearchableTests(t *testing.T, rmf RepoMakerFunc) {
+	for _, test := range []repoTestFunc{
+		testSearch,
+	} {
+		test(t, rmf)
+	}
+}
+
+func testSearch(t *testing.T, rmf RepoMakerFunc) {
+	r, ref := createDataset(t, rmf)
+	s, ok := r.(repo.Searchable)
+	if !ok {
+		t.Log("repo isn't searchable")
+		return
+	}
+
+	// cities has the title "example city data"
+	res, err := s.Search(repo.SearchParams{Q: "city", Limit: 10})
+	if err != nil {
+		t.Errorf("repo.Search: %s", err.Error())
+		return
+	}
+	for _, got := range res {
+		if got.Path == ref.Path {
+			return
+		}
+	}
+	t.Errorf("expected search results to include %s, got: %v", ref, res)
+}
@@ -0,0 +1,327 @@
+// Package localfs is a cafs.Filestore that keeps blocks in a plain directory
+// on the local filesystem, for running qri without an IPFS node.
+// Blocks are hashed with storeutil, the same hashing MapStore uses for files.
+// Directories are stored as a single block, and files within a directory
+// are read by appending their name to the directory's path:
+// /local/[dirhash]/[name]
+package localfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/store/storeutil"
+)
+
+// PathPrefix is the first element of every key in the store
+const PathPrefix = "local"
+
+// Filestore is a cafs.Filestore backed by a local directory
+type Filestore struct {
+	path string
+	// lock guards pins.json
+	lock sync.Mutex
+}
+
+// NewFilestore opens a store at path, creating it if it doesn't exist
+func NewFilestore(path string) (*Filestore, error) {
+	if err := os.MkdirAll(filepath.Join(path, "blocks"), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("error creating local store: %s", err.Error())
+	}
+	return &Filestore{path: path}, nil
+}
+
+// PathPrefix implements the cafs.Filestore interface
+func (fs *Filestore) PathPrefix() string {
+	return PathPrefix
+}
+
+// Put adds a file or directory to the store, returning it's key
+func (fs *Filestore) Put(file cafs.File, pin bool) (datastore.Key, error) {
+	tmp, err := ioutil.TempDir(fs.path, "put-")
+	if err != nil {
+		return datastore.Key{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	target := filepath.Join(tmp, "block")
+	hash, err := writeFile(target, file)
+	if err != nil {
+		return datastore.Key{}, err
+	}
+
+	key := datastore.NewKey("/" + PathPrefix + "/" + hash)
+	dst := fs.blockPath(hash)
+	if err := os.Rename(target, dst); err != nil {
+		// renaming a directory fails if the block is already stored, which can
+		// happen when another Put of the same block finishes first. blocks are
+		// content-addressed, so a block that exists holds the same content
+		if _, serr := os.Stat(dst); serr != nil {
+			return key, fmt.Errorf("error adding block to local store: %s", err.Error())
+		}
+	}
+
+	if pin {
+		err = fs.Pin(key, true)
+	}
+	return key, err
+}
+
+// Get reads a file or directory from the store
+func (fs *Filestore) Get(key datastore.Key) (cafs.File, error) {
+	path, err := fs.filepath(key)
+	if err != nil {
+		return nil, err
+	}
+	return readFile(path, key.String())
+}
+
+// Has checks if a key is in the store
+func (fs *Filestore) Has(key datastore.Key) (bool, error) {
+	path, err := fs.filepath(key)
+	if err == datastore.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes a block from the store, unpinning it. Only whole blocks can
+// be removed, not files within a directory
+func (fs *Filestore) Delete(key datastore.Key) error {
+	hash, sub, err := storeutil.SplitKey(key, PathPrefix)
+	if err != nil {
+		return err
+	}
+	if sub != "" {
+		return fmt.Errorf("can't delete %s: only blocks can be deleted, not files within them", key.String())
+	}
+	if err := os.RemoveAll(fs.blockPath(hash)); err != nil {
+		return err
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	pins, err := fs.pins()
+	if err != nil {
+		return err
+	}
+	if _, ok := pins[key.String()]; ok {
+		delete(pins, key.String())
+		return fs.savePins(pins)
+	}
+	return nil
+}
+
+// Keys lists every block in the store
+func (fs *Filestore) Keys() ([]datastore.Key, error) {
+	infos, err := ioutil.ReadDir(filepath.Join(fs.path, "blocks"))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]datastore.Key, len(infos))
+	for i, fi := range infos {
+		keys[i] = datastore.NewKey("/" + PathPrefix + "/" + fi.Name())
+	}
+	return keys, nil
+}
+
+// Pin marks a block as pinned. Pins have no effect on the store itself, but
+// are reported to pin-aware callers like garbage collection
+func (fs *Filestore) Pin(key datastore.Key, recursive bool) error {
+	hash, _, err := storeutil.SplitKey(key, PathPrefix)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(fs.blockPath(hash)); os.IsNotExist(err) {
+		return datastore.ErrNotFound
+	}
+	key = datastore.NewKey("/" + PathPrefix + "/" + hash)
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	pins, err := fs.pins()
+	if err != nil {
+		return err
+	}
+	pins[key.String()] = true
+	return fs.savePins(pins)
+}
+
+// Unpin removes the pin from a block
+func (fs *Filestore) Unpin(key datastore.Key, recursive bool) error {
+	hash, _, err := storeutil.SplitKey(key, PathPrefix)
+	if err != nil {
+		return err
+	}
+	key = datastore.NewKey("/" + PathPrefix + "/" + hash)
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	pins, err := fs.pins()
+	if err != nil {
+		return err
+	}
+	if !pins[key.String()] {
+		return fmt.Errorf("not pinned: %s", key.String())
+	}
+	delete(pins, key.String())
+	return fs.savePins(pins)
+}
+
+// Pinned lists pinned blocks
+func (fs *Filestore) Pinned() ([]datastore.Key, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+	pins, err := fs.pins()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]datastore.Key, 0, len(pins))
+	for key := range pins {
+		keys = append(keys, datastore.NewKey(key))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys, nil
+}
+
+// NewAdder creates an Adder that puts files to the store. When wrap is true
+// added files are wrapped in a directory, which is added when the adder is
+// closed
+func (fs *Filestore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	return storeutil.NewAdder(fs, pin, wrap), nil
+}
+
+// filepath gives the location of a key on disk
+func (fs *Filestore) filepath(key datastore.Key) (string, error) {
+	hash, sub, err := storeutil.SplitKey(key, PathPrefix)
+	if err != nil {
+		return "", err
+	}
+	path := fs.blockPath(hash)
+	if sub != "" {
+		path = filepath.Join(path, filepath.FromSlash(sub))
+	}
+	return path, nil
+}
+
+func (fs *Filestore) blockPath(hash string) string {
+	return filepath.Join(fs.path, "blocks", hash)
+}
+
+func (fs *Filestore) pins() (map[string]bool, error) {
+	pins := map[string]bool{}
+	data, err := ioutil.ReadFile(filepath.Join(fs.path, "pins.json"))
+	if os.IsNotExist(err) {
+		return pins, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("error reading pins: %s", err.Error())
+	}
+	return pins, nil
+}
+
+func (fs *Filestore) savePins(pins map[string]bool) error {
+	data, err := json.Marshal(pins)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(fs.path, "pins.json")
+	if err := ioutil.WriteFile(path+".tmp", data, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// writeFile writes a file or directory to path, returning it's hash
+func writeFile(path string, file cafs.File) (string, error) {
+	if !file.IsDirectory() {
+		out, err := os.Create(path)
+		if err != nil {
+			return "", err
+		}
+		h := storeutil.NewHasher()
+		if _, err := io.Copy(io.MultiWriter(out, h), file); err != nil {
+			out.Close()
+			return "", err
+		}
+		if err := out.Close(); err != nil {
+			return "", err
+		}
+		return h.Hash()
+	}
+
+	if err := os.Mkdir(path, os.ModePerm); err != nil {
+		return "", err
+	}
+	listing := storeutil.Listing{}
+	for {
+		child, err := file.NextFile()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		name := child.FileName()
+		if err := storeutil.CheckName(name); err != nil {
+			child.Close()
+			return "", err
+		}
+		h, err := writeFile(filepath.Join(path, name), child)
+		child.Close()
+		if err != nil {
+			return "", err
+		}
+		listing.Add(name, h)
+	}
+	return listing.Hash()
+}
+
+// readFile reads a file or directory from disk. directories are read into
+// memory
+func readFile(path, fullpath string) (cafs.File, error) {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, datastore.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return cafs.NewMemfileBytes(filepath.Base(fullpath), data), nil
+	}
+
+	dir := cafs.NewMemdir(fullpath)
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		child, err := readFile(filepath.Join(path, info.Name()), fullpath+"/"+info.Name())
+		if err != nil {
+			return nil, err
+		}
+		dir.AddChildren(child)
+	}
+	return dir, nil
+}
@@ -0,0 +1,198 @@
+package localfs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+)
+
+func newTestStore(t *testing.T) (*Filestore, func()) {
+	path, err := ioutil.TempDir("", "qri_localfs")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	fs, err := NewFilestore(path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return fs, func() { os.RemoveAll(path) }
+}
+
+func TestFilestore(t *testing.T) {
+	fs, cleanup := newTestStore(t)
+	defer cleanup()
+
+	key, err := fs.Put(cafs.NewMemfileBytes("a.txt", []byte("hello")), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	again, err := fs.Put(cafs.NewMemfileBytes("b.txt", []byte("hello")), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if key.String() != again.String() {
+		t.Errorf("expected identical content to share a key. %s != %s", key, again)
+	}
+
+	if has, err := fs.Has(key); err != nil || !has {
+		t.Errorf("expected store to have %s. err: %v", key, err)
+	}
+	f, err := fs.Get(key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil || string(data) != "hello" {
+		t.Errorf("data mismatch. got: %s, err: %v", data, err)
+	}
+
+	keys, err := fs.Keys()
+	if err != nil || len(keys) != 1 {
+		t.Errorf("expected 1 key, got: %v, err: %v", keys, err)
+	}
+
+	if err := fs.Delete(key); err != nil {
+		t.Fatal(err.Error())
+	}
+	if has, _ := fs.Has(key); has {
+		t.Errorf("expected %s to be deleted", key)
+	}
+	if _, err := fs.Get(key); err != datastore.ErrNotFound {
+		t.Errorf("expected getting a deleted key to return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestFilestoreDirectories(t *testing.T) {
+	fs, cleanup := newTestStore(t)
+	defer cleanup()
+
+	dir := cafs.NewMemdir("/package",
+		cafs.NewMemfileBytes("dataset.json", []byte(`{}`)),
+		cafs.NewMemfileBytes("data.csv", []byte("a,b\n1,2")),
+	)
+	key, err := fs.Put(dir, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	same := cafs.NewMemdir("/other",
+		cafs.NewMemfileBytes("data.csv", []byte("a,b\n1,2")),
+		cafs.NewMemfileBytes("dataset.json", []byte(`{}`)),
+	)
+	if k, err := fs.Put(same, false); err != nil || k.String() != key.String() {
+		t.Errorf("expected directories with the same contents to share a key. got: %s, err: %v", k, err)
+	}
+
+	f, err := fs.Get(datastore.NewKey(key.String() + "/data.csv"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data, _ := ioutil.ReadAll(f)
+	if string(data) != "a,b\n1,2" {
+		t.Errorf("data mismatch. got: %s", data)
+	}
+
+	d, err := fs.Get(key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !d.IsDirectory() {
+		t.Fatal("expected directory")
+	}
+	count := 0
+	for {
+		if _, err := d.NextFile(); err != nil {
+			break
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("expected 2 files in directory, got: %d", count)
+	}
+
+	if _, err := fs.Get(datastore.NewKey(key.String() + "/../x")); err == nil {
+		t.Error("expected paths outside of a block to error")
+	}
+}
+
+func TestFilestoreConcurrentPut(t *testing.T) {
+	fs, cleanup := newTestStore(t)
+	defer cleanup()
+
+	errs := make(chan error)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, err := fs.Put(cafs.NewMemdir("/package",
+				cafs.NewMemfileBytes("dataset.json", []byte(`{}`)),
+				cafs.NewMemfileBytes("data.csv", []byte("a,b\n1,2")),
+			), false)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error putting the same directory concurrently: %s", err.Error())
+		}
+	}
+
+	keys, err := fs.Keys()
+	if err != nil || len(keys) != 1 {
+		t.Errorf("expected 1 key, got: %v, err: %v", keys, err)
+	}
+}
+
+func TestFilestorePins(t *testing.T) {
+	fs, cleanup := newTestStore(t)
+	defer cleanup()
+
+	key, err := fs.Put(cafs.NewMemfileBytes("a.txt", []byte("pinned")), true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pinned, err := fs.Pinned()
+	if err != nil || len(pinned) != 1 || pinned[0].String() != key.String() {
+		t.Errorf("expected %s to be pinned, got: %v, err: %v", key, pinned, err)
+	}
+
+	if err := fs.Unpin(key, true); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := fs.Unpin(key, true); err == nil {
+		t.Error("expected unpinning an unpinned key to error")
+	}
+	if err := fs.Pin(datastore.NewKey("/local/QmNotAKey"), true); err != datastore.ErrNotFound {
+		t.Errorf("expected pinning a missing key to return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestAdder(t *testing.T) {
+	fs, cleanup := newTestStore(t)
+	defer cleanup()
+
+	adder, err := fs.NewAdder(true, true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var root datastore.Key
+	done := make(chan struct{})
+	go func() {
+		for added := range adder.Added() {
+			root = added.Path
+		}
+		close(done)
+	}()
+	if err := adder.AddFile(cafs.NewMemfileBytes("a.txt", []byte("a"))); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := adder.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	<-done
+
+	if _, err := fs.Get(datastore.NewKey(root.String() + "/a.txt")); err != nil {
+		t.Errorf("expected wrapped file to be in the store: %v", err)
+	}
+}
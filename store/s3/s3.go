@@ -0,0 +1,531 @@
+// Package s3store is a cafs.Filestore that keeps blocks in an S3-compatible
+// object store, with a local read cache. Blocks are hashed with storeutil,
+// the same way as the localfs store. Objects are laid out under the
+// configured prefix as:
+//
+//	blocks/[hash]              a file block
+//	blocks/[dirhash]/[name]    a file within a directory block
+//	blocks/[dirhash]/[name]/   an empty object marking a directory, so empty
+//	                           directories can be read back
+//	complete/[hash]            an empty object written once a block is uploaded
+//	pins/[hash]                an empty object marking a pinned block
+package s3store
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/ipfs/go-datastore"
+	golog "github.com/ipfs/go-log"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/store/storeutil"
+)
+
+var log = golog.Logger("s3store")
+
+// PathPrefix is the first element of every key in the store
+const PathPrefix = "s3"
+
+// Filestore is a cafs.Filestore backed by an s3 bucket
+type Filestore struct {
+	client s3iface.S3API
+	bucket string
+	prefix string
+	// cache is a local directory of blocks read from s3. blocks are immutable,
+	// so cached files never go stale
+	cache string
+}
+
+// NewFilestore connects to the bucket named in cfg. Credentials are read from
+// the environment the same way other s3 tools do, eg: $AWS_ACCESS_KEY_ID &
+// $AWS_SECRET_ACCESS_KEY
+func NewFilestore(cfg *config.Store) (*Filestore, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 store requires a bucket")
+	}
+
+	awscfg := aws.NewConfig()
+	if cfg.Region != "" {
+		awscfg = awscfg.WithRegion(cfg.Region)
+	}
+	if cfg.Endpoint != "" {
+		// S3-compatible services generally don't support bucket subdomains
+		awscfg = awscfg.WithEndpoint(cfg.Endpoint).WithS3ForcePathStyle(true)
+	}
+	sess, err := session.NewSession(awscfg)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to s3: %s", err.Error())
+	}
+
+	return NewFilestoreClient(s3.New(sess), cfg.Bucket, cfg.Prefix, cfg.CacheDir)
+}
+
+// NewFilestoreClient creates a store from an existing s3 client. cacheDir
+// may be empty to disable caching
+func NewFilestoreClient(client s3iface.S3API, bucket, prefix, cacheDir string) (*Filestore, error) {
+	if cacheDir != "" {
+		if err := os.MkdirAll(cacheDir, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("error creating s3 cache: %s", err.Error())
+		}
+	}
+	return &Filestore{
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+		cache:  cacheDir,
+	}, nil
+}
+
+// PathPrefix implements the cafs.Filestore interface
+func (fs *Filestore) PathPrefix() string {
+	return PathPrefix
+}
+
+// Put adds a file or directory to the store, returning it's key. Files are
+// spooled to a temp directory while they're hashed, then streamed to s3. A
+// block's completion marker is written after all of it's objects, so blocks
+// left partially uploaded are uploaded again by the next Put
+func (fs *Filestore) Put(file cafs.File, pin bool) (datastore.Key, error) {
+	tmp, err := ioutil.TempDir("", "qri_s3_put")
+	if err != nil {
+		return datastore.Key{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	objects := map[string]string{}
+	hash, err := spool(tmp, objects, "", file)
+	if err != nil {
+		return datastore.Key{}, err
+	}
+	key := datastore.NewKey("/" + PathPrefix + "/" + hash)
+
+	complete, err := fs.complete(hash)
+	if err != nil {
+		return key, err
+	}
+	if !complete {
+		// sort names so directory contents are written in a stable order
+		names := make([]string, 0, len(objects))
+		for name := range objects {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		uploader := s3manager.NewUploaderWithClient(fs.client)
+		for _, name := range names {
+			if strings.HasSuffix(name, "/") {
+				// directory marker
+				if err := fs.putObject(fs.blockKey(hash, strings.TrimSuffix(name, "/"))+"/", []byte{}); err != nil {
+					return key, fmt.Errorf("error adding block to s3: %s", err.Error())
+				}
+				continue
+			}
+			if err := fs.upload(uploader, fs.blockKey(hash, name), objects[name]); err != nil {
+				return key, fmt.Errorf("error adding block to s3: %s", err.Error())
+			}
+		}
+		if err := fs.putObject(fs.completeKey(hash), []byte{}); err != nil {
+			return key, fmt.Errorf("error adding block to s3: %s", err.Error())
+		}
+	}
+
+	if pin {
+		err = fs.Pin(key, true)
+	}
+	return key, err
+}
+
+// Get reads a file or directory from the store, checking the local cache
+// before s3. directories are read into memory
+func (fs *Filestore) Get(key datastore.Key) (cafs.File, error) {
+	hash, sub, err := storeutil.SplitKey(key, PathPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := fs.getObject(hash, sub)
+	if err == nil {
+		return cafs.NewMemfileBytes(path.Base(key.String()), data), nil
+	} else if err != datastore.ErrNotFound {
+		return nil, err
+	}
+
+	// no object exists at key, check for a directory
+	dirKey := fs.blockKey(hash, sub) + "/"
+	names, err := fs.list(dirKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, datastore.ErrNotFound
+	}
+
+	root := cafs.NewMemdir(key.String())
+	dirs := map[string]*cafs.Memdir{"": root}
+	for _, name := range names {
+		rel := strings.TrimPrefix(name, dirKey)
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			// directory marker
+			mkdirs(dirs, strings.TrimSuffix(rel, "/"), key.String())
+			continue
+		}
+		parent := mkdirs(dirs, path.Dir(rel), key.String())
+		data, err := fs.getObject(hash, path.Join(sub, rel))
+		if err != nil {
+			return nil, err
+		}
+		parent.AddChildren(cafs.NewMemfileBytes(path.Base(rel), data))
+	}
+	return root, nil
+}
+
+// Has checks if a key is in the store. Files are checked for directly,
+// directories by listing for anything within them
+func (fs *Filestore) Has(key datastore.Key) (bool, error) {
+	hash, sub, err := storeutil.SplitKey(key, PathPrefix)
+	if err == datastore.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	obj := fs.blockKey(hash, sub)
+	if _, err := fs.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(obj),
+	}); err == nil {
+		return true, nil
+	} else if !isNotFound(err) {
+		return false, err
+	}
+
+	res, err := fs.client.ListObjectsV2(&s3.ListObjectsV2Input{
+		Bucket:    aws.String(fs.bucket),
+		Prefix:    aws.String(obj + "/"),
+		Delimiter: aws.String("/"),
+		MaxKeys:   aws.Int64(1),
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(res.Contents) > 0 || len(res.CommonPrefixes) > 0, nil
+}
+
+// Delete removes a block & it's pin from the store. Only whole blocks can be
+// removed, not files within a directory
+func (fs *Filestore) Delete(key datastore.Key) error {
+	hash, sub, err := storeutil.SplitKey(key, PathPrefix)
+	if err != nil {
+		return err
+	}
+	if sub != "" {
+		return fmt.Errorf("can't delete %s: only blocks can be deleted, not files within them", key.String())
+	}
+
+	names, err := fs.list(fs.blockKey(hash, "") + "/")
+	if err != nil {
+		return err
+	}
+	// remove the completion marker first, so an interrupted delete leaves the
+	// block incomplete rather than missing objects
+	names = append([]string{fs.completeKey(hash)}, names...)
+	names = append(names, fs.blockKey(hash, ""), fs.pinKey(hash))
+	for _, name := range names {
+		if _, err := fs.client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(fs.bucket),
+			Key:    aws.String(name),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if fs.cache != "" {
+		return os.RemoveAll(filepath.Join(fs.cache, hash))
+	}
+	return nil
+}
+
+// Keys lists every block in the store
+func (fs *Filestore) Keys() ([]datastore.Key, error) {
+	blocks := fs.objectKey("blocks") + "/"
+	keys := []datastore.Key{}
+	err := fs.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket:    aws.String(fs.bucket),
+		Prefix:    aws.String(blocks),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, o := range page.Contents {
+			keys = append(keys, datastore.NewKey("/"+PathPrefix+"/"+strings.TrimPrefix(aws.StringValue(o.Key), blocks)))
+		}
+		for _, p := range page.CommonPrefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(aws.StringValue(p.Prefix), blocks), "/")
+			keys = append(keys, datastore.NewKey("/"+PathPrefix+"/"+name))
+		}
+		return true
+	})
+	return keys, err
+}
+
+// Pin marks a block as pinned by writing a marker object to the pin index
+func (fs *Filestore) Pin(key datastore.Key, recursive bool) error {
+	hash, _, err := storeutil.SplitKey(key, PathPrefix)
+	if err != nil {
+		return err
+	}
+	exists, err := fs.Has(datastore.NewKey("/" + PathPrefix + "/" + hash))
+	if err != nil {
+		return err
+	} else if !exists {
+		return datastore.ErrNotFound
+	}
+	return fs.putObject(fs.pinKey(hash), []byte{})
+}
+
+// Unpin removes the pin from a block
+func (fs *Filestore) Unpin(key datastore.Key, recursive bool) error {
+	hash, _, err := storeutil.SplitKey(key, PathPrefix)
+	if err != nil {
+		return err
+	}
+	if _, err := fs.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(fs.pinKey(hash)),
+	}); isNotFound(err) {
+		return fmt.Errorf("not pinned: /%s/%s", PathPrefix, hash)
+	} else if err != nil {
+		return err
+	}
+	_, err = fs.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(fs.pinKey(hash)),
+	})
+	return err
+}
+
+// Pinned lists pinned blocks
+func (fs *Filestore) Pinned() ([]datastore.Key, error) {
+	pins := fs.objectKey("pins") + "/"
+	names, err := fs.list(pins)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]datastore.Key, len(names))
+	for i, name := range names {
+		keys[i] = datastore.NewKey("/" + PathPrefix + "/" + strings.TrimPrefix(name, pins))
+	}
+	return keys, nil
+}
+
+// NewAdder creates an Adder that puts files to the store. When wrap is true
+// added files are wrapped in a directory, which is added when the adder is
+// closed
+func (fs *Filestore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	return storeutil.NewAdder(fs, pin, wrap), nil
+}
+
+// objectKey prepends the configured prefix to name
+func (fs *Filestore) objectKey(name string) string {
+	if fs.prefix == "" {
+		return name
+	}
+	return fs.prefix + "/" + name
+}
+
+func (fs *Filestore) blockKey(hash, sub string) string {
+	if sub == "" {
+		return fs.objectKey("blocks/" + hash)
+	}
+	return fs.objectKey("blocks/" + hash + "/" + sub)
+}
+
+func (fs *Filestore) pinKey(hash string) string {
+	return fs.objectKey("pins/" + hash)
+}
+
+func (fs *Filestore) completeKey(hash string) string {
+	return fs.objectKey("complete/" + hash)
+}
+
+// complete checks for a block's completion marker
+func (fs *Filestore) complete(hash string) (bool, error) {
+	_, err := fs.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(fs.completeKey(hash)),
+	})
+	if isNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// getObject reads a file from the cache, falling back to s3 & caching the
+// result
+func (fs *Filestore) getObject(hash, sub string) ([]byte, error) {
+	cached := ""
+	if fs.cache != "" {
+		cached = filepath.Join(fs.cache, hash, filepath.FromSlash(sub))
+		if fi, err := os.Stat(cached); err == nil && !fi.IsDir() {
+			return ioutil.ReadFile(cached)
+		}
+	}
+
+	res, err := fs.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(fs.blockKey(hash, sub)),
+	})
+	if isNotFound(err) {
+		return nil, datastore.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != "" {
+		if err := writeCache(cached, data); err != nil {
+			// a failed cache write doesn't prevent reading the block
+			log.Debug(err.Error())
+		}
+	}
+	return data, nil
+}
+
+func (fs *Filestore) putObject(name string, data []byte) error {
+	_, err := fs.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(name),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+// upload streams the file at path to an object
+func (fs *Filestore) upload(uploader *s3manager.Uploader, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(name),
+		Body:   f,
+	})
+	return err
+}
+
+// list gives the names of all objects that start with prefix
+func (fs *Filestore) list(prefix string) ([]string, error) {
+	names := []string{}
+	err := fs.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(fs.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, o := range page.Contents {
+			names = append(names, aws.StringValue(o.Key))
+		}
+		return true
+	})
+	return names, err
+}
+
+func writeCache(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := ioutil.WriteFile(tmp, data, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// mkdirs finds or creates the directory at rel, & any missing parents
+func mkdirs(dirs map[string]*cafs.Memdir, rel, root string) *cafs.Memdir {
+	if rel == "." {
+		rel = ""
+	}
+	if d, ok := dirs[rel]; ok {
+		return d
+	}
+	parent := mkdirs(dirs, path.Dir(rel), root)
+	d := cafs.NewMemdir(root + "/" + rel)
+	parent.AddChildren(d)
+	dirs[rel] = d
+	return d
+}
+
+func isNotFound(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case s3.ErrCodeNoSuchKey, "NotFound":
+			return true
+		}
+	}
+	return false
+}
+
+// spool writes a file or directory to temp files in dir, recording the temp
+// file for each object by it's path within the block. directories are
+// recorded by their path with a trailing slash & no temp file. returns the
+// block's hash
+func spool(dir string, objects map[string]string, name string, file cafs.File) (string, error) {
+	if !file.IsDirectory() {
+		out, err := ioutil.TempFile(dir, "object")
+		if err != nil {
+			return "", err
+		}
+		h := storeutil.NewHasher()
+		if _, err := io.Copy(io.MultiWriter(out, h), file); err != nil {
+			out.Close()
+			return "", err
+		}
+		if err := out.Close(); err != nil {
+			return "", err
+		}
+		objects[name] = out.Name()
+		return h.Hash()
+	}
+
+	objects[name+"/"] = ""
+	listing := storeutil.Listing{}
+	for {
+		child, err := file.NextFile()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		childName := child.FileName()
+		if err := storeutil.CheckName(childName); err != nil {
+			child.Close()
+			return "", err
+		}
+		h, err := spool(dir, objects, path.Join(name, childName), child)
+		child.Close()
+		if err != nil {
+			return "", err
+		}
+		listing.Add(childName, h)
+	}
+	return listing.Hash()
+}
@@ -0,0 +1,221 @@
+package s3store
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsfs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/store/storeutil"
+)
+
+// newTestStore connects to the S3-compatible service named by
+// $QRI_TEST_S3_ENDPOINT & $QRI_TEST_S3_BUCKET, skipping when they aren't set.
+// to test against a local MinIO server:
+//
+//	$ minio server /tmp/minio
+//	$ export AWS_ACCESS_KEY_ID=minioadmin AWS_SECRET_ACCESS_KEY=minioadmin AWS_REGION=us-east-1
+//	$ export QRI_TEST_S3_ENDPOINT=http://localhost:9000 QRI_TEST_S3_BUCKET=qri-test
+func newTestStore(t *testing.T) (*Filestore, string) {
+	endpoint, bucket := os.Getenv("QRI_TEST_S3_ENDPOINT"), os.Getenv("QRI_TEST_S3_BUCKET")
+	if endpoint == "" || bucket == "" {
+		t.Skip("QRI_TEST_S3_ENDPOINT & QRI_TEST_S3_BUCKET aren't set, skipping s3 tests")
+	}
+
+	cache, err := ioutil.TempDir("", "qri_s3_cache")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	fs, err := NewFilestore(&config.Store{
+		Type:     "s3",
+		Endpoint: endpoint,
+		Bucket:   bucket,
+		// give every test run it's own prefix
+		Prefix:   fmt.Sprintf("test_%d", time.Now().UnixNano()),
+		CacheDir: cache,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return fs, cache
+}
+
+func TestFilestore(t *testing.T) {
+	fs, cache := newTestStore(t)
+	defer os.RemoveAll(cache)
+
+	key, err := fs.Put(cafs.NewMemfileBytes("a.txt", []byte("hello")), true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if has, err := fs.Has(key); err != nil || !has {
+		t.Errorf("expected store to have %s. err: %v", key, err)
+	}
+
+	f, err := fs.Get(key)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if data, err := ioutil.ReadAll(f); err != nil || string(data) != "hello" {
+		t.Errorf("data mismatch. got: %s, err: %v", data, err)
+	}
+	hash, _, _ := storeutil.SplitKey(key, PathPrefix)
+	if _, err := os.Stat(filepath.Join(cache, hash)); err != nil {
+		t.Errorf("expected reading a block to cache it: %s", err.Error())
+	}
+
+	pinned, err := fs.Pinned()
+	if err != nil || len(pinned) != 1 || pinned[0].String() != key.String() {
+		t.Errorf("expected %s to be pinned, got: %v, err: %v", key, pinned, err)
+	}
+	if err := fs.Unpin(key, true); err != nil {
+		t.Error(err.Error())
+	}
+	if err := fs.Unpin(key, true); err == nil {
+		t.Error("expected unpinning an unpinned key to error")
+	}
+
+	if err := fs.Delete(key); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := fs.Get(key); err != datastore.ErrNotFound {
+		t.Errorf("expected getting a deleted key to return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestFilestoreDirectories(t *testing.T) {
+	fs, cache := newTestStore(t)
+	defer os.RemoveAll(cache)
+
+	key, err := fs.Put(cafs.NewMemdir("/package",
+		cafs.NewMemfileBytes("dataset.json", []byte(`{}`)),
+		cafs.NewMemfileBytes("data.csv", []byte("a,b\n1,2")),
+	), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	keys, err := fs.Keys()
+	if err != nil || len(keys) != 1 || keys[0].String() != key.String() {
+		t.Errorf("expected directory to be listed as a single block, got: %v, err: %v", keys, err)
+	}
+
+	f, err := fs.Get(datastore.NewKey(key.String() + "/data.csv"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if data, _ := ioutil.ReadAll(f); string(data) != "a,b\n1,2" {
+		t.Errorf("data mismatch. got: %s", data)
+	}
+}
+
+func TestFilestoreHas(t *testing.T) {
+	fs, cache := newTestStore(t)
+	defer os.RemoveAll(cache)
+
+	// "a.csv" & "a.json" sort before "a/", so a prefix listing for "a" finds
+	// them first
+	key, err := fs.Put(cafs.NewMemdir("/package",
+		cafs.NewMemfileBytes("a.csv", []byte("a,b\n1,2")),
+		cafs.NewMemfileBytes("a.json", []byte(`{}`)),
+		cafs.NewMemdir("a",
+			cafs.NewMemfileBytes("b.txt", []byte("hello")),
+		),
+	), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cases := []struct {
+		path string
+		has  bool
+	}{
+		{"", true},
+		{"/a.csv", true},
+		{"/a", true},
+		{"/a/b.txt", true},
+		{"/b.txt", false},
+		{"/a/b", false},
+	}
+	for i, c := range cases {
+		has, err := fs.Has(datastore.NewKey(key.String() + c.path))
+		if err != nil {
+			t.Errorf("case %d %q unexpected error: %s", i, c.path, err.Error())
+			continue
+		}
+		if has != c.has {
+			t.Errorf("case %d %q expected has to be %t", i, c.path, c.has)
+		}
+	}
+}
+
+func TestFilestoreEmptyDirectory(t *testing.T) {
+	fs, cache := newTestStore(t)
+	defer os.RemoveAll(cache)
+
+	key, err := fs.Put(cafs.NewMemdir("/package",
+		cafs.NewMemfileBytes("a.txt", []byte("hello")),
+		cafs.NewMemdir("empty"),
+	), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if has, err := fs.Has(datastore.NewKey(key.String() + "/empty")); err != nil || !has {
+		t.Errorf("expected store to have empty directory. err: %v", err)
+	}
+	f, err := fs.Get(datastore.NewKey(key.String() + "/empty"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !f.IsDirectory() {
+		t.Error("expected an empty directory")
+	}
+	if _, err := f.NextFile(); err != io.EOF {
+		t.Errorf("expected empty directory to have no files, got: %v", err)
+	}
+
+	empty, err := fs.Put(cafs.NewMemdir("/empty"), false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if has, err := fs.Has(empty); err != nil || !has {
+		t.Errorf("expected store to have empty directory block. err: %v", err)
+	}
+	if f, err := fs.Get(empty); err != nil || !f.IsDirectory() {
+		t.Errorf("expected empty directory block to be read back. err: %v", err)
+	}
+}
+
+func TestDsfs(t *testing.T) {
+	fs, cache := newTestStore(t)
+	defer os.RemoveAll(cache)
+
+	ds := &dataset.Dataset{
+		Meta:      &dataset.Meta{Title: "s3 dataset"},
+		Structure: &dataset.Structure{Format: dataset.CSVDataFormat, Schema: dataset.BaseSchemaArray},
+		Commit:    &dataset.Commit{Title: "initial commit"},
+	}
+	path, err := dsfs.WriteDataset(fs, ds, cafs.NewMemfileBytes("data.csv", []byte("a,b\n1,2")), true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	got, err := dsfs.LoadDataset(fs, path)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got.Meta == nil || got.Meta.Title != "s3 dataset" {
+		t.Errorf("metadata mismatch. got: %#v", got.Meta)
+	}
+	if _, err := dsfs.LoadData(fs, got); err != nil {
+		t.Errorf("error loading data: %s", err.Error())
+	}
+}
@@ -0,0 +1,150 @@
+// Package storeutil holds the block hashing, key parsing & adder shared by
+// qri's own cafs.Filestore implementations. Stores built on it hash blocks
+// the same way: files by the base58-encoded sha2-256 multihash of their
+// content, directories by the hash of their sorted listing
+package storeutil
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/cafs"
+)
+
+// SplitKey breaks a key into the block hash & the path of a file within the
+// block. Keys that don't start with prefix return datastore.ErrNotFound
+func SplitKey(key datastore.Key, prefix string) (hash, sub string, err error) {
+	parts := strings.SplitN(strings.Trim(key.String(), "/"), "/", 3)
+	if len(parts) < 2 || parts[0] != prefix || parts[1] == "" {
+		return "", "", datastore.ErrNotFound
+	}
+	hash = parts[1]
+	if len(parts) == 3 {
+		sub = parts[2]
+		for _, el := range strings.Split(sub, "/") {
+			if el == ".." || el == "." {
+				return "", "", fmt.Errorf("invalid path: %s", key.String())
+			}
+		}
+	}
+	return hash, sub, nil
+}
+
+// CheckName returns an error if name can't be used for a file within a
+// directory block
+func CheckName(name string) error {
+	if name == "" || strings.Contains(name, "/") || name == "." || name == ".." {
+		return fmt.Errorf("invalid file name in directory: '%s'", name)
+	}
+	return nil
+}
+
+// Hash gives the hash of a file's content
+func Hash(data []byte) (string, error) {
+	mh, err := multihash.Sum(data, multihash.SHA2_256, -1)
+	if err != nil {
+		return "", err
+	}
+	return mh.B58String(), nil
+}
+
+// Hasher hashes file content as it's written, so files don't need to be
+// held in memory to be hashed
+type Hasher struct {
+	h hash.Hash
+}
+
+// NewHasher creates a Hasher
+func NewHasher() *Hasher {
+	return &Hasher{h: sha256.New()}
+}
+
+// Write implements the io.Writer interface
+func (h *Hasher) Write(p []byte) (int, error) {
+	return h.h.Write(p)
+}
+
+// Hash gives the hash of everything written so far. it matches Hash for the
+// same content
+func (h *Hasher) Hash() (string, error) {
+	mh, err := multihash.Encode(h.h.Sum(nil), multihash.SHA2_256)
+	if err != nil {
+		return "", err
+	}
+	return multihash.Multihash(mh).B58String(), nil
+}
+
+// Listing accumulates the entries of a directory block
+type Listing []string
+
+// Add records a file within the directory
+func (l *Listing) Add(name, hash string) {
+	*l = append(*l, fmt.Sprintf("%s\t%s", name, hash))
+}
+
+// Hash gives the hash of the directory
+func (l Listing) Hash() (string, error) {
+	sort.Strings(l)
+	return Hash([]byte(strings.Join(l, "\n")))
+}
+
+// NewAdder creates a cafs.Adder that puts files to store. When wrap is true
+// added files are wrapped in a directory, which is added when the adder is
+// closed
+func NewAdder(store cafs.Filestore, pin, wrap bool) cafs.Adder {
+	return &adder{
+		store: store,
+		pin:   pin,
+		wrap:  wrap,
+		out:   make(chan cafs.AddedFile, 16),
+	}
+}
+
+// adder implements the cafs.Adder interface
+type adder struct {
+	store cafs.Filestore
+	pin   bool
+	wrap  bool
+	out   chan cafs.AddedFile
+	files []cafs.File
+}
+
+// AddFile adds a file to the store, or holds it for wrapping
+func (a *adder) AddFile(f cafs.File) error {
+	if a.wrap {
+		a.files = append(a.files, f)
+		return nil
+	}
+	key, err := a.store.Put(f, a.pin)
+	if err != nil {
+		return err
+	}
+	a.out <- cafs.AddedFile{Path: key, Name: f.FileName(), Hash: key.String()}
+	return nil
+}
+
+// Added gives a channel of added files
+func (a *adder) Added() chan cafs.AddedFile {
+	return a.out
+}
+
+// Close adds the wrapping directory if needed, closing the Added channel
+func (a *adder) Close() error {
+	defer close(a.out)
+	if !a.wrap {
+		return nil
+	}
+	dir := cafs.NewMemdir("/")
+	dir.AddChildren(a.files...)
+	key, err := a.store.Put(dir, a.pin)
+	if err != nil {
+		return err
+	}
+	a.out <- cafs.AddedFile{Path: key, Name: "", Hash: key.String()}
+	return nil
+}
@@ -0,0 +1,50 @@
+package storeutil
+
+import (
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+)
+
+func TestSplitKey(t *testing.T) {
+	cases := []struct {
+		key, hash, sub string
+		err            string
+	}{
+		{"/local/QmHash", "QmHash", "", ""},
+		{"/local/QmHash/a/b.txt", "QmHash", "a/b.txt", ""},
+		{"/local/QmHash/../b.txt", "", "", "invalid path: /local/QmHash/../b.txt"},
+		{"/ipfs/QmHash", "", "", datastore.ErrNotFound.Error()},
+		{"/local", "", "", datastore.ErrNotFound.Error()},
+	}
+
+	for i, c := range cases {
+		hash, sub, err := SplitKey(datastore.NewKey(c.key), "local")
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: '%s', got: '%v'", i, c.err, err)
+			continue
+		}
+		if hash != c.hash || sub != c.sub {
+			t.Errorf("case %d expected: %s %s, got: %s %s", i, c.hash, c.sub, hash, sub)
+		}
+	}
+}
+
+func TestHasher(t *testing.T) {
+	data := []byte("hello world")
+	expect, err := Hash(data)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	h := NewHasher()
+	h.Write(data[:5])
+	h.Write(data[5:])
+	got, err := h.Hash()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if got != expect {
+		t.Errorf("expected streamed hash to match: %s != %s", got, expect)
+	}
+}
@@ -0,0 +1,123 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Cursor tracks the events a webhook is done with. Events are identified by
+// content rather than position or time, so events logged out of order by
+// different processes are still sent
+type Cursor struct {
+	// Added is when the webhook was added. Events logged before then aren't
+	// sent to it
+	Added time.Time `json:"added"`
+	// Done holds the IDs of events that were delivered, failed every attempt,
+	// or don't match the webhook. IDs are dropped once their event leaves the
+	// live event log
+	Done map[string]bool `json:"done,omitempty"`
+}
+
+// EventID identifies an event by it's content
+func EventID(e *repo.Event) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%s", e.Time.UTC().Format(time.RFC3339Nano), e.Type, e.Ref.String())))
+	return hex.EncodeToString(sum[:16])
+}
+
+// CursorStore keeps a cursor for each webhook, keyed by webhook ID
+type CursorStore interface {
+	// Cursors gives every cursor
+	Cursors() (map[string]*Cursor, error)
+	// PutCursors replaces all cursors
+	PutCursors(cursors map[string]*Cursor) error
+}
+
+// StartCursor gives a webhook a cursor that starts at t, leaving any others
+// in the store as is
+func StartCursor(cs CursorStore, id string, t time.Time) error {
+	cursors, err := cs.Cursors()
+	if err != nil {
+		return err
+	}
+	cursors[id] = &Cursor{Added: t, Done: map[string]bool{}}
+	return cs.PutCursors(cursors)
+}
+
+// MemCursors is an in-memory implementation of the CursorStore interface
+type MemCursors map[string]*Cursor
+
+// Cursors implements the CursorStore interface
+func (m MemCursors) Cursors() (map[string]*Cursor, error) {
+	res := map[string]*Cursor{}
+	for id, c := range m {
+		res[id] = copyCursor(c)
+	}
+	return res, nil
+}
+
+// PutCursors implements the CursorStore interface
+func (m MemCursors) PutCursors(cursors map[string]*Cursor) error {
+	for id := range m {
+		delete(m, id)
+	}
+	for id, c := range cursors {
+		m[id] = copyCursor(c)
+	}
+	return nil
+}
+
+func copyCursor(c *Cursor) *Cursor {
+	cp := &Cursor{Added: c.Added, Done: map[string]bool{}}
+	for id := range c.Done {
+		cp.Done[id] = true
+	}
+	return cp
+}
+
+// fileCursorsLock serializes access to cursor files
+var fileCursorsLock sync.Mutex
+
+// FileCursors is a CursorStore that keeps cursors in a json file
+type FileCursors string
+
+// Cursors implements the CursorStore interface
+func (path FileCursors) Cursors() (map[string]*Cursor, error) {
+	fileCursorsLock.Lock()
+	defer fileCursorsLock.Unlock()
+
+	cursors := map[string]*Cursor{}
+	data, err := ioutil.ReadFile(string(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cursors, nil
+		}
+		return nil, fmt.Errorf("error reading webhook cursors: %s", err.Error())
+	}
+	if err := json.Unmarshal(data, &cursors); err != nil {
+		return nil, fmt.Errorf("error decoding webhook cursors: %s", err.Error())
+	}
+	return cursors, nil
+}
+
+// PutCursors implements the CursorStore interface
+func (path FileCursors) PutCursors(cursors map[string]*Cursor) error {
+	fileCursorsLock.Lock()
+	defer fileCursorsLock.Unlock()
+
+	data, err := json.Marshal(cursors)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(string(path), data, os.ModePerm); err != nil {
+		return fmt.Errorf("error writing webhook cursors: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qri-io/qri/repo"
+)
+
+// Delivery records an attempt to send an event to a webhook
+type Delivery struct {
+	ID        string         `json:"id"`
+	WebhookID string         `json:"webhookID"`
+	URL       string         `json:"url"`
+	EventType repo.EventType `json:"eventType"`
+	Ref       string         `json:"ref,omitempty"`
+	Time      time.Time      `json:"time"`
+	// Attempts is the number of requests made
+	Attempts int `json:"attempts"`
+	// Status is the http status code of the last response, 0 if no response
+	// was received
+	Status  int    `json:"status"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DeliveryLog keeps a record of webhook deliveries
+type DeliveryLog interface {
+	LogDelivery(d *Delivery) error
+	// Deliveries lists deliveries, newest first
+	Deliveries(limit, offset int) ([]*Delivery, error)
+}
+
+// fileLogLock serializes writes to delivery log files
+var fileLogLock sync.Mutex
+
+// FileDeliveryLog is a DeliveryLog that appends newline-delimited json to a
+// file
+type FileDeliveryLog string
+
+// LogDelivery implements the DeliveryLog interface
+func (path FileDeliveryLog) LogDelivery(d *Delivery) error {
+	fileLogLock.Lock()
+	defer fileLogLock.Unlock()
+
+	f, err := os.OpenFile(string(path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error opening delivery log: %s", err.Error())
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(d)
+}
+
+// Deliveries implements the DeliveryLog interface
+func (path FileDeliveryLog) Deliveries(limit, offset int) ([]*Delivery, error) {
+	fileLogLock.Lock()
+	defer fileLogLock.Unlock()
+
+	all := []*Delivery{}
+	f, err := os.Open(string(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return all, nil
+		}
+		return nil, fmt.Errorf("error opening delivery log: %s", err.Error())
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		d := &Delivery{}
+		if err := json.Unmarshal(sc.Bytes(), d); err != nil {
+			return nil, fmt.Errorf("error reading delivery log: %s", err.Error())
+		}
+		all = append(all, d)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("error reading delivery log: %s", err.Error())
+	}
+
+	res := []*Delivery{}
+	for i := len(all) - 1 - offset; i >= 0 && len(res) < limit; i-- {
+		res = append(res, all[i])
+	}
+	return res, nil
+}
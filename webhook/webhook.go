@@ -0,0 +1,311 @@
+// Package webhook delivers repo events to urls configured as webhooks.
+// Deliveries are POST requests with the event as a json body, signed with
+// an HMAC of the body using the webhook's secret. The repo's event log acts as
+// the queue of events to send: each webhook has a cursor of the events it's
+// done with, and any other event logged after the webhook was added is sent
+// the next time the log is caught up, no matter which process logged it.
+// Failed deliveries are retried with exponential backoff, and every delivery
+// is recorded in a DeliveryLog
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	golog "github.com/ipfs/go-log"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+)
+
+var log = golog.Logger("webhook")
+
+func init() {
+	golog.SetLogLevel("webhook", "info")
+}
+
+const (
+	// SignatureHeader holds the hex-encoded HMAC-SHA256 of the request body,
+	// prefixed with "sha256="
+	SignatureHeader = "X-Qri-Signature"
+	// EventHeader holds the type of event being delivered
+	EventHeader = "X-Qri-Event"
+	// DeliveryHeader holds a unique identifier for each delivery
+	DeliveryHeader = "X-Qri-Delivery"
+)
+
+// Sign creates the signature of a delivery body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature created by Sign, for use by receivers
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// NewID creates a random identifier, used for webhook IDs & secrets
+func NewID(size int) string {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// Matches returns true if a webhook should be sent an event type
+func Matches(hook *config.Webhook, t repo.EventType) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, et := range hook.Events {
+		if repo.EventType(et) == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher sends events to webhooks
+type Dispatcher struct {
+	lk      sync.Mutex
+	hooks   func() config.Webhooks
+	log     DeliveryLog
+	cursors CursorStore
+	client  *http.Client
+	// sending holds deliveries that haven't reached a final state, keyed by
+	// webhook ID & event ID
+	sending map[string]bool
+
+	// MaxAttempts is the number of times a delivery is tried before it's
+	// recorded as failed
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubling with each retry
+	Backoff time.Duration
+}
+
+// NewDispatcher creates a Dispatcher. hooks is called on each catch up, so
+// webhooks added or removed since the dispatcher was created are picked up.
+// log is optional, cursors default to an in-memory store
+func NewDispatcher(hooks func() config.Webhooks, dl DeliveryLog, cursors CursorStore) *Dispatcher {
+	if cursors == nil {
+		cursors = MemCursors{}
+	}
+	return &Dispatcher{
+		hooks:       hooks,
+		log:         dl,
+		cursors:     cursors,
+		sending:     map[string]bool{},
+		client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+	}
+}
+
+// Run catches up with a repo's event log, then catches up again each time
+// the subscription gets an event, until it's closed
+func (d *Dispatcher) Run(r repo.Repo, sub *repo.Subscription) {
+	d.catchup(r)
+	for range sub.Events() {
+		d.catchup(r)
+	}
+}
+
+func (d *Dispatcher) catchup(r repo.Repo) {
+	if _, err := d.Catchup(r); err != nil {
+		log.Infof("error sending webhooks: %s", err.Error())
+	}
+}
+
+// Catchup sends each webhook every matching event it isn't done with yet.
+// Webhooks without a cursor start from now. Each delivery happens in it's
+// own goroutine so a slow webhook doesn't hold up the others, and an event
+// is only marked done once it's delivery reaches a final state, so events
+// being sent when the process exits are sent again. Catchup returns the
+// number of deliveries started
+func (d *Dispatcher) Catchup(r repo.Repo) (int, error) {
+	d.lk.Lock()
+	defer d.lk.Unlock()
+
+	var hooks config.Webhooks
+	if d.hooks != nil {
+		hooks = d.hooks()
+	}
+	cursors, err := d.cursors.Cursors()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	since := now
+	for _, hook := range hooks {
+		c, ok := cursors[hook.ID]
+		if !ok {
+			c = &Cursor{Added: now}
+			cursors[hook.ID] = c
+		}
+		if c.Done == nil {
+			c.Done = map[string]bool{}
+		}
+		if c.Added.Before(since) {
+			since = c.Added
+		}
+	}
+	events, err := r.EventsSince(since)
+	if err != nil {
+		return 0, err
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	live := map[string]bool{}
+	for _, e := range events {
+		live[EventID(e)] = true
+	}
+
+	started := 0
+	next := map[string]*Cursor{}
+	for _, hook := range hooks {
+		c := cursors[hook.ID]
+		for id := range c.Done {
+			if !live[id] {
+				delete(c.Done, id)
+			}
+		}
+		for _, e := range events {
+			id := EventID(e)
+			key := hook.ID + "/" + id
+			if c.Done[id] || d.sending[key] || !e.Time.After(c.Added) {
+				continue
+			}
+			if !Matches(hook, e.Type) {
+				c.Done[id] = true
+				continue
+			}
+			d.sending[key] = true
+			go d.send(hook, e, id)
+			started++
+		}
+		next[hook.ID] = c
+	}
+
+	return started, d.cursors.PutCursors(next)
+}
+
+// send delivers an event, then marks it done in the webhook's cursor
+func (d *Dispatcher) send(hook *config.Webhook, e *repo.Event, id string) {
+	d.Deliver(hook, e)
+
+	d.lk.Lock()
+	defer d.lk.Unlock()
+	delete(d.sending, hook.ID+"/"+id)
+
+	cursors, err := d.cursors.Cursors()
+	if err != nil {
+		log.Infof("error marking webhook delivery done: %s", err.Error())
+		return
+	}
+	c, ok := cursors[hook.ID]
+	if !ok {
+		// the webhook was removed while sending
+		return
+	}
+	if c.Done == nil {
+		c.Done = map[string]bool{}
+	}
+	c.Done[id] = true
+	if err := d.cursors.PutCursors(cursors); err != nil {
+		log.Infof("error marking webhook delivery done: %s", err.Error())
+	}
+}
+
+// Deliver sends an event to a webhook, retrying failed attempts. The result
+// is recorded in the dispatcher's delivery log & returned
+func (d *Dispatcher) Deliver(hook *config.Webhook, e *repo.Event) *Delivery {
+	del := &Delivery{
+		ID:        NewID(8),
+		WebhookID: hook.ID,
+		URL:       hook.URL,
+		EventType: e.Type,
+		Ref:       e.Ref.String(),
+		Time:      time.Now(),
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		del.Error = err.Error()
+		d.record(del)
+		return del
+	}
+
+	wait := d.Backoff
+	for del.Attempts < d.MaxAttempts {
+		if del.Attempts > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		del.Attempts++
+
+		del.Status, err = d.post(hook, del.ID, e.Type, body)
+		if err == nil {
+			del.Success = true
+			del.Error = ""
+			break
+		}
+		del.Error = err.Error()
+		log.Debugf("webhook %s delivery %s attempt %d failed: %s", hook.ID, del.ID, del.Attempts, err.Error())
+
+		// client errors won't be fixed by trying again, unless we're being
+		// rate limited
+		if del.Status >= 400 && del.Status < 500 && del.Status != http.StatusTooManyRequests {
+			break
+		}
+	}
+
+	if !del.Success {
+		log.Infof("webhook %s delivery of %s event failed after %d attempts: %s", hook.ID, e.Type, del.Attempts, del.Error)
+	}
+	d.record(del)
+	return del
+}
+
+func (d *Dispatcher) post(hook *config.Webhook, id string, t repo.EventType, body []byte) (int, error) {
+	req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(t))
+	req.Header.Set(DeliveryHeader, id)
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status: %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func (d *Dispatcher) record(del *Delivery) {
+	if d.log == nil {
+		return
+	}
+	if err := d.log.LogDelivery(del); err != nil {
+		log.Infof("error recording webhook delivery: %s", err.Error())
+	}
+}
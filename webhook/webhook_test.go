@@ -0,0 +1,221 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qri-io/cafs"
+	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+func TestDispatcher(t *testing.T) {
+	var (
+		lk       sync.Mutex
+		requests int
+		verified bool
+	)
+	// fail the first request to exercise retries
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lk.Lock()
+		defer lk.Unlock()
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		verified = Verify("secret", body, r.Header.Get(SignatureHeader)) && r.Header.Get(EventHeader) == "ds_created"
+	}))
+	defer server.Close()
+
+	path := filepath.Join(os.TempDir(), "qri_webhook_deliveries.json")
+	defer os.Remove(path)
+
+	r, err := repo.NewMemRepo(&profile.Profile{}, cafs.NewMapstore(), profile.MemStore{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	hook := &config.Webhook{ID: "a1", URL: server.URL, Events: []string{"ds_created"}, Secret: "secret"}
+	cursors := MemCursors{"a1": {Added: time.Now()}}
+	d := NewDispatcher(func() config.Webhooks { return config.Webhooks{hook} }, FileDeliveryLog(path), cursors)
+	d.Backoff = time.Millisecond
+
+	// events logged before the dispatcher runs, as if by another process
+	if err := r.LogEvent(repo.ETDsPinned, repo.DatasetRef{Peername: "peer", Name: "movies"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.LogEvent(repo.ETDsCreated, repo.DatasetRef{Peername: "peer", Name: "movies"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if n, err := d.Catchup(r); err != nil {
+		t.Fatal(err.Error())
+	} else if n != 1 {
+		t.Errorf("expected only the matching event to be sent, started %d deliveries", n)
+	}
+	if n, err := d.Catchup(r); err != nil {
+		t.Fatal(err.Error())
+	} else if n != 0 {
+		t.Errorf("expected catching up again to send nothing, started %d deliveries", n)
+	}
+
+	var dels []*Delivery
+	for i := 0; i < 100; i++ {
+		var err error
+		if dels, err = FileDeliveryLog(path).Deliveries(10, 0); err != nil {
+			t.Fatal(err.Error())
+		} else if len(dels) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(dels) != 1 {
+		t.Fatalf("expected only the matching event to be delivered, got %d deliveries", len(dels))
+	}
+	if !dels[0].Success || dels[0].Attempts != 2 || dels[0].Status != http.StatusOK {
+		t.Errorf("expected delivery to succeed on the second attempt, got: %#v", dels[0])
+	}
+	lk.Lock()
+	defer lk.Unlock()
+	if !verified {
+		t.Error("expected delivery to be signed with the webhook secret")
+	}
+}
+
+func TestDeliverClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(nil, nil, nil)
+	del := d.Deliver(&config.Webhook{ID: "a1", URL: server.URL}, &repo.Event{Type: repo.ETDsCreated})
+	if del.Success || del.Attempts != 1 || del.Status != http.StatusNotFound {
+		t.Errorf("expected a single failed attempt, got: %#v", del)
+	}
+}
+
+func TestRunNewWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	r, err := repo.NewMemRepo(&profile.Profile{}, cafs.NewMapstore(), profile.MemStore{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := r.LogEvent(repo.ETDsCreated, repo.DatasetRef{Peername: "peer", Name: "movies"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dl := &memDeliveryLog{}
+	hook := &config.Webhook{ID: "a1", URL: server.URL}
+	d := NewDispatcher(func() config.Webhooks { return config.Webhooks{hook} }, dl, nil)
+
+	// the first catch up starts the new webhook's cursor
+	if n, err := d.Catchup(r); err != nil {
+		t.Fatal(err.Error())
+	} else if n != 0 {
+		t.Errorf("expected a new webhook not to be sent events logged before it was added, started %d deliveries", n)
+	}
+
+	sub := r.Subscribe(repo.EventFilter{})
+	done := make(chan bool)
+	go func() {
+		d.Run(r, sub)
+		done <- true
+	}()
+
+	if err := r.LogEvent(repo.ETDsRenamed, repo.DatasetRef{Peername: "peer", Name: "films"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var dels []*Delivery
+	for i := 0; i < 100 && len(dels) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		dels, _ = dl.Deliveries(10, 0)
+	}
+	sub.Close()
+	<-done
+
+	if len(dels) != 1 || dels[0].EventType != repo.ETDsRenamed {
+		t.Errorf("expected a new webhook to only be sent events logged after it's added, got: %#v", dels)
+	}
+}
+
+// memDeliveryLog is a DeliveryLog for tests
+type memDeliveryLog struct {
+	lk   sync.Mutex
+	dels []*Delivery
+}
+
+func (l *memDeliveryLog) LogDelivery(d *Delivery) error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.dels = append([]*Delivery{d}, l.dels...)
+	return nil
+}
+
+func (l *memDeliveryLog) Deliveries(limit, offset int) ([]*Delivery, error) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	res := []*Delivery{}
+	for i := offset; i < len(l.dels) && len(res) < limit; i++ {
+		res = append(res, l.dels[i])
+	}
+	return res, nil
+}
+
+func TestCatchupOutOfOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	r, err := repo.NewMemRepo(&profile.Profile{}, cafs.NewMapstore(), profile.MemStore{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	added := time.Now()
+	if err := r.LogEvent(repo.ETDsCreated, repo.DatasetRef{Peername: "peer", Name: "movies"}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dl := &memDeliveryLog{}
+	hook := &config.Webhook{ID: "a1", URL: server.URL}
+	d := NewDispatcher(func() config.Webhooks { return config.Webhooks{hook} }, dl, MemCursors{"a1": {Added: added}})
+
+	if n, err := d.Catchup(r); err != nil {
+		t.Fatal(err.Error())
+	} else if n != 1 {
+		t.Fatalf("expected 1 delivery, started %d", n)
+	}
+	for i := 0; i < 100; i++ {
+		if dels, _ := dl.Deliveries(10, 0); len(dels) == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// another process logs an event with an earlier timestamp after the
+	// newer one was sent
+	mr := r.(*repo.MemRepo)
+	*mr.MemEventLog = append(*mr.MemEventLog, &repo.Event{
+		Time: added.Add(time.Nanosecond),
+		Type: repo.ETDsPinned,
+		Ref:  repo.DatasetRef{Peername: "peer", Name: "movies"},
+	})
+
+	if n, err := d.Catchup(r); err != nil {
+		t.Fatal(err.Error())
+	} else if n != 1 {
+		t.Errorf("expected the late event to be sent, started %d deliveries", n)
+	}
+}